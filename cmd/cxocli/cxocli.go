@@ -75,6 +75,14 @@ var (
 		"root tree ",
 		"last root ",
 
+		// pins
+
+		"pin root ",
+		"pin object ",
+		"unpin root ",
+		"unpin object ",
+		"list pins ",
+
 		// stat
 
 		"stat ",
@@ -284,8 +292,16 @@ func (c *client) mapping() map[string]func(in []string) (err error) {
 		"root tree": c.rootTree,
 		"last root": c.lastRoot,
 
+		"pin root":     c.pinRoot,
+		"pin object":   c.pinObject,
+		"unpin root":   c.unpinRoot,
+		"unpin object": c.unpinObject,
+		"list pins":    c.listPins,
+
 		"stat": c.stat,
 
+		"evidence": c.evidence,
+
 		"help": c.help,
 
 		"quit": c.quit,
@@ -714,6 +730,142 @@ func (c *client) lastRoot(in []string) (err error) {
 	return
 }
 
+//
+// pins
+//
+
+// optional [duration [label]] arguments of a pin
+func argsPin(in []string) (label string, expire time.Time, err error) {
+
+	if len(in) == 0 {
+		return
+	}
+
+	var ttl time.Duration
+	if ttl, err = time.ParseDuration(in[0]); err != nil {
+		return
+	}
+
+	if ttl > 0 {
+		expire = time.Now().Add(ttl)
+	}
+
+	label = strings.Join(in[1:], " ")
+	return
+}
+
+func (c *client) pinRoot(in []string) (err error) {
+
+	if len(in) < 3 {
+		_, err = c.argsRoot(in) // missing arguments
+		return
+	}
+
+	var sl node.RootSelector
+	if sl, err = c.argsRoot(in[:3]); err != nil {
+		return
+	}
+
+	var (
+		label  string
+		expire time.Time
+	)
+
+	if label, expire, err = argsPin(in[3:]); err != nil {
+		return
+	}
+
+	var p *skyobject.Pin
+	p, err = c.r.Pin().Root(sl.Feed, sl.Nonce, sl.Seq, label, expire)
+	if err != nil {
+		return
+	}
+
+	fmt.Fprintln(out, "  pinned", p.String())
+	return
+}
+
+func (c *client) pinObject(in []string) (err error) {
+
+	if len(in) == 0 {
+		return errors.New("missing argument: expected hash")
+	}
+
+	var hash cipher.SHA256
+	if hash, err = cipher.SHA256FromHex(in[0]); err != nil {
+		return
+	}
+
+	var (
+		label  string
+		expire time.Time
+	)
+
+	if label, expire, err = argsPin(in[1:]); err != nil {
+		return
+	}
+
+	var p *skyobject.Pin
+	if p, err = c.r.Pin().Object(hash, label, expire); err != nil {
+		return
+	}
+
+	fmt.Fprintln(out, "  pinned", p.String())
+	return
+}
+
+func (c *client) unpinRoot(in []string) (err error) {
+	var sl node.RootSelector
+	if sl, err = c.argsRoot(in); err != nil {
+		return
+	}
+	return c.r.Pin().UnpinRoot(sl.Feed, sl.Nonce, sl.Seq)
+}
+
+func (c *client) unpinObject(in []string) (err error) {
+
+	var one string
+	if one, err = c.argsOne(in, "hash"); err != nil {
+		return
+	}
+
+	var hash cipher.SHA256
+	if hash, err = cipher.SHA256FromHex(one); err != nil {
+		return
+	}
+
+	return c.r.Pin().UnpinObject(hash)
+}
+
+func (c *client) listPins(in []string) (err error) {
+
+	if err = c.argsNo(in); err != nil {
+		return
+	}
+
+	var ps []*skyobject.Pin
+	if ps, err = c.r.Pin().List(); err != nil {
+		return
+	}
+
+	if len(ps) == 0 {
+		fmt.Fprintln(out, "  no pins")
+		return
+	}
+
+	var now = time.Now().UnixNano()
+
+	for _, p := range ps {
+		if p.IsExpired(now) == true {
+			fmt.Fprintln(out, "  -", p.String(), "(expired)")
+			continue
+		}
+		fmt.Fprintln(out, "  -", p.String())
+	}
+
+	return
+}
+
 //
 // stat
 //
@@ -724,6 +876,30 @@ func (c *client) printRootStat(rs skyobject.RootStat) {
 	fmt.Fprintln(out, "      seq: ", rs.Seq)
 }
 
+func (c *client) printFillings(fps []*node.FillProgress) {
+
+	if len(fps) == 0 {
+		fmt.Fprintln(out, "  no fillings")
+		return
+	}
+
+	fmt.Fprintln(out, "  fillings")
+
+	for _, fp := range fps {
+		fmt.Fprintln(out, "  -", fp.Pub.Hex(), fp.Nonce, fp.Seq)
+		fmt.Fprintln(out, "      hash:     ", fp.Hash.Hex())
+		fmt.Fprintf(out, "      objects:   %d of ~%d (%d received, %d bytes)\n",
+			fp.Known, fp.Estimated, fp.Received, fp.Volume)
+		fmt.Fprintf(out, "      requests:  %d (%d queued)\n", fp.Requests,
+			fp.Queued)
+		fmt.Fprintln(out, "      elapsed:  ", fp.Elapsed)
+		for addr, n := range fp.Conns {
+			fmt.Fprintf(out, "      from:      %s (%d objects)\n", addr, n)
+		}
+	}
+
+}
+
 func round(f float64) (s string) {
 	return fmt.Sprintf("%.2f", f)
 }
@@ -764,6 +940,10 @@ func (c *client) stat(in []string) (err error) {
 
 	fmt.Fprintln(out, "  new Root objects per second:    ", s.RootsPerSecond)
 
+	fmt.Fprintln(out, "  evidence of equivocation:       ", s.Evidence)
+
+	c.printFillings(s.Fillings)
+
 	if len(s.Feeds) == 0 {
 		fmt.Fprintln(out, "  no feeds")
 		return
@@ -772,6 +952,10 @@ func (c *client) stat(in []string) (err error) {
 	for pk, fs := range s.Feeds {
 		fmt.Fprintln(out, " ", pk.Hex())
 
+		if fs.Evidence > 0 {
+			fmt.Fprintln(out, "    evidence of equivocation:", fs.Evidence)
+		}
+
 		if len(fs.Heads) == 0 {
 			fmt.Fprintln(out, "    no heads")
 			continue
@@ -800,6 +984,36 @@ func (c *client) stat(in []string) (err error) {
 	return
 }
 
+func (c *client) evidence(in []string) (err error) {
+
+	var pk cipher.PubKey // blank for all
+
+	if len(in) != 0 {
+		if pk, err = c.argsFeed(in); err != nil {
+			return
+		}
+	}
+
+	var evs []*skyobject.Evidence
+	if evs, err = c.r.Node().Evidence(pk); err != nil {
+		return
+	}
+
+	if len(evs) == 0 {
+		fmt.Fprintln(out, "  no evidence")
+		return
+	}
+
+	for _, ev := range evs {
+		fmt.Fprintln(out, "  -", ev.String())
+		fmt.Fprintln(out, "    feed:  ", ev.Pub.Hex())
+		fmt.Fprintln(out, "    first: ", cipher.SumSHA256(ev.First.Value).Hex())
+		fmt.Fprintln(out, "    second:", cipher.SumSHA256(ev.Second.Value).Hex())
+	}
+
+	return
+}
+
 func (c *client) help(in []string) (err error) {
 	fmt.Fprint(out, `
 
@@ -849,9 +1063,24 @@ func (c *client) help(in []string) (err error) {
     show info about last Root of given feed
 
 
+  pin root <public key> <nonce> <seq> [duration [label]]
+    pin selected Root, use zero duration for never expiring pin
+  pin object <hash> [duration [label]]
+    pin object, use zero duration for never expiring pin
+  unpin root <public key> <nonce> <seq>
+    remove pin of selected Root
+  unpin object <hash>
+    remove pin of object
+  list pins
+    show all pins
+
+
   stat
     show statistic of node
 
+  evidence [public key]
+    show evidence of equivocation of given feed or all
+
 
   help
     show this help messege
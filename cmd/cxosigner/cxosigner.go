@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/skyobject"
+	"github.com/skycoin/cxo/skyobject/signer"
+)
+
+func waitInterrupt() {
+	var sig = make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	<-sig
+}
+
+func main() {
+
+	var (
+		socket = filepath.Join(skyobject.DataDir(), "signer", "signer.sock")
+		keys   = "cxosigner.keys"
+		gen    bool
+	)
+
+	flag.StringVar(&socket, "socket", socket,
+		"path to Unix socket, the directory must have 0700 mode")
+	flag.StringVar(&keys, "keys", keys, "file with secret keys")
+	flag.BoolVar(&gen, "gen", false, "generate new key, add it and exit")
+
+	flag.Parse()
+
+	if gen == true {
+		if err := generateKey(keys); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	var sks, err = readKeys(keys)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var s *signer.Server
+	if s, err = signer.NewServer(sks...); err != nil {
+		log.Fatal(err)
+	}
+
+	if err = s.Listen(socket); err != nil {
+		log.Fatal(err)
+	}
+	defer s.Close()
+
+	for _, pk := range s.PubKeys() {
+		log.Print("key ", pk.Hex())
+	}
+
+	log.Print("listen on ", s.Address())
+
+	// waiting for SIGINT
+	waitInterrupt()
+}
+
+// read hex-encoded secret keys, one per line; empty
+// lines and lines started with # are ignored
+func readKeys(name string) (sks []cipher.SecKey, err error) {
+
+	var fl *os.File
+	if fl, err = os.Open(name); err != nil {
+		return
+	}
+	defer fl.Close()
+
+	var sc = bufio.NewScanner(fl)
+
+	for ln := 1; sc.Scan(); ln++ {
+
+		var line = strings.TrimSpace(sc.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var sk cipher.SecKey
+		if sk, err = cipher.SecKeyFromHex(line); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", name, ln, err)
+		}
+
+		sks = append(sks, sk)
+	}
+
+	err = sc.Err()
+	return
+}
+
+// generate new key pair and append the secret
+// key to the file, the public key printed
+func generateKey(name string) (err error) {
+
+	var pk, sk = cipher.GenerateKeyPair()
+
+	var fl *os.File
+	fl, err = os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer fl.Close()
+
+	if _, err = fmt.Fprintf(fl, "# %s\n%s\n", pk.Hex(), sk.Hex()); err != nil {
+		return
+	}
+
+	fmt.Println(pk.Hex())
+	return
+}
@@ -12,6 +12,11 @@
 // And the same for Root objects. The CXO keeps all
 // Root objects. But who interest old, replaced Root
 // objects?
+//
+// Pinned Root objects and pinned objects (see Pin
+// of the skyobject package) are never removed. But
+// expired pins are removed first. The same for
+// objects of interrupted fillings (see Filling)
 package cxoutils
 
 import (
@@ -27,9 +32,14 @@ import (
 // the Container and all heads.
 //
 // If a feed contains more then one head, then the method
-// keeps last n-th Root objects of every head.
+// keeps last n-th Root objects of every head. Pinned Root
+// objects are skipped.
 func RemoveRootObjects(c *skyobject.Container, keepLast int) (err error) {
 
+	if err = c.DelExpiredPins(); err != nil {
+		return
+	}
+
 	for _, pk := range c.Feeds() {
 
 		var heads []uint64
@@ -54,6 +64,10 @@ func RemoveRootObjects(c *skyobject.Container, keepLast int) (err error) {
 			for ; goDown > 0; goDown-- {
 
 				if err = c.DelRoot(pk, nonce, goDown); err != nil {
+					if err == skyobject.ErrRootIsPinned {
+						err = nil // keep pinned
+						continue
+					}
 					if err == data.ErrNotFound {
 						err = nil // clear error
 						continue HeadLoop
@@ -65,6 +79,10 @@ func RemoveRootObjects(c *skyobject.Container, keepLast int) (err error) {
 
 			// seq = 0 (goDown == 0)
 			if err = c.DelRoot(pk, nonce, 0); err != nil {
+				if err == skyobject.ErrRootIsPinned {
+					err = nil // keep pinned
+					continue HeadLoop
+				}
 				if err == data.ErrNotFound {
 					err = nil // clear error
 					continue HeadLoop
@@ -82,16 +100,55 @@ func RemoveRootObjects(c *skyobject.Container, keepLast int) (err error) {
 	return
 }
 
-// RemoveObjects with rc == 0 from CXDS
+// RemoveObjects with rc == 0 from CXDS. Pinned
+// objects and objects of interrupted fillings are
+// kept (see Pin and Filling of the skyobject package).
+// Objects of partially filled Root objects are kept
+// outside the CXDS (see FillFilter of the skyobject
+// package)
 func RemoveObjects(c *skyobject.Container) (err error) {
 
-	var db = c.DB().CXDS()
+	if err = c.DelExpiredPins(); err != nil {
+		return
+	}
+
+	if err = c.DelExpiredFillings(); err != nil {
+		return
+	}
+
+	// objects of interrupted fillings
+	var fos map[cipher.SHA256]struct{}
+	if fos, err = c.FillingObjects(); err != nil {
+		return
+	}
+
+	var (
+		db = c.DB().CXDS()
+		ev = skyobject.Event{Kind: skyobject.ObjectsCollected}
+	)
 
 	err = db.IterateDel(
-		func(key cipher.SHA256, rc uint32, _ []byte) (bool, error) {
-			return (rc == 0) && (c.IsCached(key) == false), nil
+		func(key cipher.SHA256, rc uint32, val []byte) (del bool, err error) {
+			if _, ok := fos[key]; ok == true {
+				return // keep to resume the filling
+			}
+			if rc != 0 || c.IsCached(key) == true {
+				return
+			}
+			var pinned bool
+			if pinned, err = c.IsPinnedObject(key); err != nil || pinned == true {
+				return
+			}
+			del = true
+			ev.Amount++
+			ev.Volume += len(val)
+			return
 		})
 
+	if ev.Amount > 0 {
+		c.Emit(ev) // even if the err is not nil
+	}
+
 	return
 }
 
@@ -110,6 +110,55 @@ type Roots interface {
 	Len() (length int)
 }
 
+// An IterateBucketFunc represents function for
+// iterating over key-value pairs of a Bucket. The
+// key and the value are valid inside the function
+// only, copy them to use after
+type IterateBucketFunc func(key, val []byte) (err error)
+
+// A Bucket represents named key-value storage of
+// the IdxDB. Buckets used to keep information that
+// relates to feeds and Root objects, but is not a
+// part of them (secondary indexes, for example).
+// All keys of a Bucket ordered by bytes
+type Bucket interface {
+	// Get value by key. The Get returns ErrNotFound
+	// if value doesn't exist. The value is valid
+	// inside the transaction only
+	Get(key []byte) (val []byte, err error)
+	// Set value by key. The Set replaces
+	// existing value if any
+	Set(key, val []byte) (err error)
+	// Del value by key. The Del never returns
+	// ErrNotFound if value doesn't exist
+	Del(key []byte) (err error)
+
+	// Ascend iterates over all key-value pairs
+	// keys of which have given prefix. Use nil
+	// prefix to iterate over all pairs. Use
+	// ErrStopIteration to break the iteration.
+	// It's possible to mutate the Bucket inside
+	// the Ascend
+	Ascend(prefix []byte, iterateFunc IterateBucketFunc) (err error)
+
+	// Len is number of key-value pairs stored
+	Len() (length int)
+}
+
+// A Buckets represents set of named buckets. Inside
+// read-only transaction (BucketsView) the Bucket method
+// doesn't create a bucket and returns empty one if it
+// doesn't exist, and all mutating methods return error
+type Buckets interface {
+	// Bucket returns Bucket with given name.
+	// The Bucket creates it if it doesn't exist
+	Bucket(name []byte) (bk Bucket, err error)
+	// Del deletes Bucket with given name with all
+	// its content. The Del never returns ErrNotFound
+	// if the Bucket doesn't exist
+	Del(name []byte) (err error)
+}
+
 // An IdxDB repesents database that contains
 // meta information: feeds meta information
 // about Root objects. There is data/idxdb
@@ -118,6 +167,9 @@ type Roots interface {
 // ErrNoSuchFeed, ErrNoSuchHead, and
 // ErrStopIteration, and from this package.
 type IdxDB interface {
-	Tx(func(Feeds) error) error // transaction
-	Close() error               // close the IdxDB
+	Tx(func(Feeds) error) error                      // transaction
+	BucketsTx(func(Buckets) error) error             // transaction over buckets
+	BucketsView(func(Buckets) error) error           // read-only transaction over buckets
+	FeedsBucketsTx(func(Feeds, Buckets) error) error // transaction over feeds and buckets
+	Close() error                                    // close the IdxDB
 }
@@ -0,0 +1,66 @@
+package idxdb
+
+import (
+	"os"
+	"testing"
+
+	"github.com/skycoin/cxo/data/tests"
+)
+
+func TestBucket_GetSetDel(t *testing.T) {
+	// Get([]byte) ([]byte, error)
+	// Set([]byte, []byte) error
+	// Del([]byte) error
+
+	// TODO (kostyarin): memory
+
+	t.Run("drive", func(t *testing.T) {
+		idx := testNewDriveIdxDB(t)
+		defer os.Remove(testFileName)
+		defer idx.Close()
+		tests.BucketGetSetDel(t, idx)
+	})
+
+}
+
+func TestBucket_Ascend(t *testing.T) {
+	// Ascend([]byte, IterateBucketFunc) error
+
+	// TODO (kostyarin): memory
+
+	t.Run("drive", func(t *testing.T) {
+		idx := testNewDriveIdxDB(t)
+		defer os.Remove(testFileName)
+		defer idx.Close()
+		tests.BucketAscend(t, idx)
+	})
+
+}
+
+func TestIdxDB_BucketsView(t *testing.T) {
+	// BucketsView(func(Buckets) error) error
+
+	// TODO (kostyarin): memory
+
+	t.Run("drive", func(t *testing.T) {
+		idx := testNewDriveIdxDB(t)
+		defer os.Remove(testFileName)
+		defer idx.Close()
+		tests.BucketsView(t, idx)
+	})
+
+}
+
+func TestIdxDB_FeedsBucketsTx(t *testing.T) {
+	// FeedsBucketsTx(func(Feeds, Buckets) error) error
+
+	// TODO (kostyarin): memory
+
+	t.Run("drive", func(t *testing.T) {
+		idx := testNewDriveIdxDB(t)
+		defer os.Remove(testFileName)
+		defer idx.Close()
+		tests.FeedsBucketsTx(t, idx)
+	})
+
+}
@@ -1,6 +1,7 @@
 package idxdb
 
 import (
+	"bytes"
 	"encoding/binary"
 	"os"
 	"time"
@@ -13,9 +14,10 @@ import (
 )
 
 var (
-	feedsBucket = []byte("f")       // feeds
-	metaBucket  = []byte("m")       // meta information
-	versionKey  = []byte("version") // encoded version in the meta bucket
+	feedsBucket   = []byte("f")       // feeds
+	metaBucket    = []byte("m")       // meta information
+	bucketsBucket = []byte("b")       // named buckets
+	versionKey    = []byte("version") // encoded version in the meta bucket
 )
 
 type driveDB struct {
@@ -83,7 +85,11 @@ func NewDriveIdxDB(fileName string) (idx data.IdxDB, err error) {
 
 		}
 
-		_, err = tx.CreateBucketIfNotExists(feedsBucket)
+		if _, err = tx.CreateBucketIfNotExists(feedsBucket); err != nil {
+			return
+		}
+
+		_, err = tx.CreateBucketIfNotExists(bucketsBucket)
 		return
 	})
 
@@ -103,6 +109,42 @@ func (d *driveDB) Tx(txFunc func(feeds data.Feeds) (err error)) (err error) {
 	})
 }
 
+// BucketsTx performs ACID-transaction over named buckets
+func (d *driveDB) BucketsTx(
+	txFunc func(buckets data.Buckets) (err error),
+) (
+	err error,
+) {
+	return d.b.Update(func(tx *bolt.Tx) (err error) {
+		return txFunc(&driveBuckets{tx.Bucket(bucketsBucket)})
+	})
+}
+
+// BucketsView performs read-only transaction over named
+// buckets; the View doesn't take the writer lock
+func (d *driveDB) BucketsView(
+	txFunc func(buckets data.Buckets) (err error),
+) (
+	err error,
+) {
+	return d.b.View(func(tx *bolt.Tx) (err error) {
+		return txFunc(&driveBuckets{tx.Bucket(bucketsBucket)})
+	})
+}
+
+// FeedsBucketsTx performs ACID-transaction over
+// feeds and named buckets
+func (d *driveDB) FeedsBucketsTx(
+	txFunc func(feeds data.Feeds, buckets data.Buckets) (err error),
+) (
+	err error,
+) {
+	return d.b.Update(func(tx *bolt.Tx) (err error) {
+		return txFunc(&driveFeeds{tx.Bucket(feedsBucket)},
+			&driveBuckets{tx.Bucket(bucketsBucket)})
+	})
+}
+
 // Close the DB
 func (d *driveDB) Close() (err error) {
 	return d.b.Close()
@@ -409,3 +451,128 @@ func utob(u uint64) (p []byte) {
 	binary.BigEndian.PutUint64(p, u)
 	return
 }
+
+type driveBuckets struct {
+	bk *bolt.Bucket
+}
+
+// Bucket returns named bucket creating it if necessary
+func (d *driveBuckets) Bucket(name []byte) (bk data.Bucket, err error) {
+	var b *bolt.Bucket
+	if d.bk.Tx().Writable() == false {
+		if b = d.bk.Bucket(name); b == nil {
+			return emptyBucket{}, nil // read-only
+		}
+		return &driveBucket{b}, nil
+	}
+	if b, err = d.bk.CreateBucketIfNotExists(name); err != nil {
+		return
+	}
+	return &driveBucket{b}, nil
+}
+
+// Del named bucket
+func (d *driveBuckets) Del(name []byte) (err error) {
+	if err = d.bk.DeleteBucket(name); err == bolt.ErrBucketNotFound {
+		err = nil
+	}
+	return
+}
+
+type driveBucket struct {
+	bk *bolt.Bucket
+}
+
+// Get value by key
+func (d *driveBucket) Get(key []byte) (val []byte, err error) {
+	if val = d.bk.Get(key); val == nil {
+		err = data.ErrNotFound
+	}
+	return
+}
+
+// Set value by key
+func (d *driveBucket) Set(key, val []byte) (err error) {
+	return d.bk.Put(key, val)
+}
+
+// Del value by key
+func (d *driveBucket) Del(key []byte) (err error) {
+	return d.bk.Delete(key)
+}
+
+// Ascend iterates over key-value pairs with given prefix
+func (d *driveBucket) Ascend(
+	prefix []byte,
+	iterateFunc data.IterateBucketFunc,
+) (
+	err error,
+) {
+
+	var (
+		c    = d.bk.Cursor()
+		next []byte
+	)
+
+	// we have to Seek(next) instead of using Next
+	// because we allows mutations during the iteration;
+	// the next is the smallest key greater then current
+
+	for k, v := c.Seek(prefix); k != nil; k, v = c.Seek(next) {
+
+		if bytes.HasPrefix(k, prefix) == false {
+			return
+		}
+
+		next = append(append(next[:0], k...), 0)
+
+		if err = iterateFunc(k, v); err != nil {
+			if err == data.ErrStopIteration {
+				err = nil
+			}
+			return
+		}
+
+	}
+
+	return
+}
+
+// Len returns number of key-value pairs; the
+// Stats of bolt.Bucket doesn't count changes of
+// current transaction, thus we have to count
+func (d *driveBucket) Len() (length int) {
+	var c = d.bk.Cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		length++
+	}
+	return
+}
+
+// bucket that doesn't exist in read-only transaction
+type emptyBucket struct{}
+
+// Get returns data.ErrNotFound
+func (emptyBucket) Get([]byte) ([]byte, error) {
+	return nil, data.ErrNotFound
+}
+
+// Set returns bolt.ErrTxNotWritable
+func (emptyBucket) Set(_, _ []byte) error {
+	return bolt.ErrTxNotWritable
+}
+
+// Del returns bolt.ErrTxNotWritable
+func (emptyBucket) Del([]byte) error {
+	return bolt.ErrTxNotWritable
+}
+
+// Ascend does nothing
+func (emptyBucket) Ascend([]byte, data.IterateBucketFunc) error {
+	return nil
+}
+
+// Len is zero
+func (emptyBucket) Len() int {
+	return 0
+}
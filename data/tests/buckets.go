@@ -0,0 +1,394 @@
+package tests
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/data"
+)
+
+// BucketGetSetDel is test case for Bucket.Get,
+// Bucket.Set and Bucket.Del
+func BucketGetSetDel(t *testing.T, idx data.IdxDB) {
+
+	var (
+		name = []byte("test")
+		key  = []byte("key")
+		val  = []byte("value")
+	)
+
+	t.Run("not found", func(t *testing.T) {
+		err := idx.BucketsTx(func(bs data.Buckets) (err error) {
+			var bk data.Bucket
+			if bk, err = bs.Bucket(name); err != nil {
+				return
+			}
+			if _, err := bk.Get(key); err == nil {
+				t.Error("missing error")
+			} else if err != data.ErrNotFound {
+				t.Error("wrong error:", err)
+			}
+			return
+		})
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("set", func(t *testing.T) {
+		err := idx.BucketsTx(func(bs data.Buckets) (err error) {
+			var bk data.Bucket
+			if bk, err = bs.Bucket(name); err != nil {
+				return
+			}
+			if err = bk.Set(key, val); err != nil {
+				return
+			}
+			var got []byte
+			if got, err = bk.Get(key); err != nil {
+				return
+			}
+			if bytes.Compare(got, val) != 0 {
+				t.Errorf("wrong value %q, want %q", got, val)
+			}
+			if bk.Len() != 1 {
+				t.Error("wrong length", bk.Len())
+			}
+			return
+		})
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("del", func(t *testing.T) {
+		err := idx.BucketsTx(func(bs data.Buckets) (err error) {
+			var bk data.Bucket
+			if bk, err = bs.Bucket(name); err != nil {
+				return
+			}
+			if err = bk.Del(key); err != nil {
+				return
+			}
+			if _, err := bk.Get(key); err != data.ErrNotFound {
+				t.Error("wrong error:", err)
+			}
+			return bk.Del(key) // twice
+		})
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("del bucket", func(t *testing.T) {
+		err := idx.BucketsTx(func(bs data.Buckets) (err error) {
+			var bk data.Bucket
+			if bk, err = bs.Bucket(name); err != nil {
+				return
+			}
+			if err = bk.Set(key, val); err != nil {
+				return
+			}
+			if err = bs.Del(name); err != nil {
+				return
+			}
+			if bk, err = bs.Bucket(name); err != nil {
+				return
+			}
+			if bk.Len() != 0 {
+				t.Error("bucket is not deleted")
+			}
+			return bs.Del([]byte("not existing"))
+		})
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+}
+
+// BucketAscend is test case for Bucket.Ascend
+func BucketAscend(t *testing.T, idx data.IdxDB) {
+
+	var (
+		name = []byte("test")
+		keys = []string{"a", "ab", "abc", "b", "ba"}
+	)
+
+	err := idx.BucketsTx(func(bs data.Buckets) (err error) {
+		var bk data.Bucket
+		if bk, err = bs.Bucket(name); err != nil {
+			return
+		}
+		for _, k := range keys {
+			if err = bk.Set([]byte(k), []byte(k)); err != nil {
+				return
+			}
+		}
+		return
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ascend = func(
+		t *testing.T,
+		prefix string,
+		del bool,
+		want ...string,
+	) {
+		t.Helper()
+
+		var got []string
+
+		err := idx.BucketsTx(func(bs data.Buckets) (err error) {
+			var bk data.Bucket
+			if bk, err = bs.Bucket(name); err != nil {
+				return
+			}
+			var pfx []byte
+			if prefix != "" {
+				pfx = []byte(prefix)
+			}
+			return bk.Ascend(pfx, func(key, val []byte) (err error) {
+				if bytes.Compare(key, val) != 0 {
+					t.Errorf("wrong value %q of %q", val, key)
+				}
+				got = append(got, string(key))
+				if del == true {
+					return bk.Del(key)
+				}
+				return
+			})
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(got) != len(want) {
+			t.Fatalf("wrong keys %q, want %q", got, want)
+		}
+
+		for i, k := range want {
+			if got[i] != k {
+				t.Fatalf("wrong keys %q, want %q", got, want)
+			}
+		}
+	}
+
+	t.Run("all", func(t *testing.T) {
+		ascend(t, "", false, keys...)
+	})
+
+	t.Run("prefix", func(t *testing.T) {
+		ascend(t, "ab", false, "ab", "abc")
+		ascend(t, "b", false, "b", "ba")
+		ascend(t, "c", false)
+	})
+
+	t.Run("stop iteration", func(t *testing.T) {
+		var called int
+		err := idx.BucketsTx(func(bs data.Buckets) (err error) {
+			var bk data.Bucket
+			if bk, err = bs.Bucket(name); err != nil {
+				return
+			}
+			return bk.Ascend(nil, func(_, _ []byte) (err error) {
+				called++
+				return data.ErrStopIteration
+			})
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		if called != 1 {
+			t.Error("ErrStopIteration doesn't stop the iteration")
+		}
+	})
+
+	t.Run("mutate del", func(t *testing.T) {
+		ascend(t, "a", true, "a", "ab", "abc")
+		ascend(t, "", false, "b", "ba")
+	})
+
+}
+
+// BucketsView is test case for read-only BucketsView
+func BucketsView(t *testing.T, idx data.IdxDB) {
+
+	var (
+		name = []byte("view")
+		key  = []byte("key")
+		val  = []byte("value")
+	)
+
+	t.Run("not existing", func(t *testing.T) {
+		err := idx.BucketsView(func(bs data.Buckets) (err error) {
+			var bk data.Bucket
+			if bk, err = bs.Bucket(name); err != nil {
+				return
+			}
+			if _, err := bk.Get(key); err != data.ErrNotFound {
+				t.Error("wrong error:", err)
+			}
+			if bk.Len() != 0 {
+				t.Error("not empty")
+			}
+			if err := bk.Set(key, val); err == nil {
+				t.Error("missing error")
+			}
+			return
+		})
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("get", func(t *testing.T) {
+		err := idx.BucketsTx(func(bs data.Buckets) (err error) {
+			var bk data.Bucket
+			if bk, err = bs.Bucket(name); err != nil {
+				return
+			}
+			return bk.Set(key, val)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = idx.BucketsView(func(bs data.Buckets) (err error) {
+			var bk data.Bucket
+			if bk, err = bs.Bucket(name); err != nil {
+				return
+			}
+			var got []byte
+			if got, err = bk.Get(key); err != nil {
+				return
+			}
+			if bytes.Compare(got, val) != 0 {
+				t.Errorf("wrong value %q, want %q", got, val)
+			}
+			if err := bk.Del(key); err == nil {
+				t.Error("missing error")
+			}
+			return
+		})
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+}
+
+// FeedsBucketsTx is test case for FeedsBucketsTx
+func FeedsBucketsTx(t *testing.T, idx data.IdxDB) {
+
+	var (
+		pk, _ = cipher.GenerateKeyPair()
+
+		name = []byte("feeds-buckets")
+		key  = []byte("key")
+		val  = []byte("value")
+	)
+
+	t.Run("commit", func(t *testing.T) {
+
+		err := idx.FeedsBucketsTx(func(fs data.Feeds, bs data.Buckets) (err error) {
+			if err = fs.Add(pk); err != nil {
+				return
+			}
+			var bk data.Bucket
+			if bk, err = bs.Bucket(name); err != nil {
+				return
+			}
+			return bk.Set(key, val)
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = idx.Tx(func(fs data.Feeds) (err error) {
+			var ok bool
+			if ok, err = fs.Has(pk); err == nil && ok == false {
+				t.Error("feed not added")
+			}
+			return
+		})
+
+		if err != nil {
+			t.Error(err)
+		}
+
+		err = idx.BucketsView(func(bs data.Buckets) (err error) {
+			var bk data.Bucket
+			if bk, err = bs.Bucket(name); err != nil {
+				return
+			}
+			if _, err := bk.Get(key); err != nil {
+				t.Error(err)
+			}
+			return
+		})
+
+		if err != nil {
+			t.Error(err)
+		}
+
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+
+		var opk, _ = cipher.GenerateKeyPair()
+
+		err := idx.FeedsBucketsTx(func(fs data.Feeds, bs data.Buckets) (err error) {
+			if err = fs.Add(opk); err != nil {
+				return
+			}
+			var bk data.Bucket
+			if bk, err = bs.Bucket(name); err != nil {
+				return
+			}
+			if err = bk.Del(key); err != nil {
+				return
+			}
+			return data.ErrStopIteration // any error
+		})
+
+		if err != data.ErrStopIteration {
+			t.Fatal("wrong error:", err)
+		}
+
+		err = idx.Tx(func(fs data.Feeds) (err error) {
+			var ok bool
+			if ok, err = fs.Has(opk); err == nil && ok == true {
+				t.Error("feed added")
+			}
+			return
+		})
+
+		if err != nil {
+			t.Error(err)
+		}
+
+		err = idx.BucketsView(func(bs data.Buckets) (err error) {
+			var bk data.Bucket
+			if bk, err = bs.Bucket(name); err != nil {
+				return
+			}
+			if _, err := bk.Get(key); err != nil {
+				t.Error("value removed:", err)
+			}
+			return
+		})
+
+		if err != nil {
+			t.Error(err)
+		}
+
+	})
+
+}
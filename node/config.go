@@ -18,6 +18,7 @@ const (
 	MaxConnections        int           = 1000 * 1000
 	MaxPendingConnections int           = 1000
 	MaxFillingTime        time.Duration = 10 * time.Minute
+	FillProgressInterval  time.Duration = time.Second
 	MaxHeads              int           = 10
 	ListenTCP             string        = ":8870"
 	ListenUDP             string        = "" // don't listen
@@ -25,6 +26,7 @@ const (
 	ResponseTimeout       time.Duration = 59 * time.Second
 	Pings                 time.Duration = 118 * time.Second
 	Public                bool          = false
+	StampDifficulty       int           = 0 // don't require
 )
 
 // Addresses are discovery addresses
@@ -69,11 +71,35 @@ type OnRootFilledFunc func(n *Node, r *registry.Root)
 // called when a new Root object can't be filled.
 // The callback called with non-full Root (that
 // can't be used), and with filling error.
-// There is no way to resume the filling. If
-// a remote peer push this Root object again,
+// If the filling is interrupted (timeout, no
+// connections to fill from), then the Root
+// and received objects are kept, and the
+// filling is resumed when a peer of the feed
+// connects (see Filling of the skyobject).
+// Otherwise the Root is dropped, but if a
+// remote peer push this Root object again,
 // then the Root can be filled (or can be not).
 type OnFillingBreaksFunc func(n *Node, r *registry.Root, err error)
 
+// OnFillProgressFunc represents callback that
+// called periodically (see FillProgressInterval)
+// while a Root fills. The callback called from
+// goroutine of head of the Root and should not
+// block the filling. The FillProgress can be
+// kept, because it's a copy
+type OnFillProgressFunc func(n *Node, p *FillProgress)
+
+// OnEquivocationFunc represents callback that called
+// when a remote peer sends a Root that conflicts with
+// stored one. E.g. publisher of the feed has signed
+// two different Roots with the same seq, or a Root
+// that doesn't point to previous one. The Evidence
+// is already stored (see Evidence method of the
+// skyobject.Container). The received Root is dropped.
+// The callback is called every time the peer sends
+// conflicting Root
+type OnEquivocationFunc func(c *Conn, e *skyobject.Evidence)
+
 // OnConnectFunc represents callback that called
 // when a connection created and established. It's
 // possible to terminate connection returning error
@@ -238,6 +264,23 @@ type Config struct {
 	// limit.
 	MaxFillingTime time.Duration
 
+	// FillProgressInterval is interval of calls of
+	// the OnFillProgress callback. Set it to zero to
+	// turn the calls off. Progress of fillings is
+	// available through Stat anyway
+	FillProgressInterval time.Duration
+
+	// StampDifficulty is required difficulty of
+	// proof-of-work stamps of received Root objects
+	// (see registry.StampBits). Roots with weaker
+	// stamps are dropped before signature checking
+	// and filling. It protects public nodes against
+	// flood of Roots of generated feeds. Publishers
+	// should compute stamps saving Roots (see Stamp
+	// method of skyobject.Unpack). Set it to zero to
+	// accept Roots without stamps
+	StampDifficulty int
+
 	// RPC is RPC listening address. Empty string
 	// disables RPC.
 	RPC string
@@ -315,6 +358,16 @@ type Config struct {
 	// used. See OnRootFilledFunc for details.
 	OnFillingBreaks OnFillingBreaksFunc
 
+	// OnFillProgress is a callback that called
+	// periodically while a Root fills. See
+	// OnFillProgressFunc for details
+	OnFillProgress OnFillProgressFunc
+
+	// OnEquivocation is a callback that called
+	// when a received Root conflicts with stored
+	// one. See OnEquivocationFunc for details
+	OnEquivocation OnEquivocationFunc
+
 	// OnPeerAdded
 	OnPeerAdded OnPeerAddedFunc
 
@@ -341,7 +394,9 @@ func NewConfig() (c *Config) {
 	c.MaxConnections = MaxConnections
 	c.MaxPendingConnections = MaxPendingConnections
 	c.MaxFillingTime = MaxFillingTime
+	c.FillProgressInterval = FillProgressInterval
 	c.MaxHeads = MaxHeads
+	c.StampDifficulty = StampDifficulty
 
 	c.TCP.Listen = ListenTCP
 	c.TCP.Pings = Pings
@@ -394,11 +449,21 @@ func (c *Config) FromFlags() {
 		c.MaxFillingTime,
 		"max time to fill a Root")
 
+	flag.DurationVar(&c.FillProgressInterval,
+		"fill-progress-interval",
+		c.FillProgressInterval,
+		"interval of fill progress callback")
+
 	flag.IntVar(&c.MaxHeads,
 		"max-heads",
 		c.MaxHeads,
 		"max heads of a feed allowed")
 
+	flag.IntVar(&c.StampDifficulty,
+		"stamp-difficulty",
+		c.StampDifficulty,
+		"required proof-of-work bits of received Roots")
+
 	flag.StringVar(&c.RPC,
 		"rpc",
 		c.RPC,
@@ -460,7 +525,17 @@ func (c *Config) Validate() (err error) {
 		}
 	}
 
-	// nothing to validate in the Node configs
+	if c.FillProgressInterval < 0 {
+		return fmt.Errorf("negative FillProgressInterval %s",
+			c.FillProgressInterval)
+	}
+
+	if c.StampDifficulty < 0 ||
+		c.StampDifficulty > registry.MaxStampDifficulty {
+
+		return fmt.Errorf("invalid StampDifficulty %d, max is %d",
+			c.StampDifficulty, registry.MaxStampDifficulty)
+	}
 
 	return
 
@@ -35,6 +35,8 @@ type Conn struct {
 
 	sendq chan<- []byte // channel from factory.Connection
 
+	filters // fill filters of subscriptions
+
 	// # stat
 	//
 	// TODO (kostyarin): stat without mutexes to do not slow down the connection
@@ -231,16 +233,49 @@ func (c *Conn) RemoteFeeds() (feeds []cipher.PubKey, err error) {
 	return
 }
 
-func (c *Conn) sendRoot(r *registry.Root) {
-	c.sendMsg(c.nextSeq(), 0, &msg.Root{
+// rootMsg creates Root message; the Root
+// should be stamped (see stampedRoot)
+func (c *Conn) rootMsg(r *registry.Root) (rm *msg.Root) {
+
+	return &msg.Root{
 		Feed:  r.Pub,
 		Nonce: r.Nonce,
 		Seq:   r.Seq,
 
 		Value: r.Encode(),
 
-		Sig: r.Sig,
-	})
+		Sig:   r.Sig,
+		Stamp: r.Stamp,
+	}
+}
+
+// stampedRoot returns the Root or its copy with proof-of-work
+// stamp loaded from DB if the Stamp field is not set; the Root
+// is shared, thus it's never changed
+func (n *Node) stampedRoot(r *registry.Root) *registry.Root {
+
+	if r.Stamp != 0 {
+		return r
+	}
+
+	var stamp, err = n.c.Stamp(r.Hash)
+
+	if err != nil {
+		n.Printf("[ERR] can't get stamp of %s: %v", r.Short(), err)
+		return r
+	}
+
+	if stamp == 0 {
+		return r // not stamped
+	}
+
+	var sr = *r
+	sr.Stamp = stamp
+	return &sr
+}
+
+func (c *Conn) sendRoot(r *registry.Root) {
+	c.sendMsg(c.nextSeq(), 0, c.rootMsg(r))
 }
 
 // send last Root to peer
@@ -252,7 +287,12 @@ func (c *Conn) sendLastRoot(pk cipher.PubKey) {
 	)
 
 	if err == nil {
-		c.sendRoot(r)
+		if r.IsPartial == true {
+			c.n.Debugf(MsgSendPin, "[%s] sendLastRoot %s: last Root is "+
+				"partially filled", c.String(), r.Short())
+			return // peer can't get skipped objects from this node
+		}
+		c.sendRoot(c.n.stampedRoot(r))
 		return
 	}
 
@@ -313,7 +353,8 @@ func (c *Conn) unsubscribe(pk cipher.PubKey) {
 // Unsubscribe from given feed of remote peer
 func (c *Conn) Unsubscribe(feed cipher.PubKey) {
 	c.n.fs.delConnFeed(c, feed)
-	c.unsubscribe(feed) // notify peer
+	c.SetFillFilter(feed, nil) // remove filter
+	c.unsubscribe(feed)        // notify peer
 	return
 }
 
@@ -690,6 +731,7 @@ func (c *Conn) handleUnsub(seq uint32, unsub *msg.Unsub) (err error) {
 	}
 
 	c.n.fs.delConnFeed(c, unsub.Feed) // delete
+	c.SetFillFilter(unsub.Feed, nil)  // remove filter
 	return
 }
 
@@ -716,7 +758,19 @@ func (c *Conn) handleRoot(root *msg.Root) (_ error) {
 	c.n.Debugf(MsgReceivePin, "[%s] handleRoot %s/%d/%d",
 		c.String(), root.Feed.Hex()[:7], root.Nonce, root.Seq)
 
-	// check seq first (avoid verify-signature for old unwanted Root objects)
+	// proof-of-work stamp is much cheaper then signature and
+	// the CheckOldRoot (that verifies signature)
+
+	if d := c.n.config.StampDifficulty; d > 0 {
+		var hash = cipher.SumSHA256(root.Value)
+		if registry.StampBits(hash, root.Stamp) < d {
+			c.n.Debugf(MsgReceivePin, "[%s] underpowered Root %s/%d/%d",
+				c.String(), root.Feed.Hex()[:7], root.Nonce, root.Seq)
+			return
+		}
+	}
+
+	// check seq (avoid verify-signature for old unwanted Root objects)
 
 	var last, err = c.n.c.LastRootSeq(root.Feed, root.Nonce) // last is full
 
@@ -732,7 +786,12 @@ func (c *Conn) handleRoot(root *msg.Root) (_ error) {
 	default: // nil (found)
 
 		if last >= root.Seq {
-			return // we have newer one
+			// we have newer one, but the Root can be
+			// conflicting with one of stored Roots
+			err = c.n.c.CheckOldRoot(root.Feed, root.Nonce, root.Seq,
+				root.Sig, root.Value)
+			c.checkEquivocation(err)
+			return
 		}
 
 	}
@@ -742,7 +801,9 @@ func (c *Conn) handleRoot(root *msg.Root) (_ error) {
 	r, err = c.n.c.ReceivedRoot(root.Feed, root.Sig, root.Value)
 
 	if err != nil {
-		c.n.Printf("[ERR] [%s] received Root error: %s", c.String(), err)
+		if c.checkEquivocation(err) == false {
+			c.n.Printf("[ERR] [%s] received Root error: %s", c.String(), err)
+		}
 		return // keep connection ?
 	}
 
@@ -751,12 +812,33 @@ func (c *Conn) handleRoot(root *msg.Root) (_ error) {
 		return
 	}
 
+	r.Stamp = root.Stamp // keep the stamp to send it further
+
 	// fill the Root only if the node and the connection
 	// subscribed to feed of the Root
 	c.n.fs.receivedRoot(c, r)
 	return
 }
 
+// checkEquivocation calls OnEquivocation callback
+// if given error is *skyobject.EquivocationError
+func (c *Conn) checkEquivocation(err error) (yep bool) {
+
+	var ee, ok = err.(*skyobject.EquivocationError)
+
+	if ok == false {
+		return
+	}
+
+	c.n.Printf("[ERR] [%s] %s", c.String(), ee.Error())
+
+	if eq := c.n.config.OnEquivocation; eq != nil {
+		eq(c, ee.Evidence)
+	}
+
+	return true
+}
+
 // async
 func (c *Conn) handleRqObject(seq uint32, rq *msg.RqObject) {
 	defer c.await.Done()
@@ -818,20 +900,16 @@ func (c *Conn) handleRqPreview(seq uint32, rqp *msg.RqPreview) (_ error) {
 
 	var r, err = c.n.c.LastRoot(rqp.Feed, c.n.c.ActiveHead(rqp.Feed))
 
+	if err == nil && r.IsPartial == true {
+		err = ErrPartialRoot // peer can't get skipped objects from this node
+	}
+
 	if err != nil {
 		c.sendMsg(c.nextSeq(), seq, &msg.Err{Err: err.Error()})
 		return
 	}
 
-	c.sendMsg(c.nextSeq(), seq, &msg.Root{
-		Feed:  r.Pub,
-		Nonce: r.Nonce,
-		Seq:   r.Seq,
-
-		Value: r.Encode(),
-
-		Sig: r.Sig,
-	})
+	c.sendMsg(c.nextSeq(), seq, c.rootMsg(c.n.stampedRoot(r)))
 
 	return
 }
@@ -13,9 +13,11 @@ var (
 	ErrAlreadyHaveConnection   = errors.New("already have connection")
 	ErrInvalidResponse         = errors.New("invalid response")
 	ErrNoConnectionsToFillFrom = errors.New("no connections to fill from")
+	ErrNoConnectionsToGetFrom  = errors.New("no connections to get object from")
 	ErrMaxHeadsLimit           = errors.New("max heads limit")
 	ErrUnsubscribe             = errors.New("unsubscribe")
 	ErrBlankFeed               = errors.New("blank feed")
 	ErrConnLimit               = errors.New("max nubmber of connections exceeded")
 	ErrPendConnLimit           = errors.New("max number of pending connections exceeded")
+	ErrPartialRoot             = errors.New("last Root is partially filled")
 )
@@ -27,7 +27,39 @@ func newNodeFeed(nf *nodeFeeds, pk cipher.PubKey) (n *nodeFeed) {
 }
 
 func (n *nodeFeed) addConn(c *Conn) {
+
+	if _, ok := n.cs[c]; ok == true {
+		return // already have
+	}
+
 	n.cs[c] = struct{}{}
+
+	if n.this == (cipher.PubKey{}) {
+		return // special blank feed
+	}
+
+	n.resumeFillings(c)
+}
+
+// resume interrupted fillings of the feed
+// using new connection; if the peer doesn't
+// have the Root, then the filling breaks
+// with ErrNoConnectionsToFillFrom again
+func (n *nodeFeed) resumeFillings(c *Conn) {
+
+	var fs, err = n.node().c.Fillings(n.this)
+
+	if err != nil {
+		n.node().Printf("[ERR] can't get fillings of %s: %v",
+			n.this.Hex()[:7], err)
+		return
+	}
+
+	for _, fl := range fs {
+		n.node().Debugln(FillPin, "[fill] resume", c.String(), fl.Root.Short())
+		n.receivedRoot(connRoot{c, fl.Root})
+	}
+
 }
 
 func (n *nodeFeed) delConn(c *Conn) {
@@ -379,12 +379,18 @@ func (n *nodeFeeds) broadcastRoot(cr connRoot) {
 // (handler)
 func (n *nodeFeeds) handleBroadcastRoot(cr connRoot) {
 
+	if cr.r.IsPartial == true {
+		return // peers can't get skipped objects from this node
+	}
+
 	var nf, ok = n.fs[cr.r.Pub]
 
 	if ok == false {
 		return
 	}
 
+	cr.r = n.n.stampedRoot(cr.r) // load the stamp once for all peers
+
 	nf.broadcastRoot(cr)
 }
 
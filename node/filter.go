@@ -0,0 +1,96 @@
+package node
+
+import (
+	"sync"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/skyobject"
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+// fill filters of subscriptions of a connection
+type filters struct {
+	flmx sync.Mutex
+	fls  map[cipher.PubKey]*skyobject.FillFilter
+}
+
+// SetFillFilter sets skyobject.FillFilter for subscription
+// to given feed. Root objects of the feed received from this
+// connection will be filled partially using the filter (see
+// FillPartial of the skyobject package). The nil removes the
+// filter. The filter affects Root objects received after the
+// call only. The filter is removed if the subscription is
+// removed.
+//
+// Since a partially filled Root doesn't have some objects,
+// the Node doesn't send it to other peers, and walking the
+// Root fails on first missing object. Use PartialPack or
+// (*Node).LazyPack to get skipped objects on demand
+func (f *filters) SetFillFilter(
+	feed cipher.PubKey, //            : the feed
+	filter *skyobject.FillFilter, //  : the filter or nil
+) {
+
+	f.flmx.Lock()
+	defer f.flmx.Unlock()
+
+	if filter == nil {
+		delete(f.fls, feed)
+		return
+	}
+
+	if f.fls == nil {
+		f.fls = make(map[cipher.PubKey]*skyobject.FillFilter)
+	}
+
+	f.fls[feed] = filter
+}
+
+// FillFilter returns skyobject.FillFilter of subscription
+// to given feed or nil if the feed is filled fully
+func (f *filters) FillFilter(
+	feed cipher.PubKey,
+) (
+	filter *skyobject.FillFilter,
+) {
+
+	f.flmx.Lock()
+	defer f.flmx.Unlock()
+
+	return f.fls[feed]
+}
+
+// SubscribeFilter subscribes to given feed of remote
+// peer setting given filter. See SetFillFilter and
+// Subscribe for details. Other connections of the
+// feed are not affected
+func (c *Conn) SubscribeFilter(
+	feed cipher.PubKey, //            : the feed
+	filter *skyobject.FillFilter, //  : the filter
+) (
+	err error, //                     : an error
+) {
+
+	c.SetFillFilter(feed, filter)
+
+	if err = c.Subscribe(feed); err != nil {
+		c.SetFillFilter(feed, nil) // not subscribed
+	}
+
+	return
+}
+
+// PartialPack returns skyobject.PartialPack of given
+// Root. The PartialPack uses this connection to get
+// objects skipped by filter of the feed. The request
+// is blocking, and objects received are saved
+func (c *Conn) PartialPack(
+	r *registry.Root, //             : the Root
+) (
+	pp *skyobject.PartialPack, //    : the pack
+	err error, //                    : an error
+) {
+
+	return c.n.c.PartialPack(r, c.getter())
+}
@@ -0,0 +1,139 @@
+package node
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/skyobject"
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+func TestConn_SubscribeFilter(t *testing.T) {
+
+	var (
+		sn     = getTestNode("sender")
+		rconf  = getTestConfig("receiver")
+		filled = make(chan *registry.Root, 1)
+	)
+
+	rconf.TCP.Listen = "127.0.0.1:8088"
+	rconf.UDP.Listen = "" // don't listen
+
+	rconf.OnRootFilled = func(_ *Node, r *registry.Root) {
+		filled <- r
+	}
+
+	var rn, err = NewNode(rconf)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer sn.Close()
+	defer rn.Close()
+
+	var pk, sk = cipher.GenerateKeyPair()
+
+	assertNil(t, sn.Share(pk))
+
+	var (
+		reg = getTestRegistry()
+		sc  = sn.Container()
+
+		up *skyobject.Unpack
+	)
+
+	if up, err = sc.Unpack(sk, reg); err != nil {
+		t.Fatal(err)
+	}
+
+	var feed Feed
+
+	for i := 0; i < 16; i++ {
+		err = feed.Posts.AppendValues(up, Post{
+			Head: fmt.Sprintf("Head #%d", i),
+			Body: fmt.Sprintf("Body #%d", i),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var r = new(registry.Root)
+
+	r.Pub = pk
+	r.Nonce = 9021
+	r.Refs = append(r.Refs, dynamicByValue(t, up, "test.Feed", feed))
+
+	if err = sc.Save(up, r); err != nil {
+		t.Fatal(err)
+	}
+
+	var c *Conn
+	if c, err = rn.TCP().Connect(sn.TCP().Address()); err != nil {
+		t.Fatal(err)
+	}
+
+	var filter = &skyobject.FillFilter{LastRefs: 4}
+
+	assertNil(t, c.SubscribeFilter(pk, filter))
+
+	if c.FillFilter(pk) != filter {
+		t.Error("missing filter")
+	}
+
+	// the Root sent on subscription can be received before
+	// the subscription is added, thus, publish it again
+
+	var (
+		tm = time.After(time.Second * 5)
+		tc = time.NewTicker(100 * time.Millisecond)
+	)
+
+	defer tc.Stop()
+
+	for wait := true; wait == true; {
+		select {
+		case fr := <-filled:
+			if fr.IsPartial == false {
+				t.Error("not partial")
+			}
+			wait = false
+		case <-tc.C:
+			sn.Publish(r)
+		case <-tm:
+			t.Fatal("slow")
+		}
+	}
+
+	t.Run("preview", func(t *testing.T) {
+
+		var pn = getTestNodeNotListen("preview")
+		defer pn.Close()
+
+		var pc, err = pn.TCP().Connect(rn.TCP().Address())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = pc.Preview(pk, func(registry.Pack, *registry.Root) bool {
+			t.Error("partial Root sent")
+			return false
+		})
+
+		if err == nil || err.Error() != "error: "+ErrPartialRoot.Error() {
+			t.Error("unexpected error", err)
+		}
+
+	})
+
+	c.Unsubscribe(pk)
+
+	if c.FillFilter(pk) != nil {
+		t.Error("filter of removed subscription")
+	}
+
+}
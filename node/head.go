@@ -16,6 +16,8 @@ import (
 type nodeHead struct {
 	n *nodeFeed // back reference
 
+	headProgress // progress of filling
+
 	delcq chan *Conn    // delete connection
 	rrq   chan connRoot // received roots
 	errq  chan error    // close with error (max heads limit)
@@ -108,9 +110,14 @@ type fillHead struct {
 	rq chan cipher.SHA256 // request objects (TODO: maxParall)
 	ff chan error         // filler failure
 
+	filtered bool // the r filled using filter (not broadcasted)
+
 	ft *time.Timer      // fill timeout
 	tc <-chan time.Time // ------------
 
+	pt  *time.Ticker     // progress callback
+	ptc <-chan time.Time // -----------------
+
 	tp   time.Time          // start point (start filling, for stat)
 	favg *statutil.Duration // average filling time
 
@@ -183,6 +190,12 @@ func (n *nodeHead) handle() {
 
 			f.handleDelConn(c)
 
+		case <-f.ptc: // progress
+
+			if fp := f.progress(); fp != nil {
+				f.node().onFillProgress(fp)
+			}
+
 		case <-f.tc: // filling timeout
 
 			if f.f != nil {
@@ -225,6 +238,7 @@ func (f *fillHead) handleSuccess(c *Conn) {
 	f.node().Debugln(FillPin, "[fill] handleSuccess", c.String())
 
 	f.requesting--
+	f.received(c)
 	f.fc.PushBack(c) // push
 	f.triggerRequest()
 }
@@ -338,8 +352,18 @@ func (f *fillHead) createFiller(cr connRoot) {
 	f.node().Debugln(FillPin, "[fill] createFiller", cr.c.String(),
 		cr.r.Short())
 
-	// broadcast the Root we are going to fill
-	f.nodeHead.n.fs.broadcastRoot(cr)
+	// filter of subscription of the connection, if any
+	var filter *skyobject.FillFilter
+	if cr.c != nil {
+		filter = cr.c.FillFilter(cr.r.Pub)
+	}
+
+	// broadcast the Root we are going to fill, a partially
+	// filled Root is not sent to peers, since they can't
+	// get skipped objects from this node
+	if f.filtered = (filter != nil); f.filtered == false {
+		f.nodeHead.n.fs.broadcastRoot(cr)
+	}
 
 	f.tp = time.Now() // time point
 
@@ -350,7 +374,17 @@ func (f *fillHead) createFiller(cr connRoot) {
 
 	f.r = cr
 	f.rq = make(chan cipher.SHA256, f.maxParallel())
-	f.f = f.node().c.Fill(cr.r, f.rq, f.maxParallel())
+	f.f = f.node().c.FillPartial(cr.r, f.rq, f.maxParallel(), filter)
+
+	f.start(f.f, cr)
+	f.node().addFilling(&f.headProgress)
+
+	if pi := f.node().config.FillProgressInterval; pi > 0 &&
+		f.node().config.OnFillProgress != nil {
+
+		f.pt = time.NewTicker(pi)
+		f.ptc = f.pt.C
+	}
 
 	f.rqo = list.New()                   // create list of keys
 	f.fc = f.cs.buildConnsList(cr.r.Seq) // create list of connections
@@ -380,6 +414,14 @@ func (f *fillHead) closeFiller() {
 		f.ft.Stop()
 	}
 
+	if f.pt != nil {
+		f.pt.Stop()
+		f.pt, f.ptc = nil, nil
+	}
+
+	f.node().delFilling(&f.headProgress)
+	f.stop()
+
 	f.f.Close()
 
 	f.rqo, f.fc, f.rq = nil, nil, nil
@@ -397,8 +439,15 @@ func (f *fillHead) handleFillingResult(err error) {
 		f.node().onRootFilled(f.r.r)     // callback
 		f.favg.Add(time.Now().Sub(f.tp)) // average time
 		f.cs.moveForward(f.r.r.Seq + 1)  // move forward
+		if f.filtered == true && f.r.r.IsPartial == false {
+			f.nodeHead.n.fs.broadcastRoot(f.r) // nothing skipped
+		}
 	} else {
 		f.node().onFillingBreaks(f.r.r, err) // callback
+		if isInterrupted(err) == false {
+			f.node().c.DelFilling(f.r.r.Pub, f.r.r.Nonce,
+				f.r.r.Seq) // ignore error
+		}
 	}
 
 	f.closeFiller() // close the filler and wait it's goroutines
@@ -418,6 +467,15 @@ func (f *fillHead) handleFillingResult(err error) {
 
 }
 
+// is the filling interrupted and can be resumed later
+func isInterrupted(err error) bool {
+	switch err {
+	case ErrTimeout, ErrNoConnectionsToFillFrom, skyobject.ErrTerminated:
+		return true
+	}
+	return false
+}
+
 func (f *fillHead) triggerRequest() {
 
 	if fatal := f.tryRequest(); fatal == true {
@@ -426,6 +484,10 @@ func (f *fillHead) triggerRequest() {
 		}
 	}
 
+	if f.rqo != nil {
+		f.pending(f.requesting, f.rqo.Len())
+	}
+
 }
 
 // the fatal means that we haven't connections to
@@ -0,0 +1,113 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/node/msg"
+	"github.com/skycoin/cxo/skyobject"
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+// implements skyobject.Getter using
+// connections of a feed
+type fget struct {
+	n    *Node
+	feed cipher.PubKey
+	c    *Conn // preferred connection or nil
+}
+
+// Get object from first connection that has it
+func (f *fget) Get(key cipher.SHA256) (val []byte, err error) {
+
+	var cs = f.n.ConnectionsOfFeed(f.feed)
+
+	if f.c != nil {
+		cs = append([]*Conn{f.c}, cs...)
+	}
+
+	err = ErrNoConnectionsToGetFrom
+
+	for i, c := range cs {
+
+		if i > 0 && c == f.c {
+			continue // already requested
+		}
+
+		if val, err = c.getter().Get(key); err == nil {
+			return
+		}
+
+		f.n.Debugln(FillPin, "[lazy] can't get", key.Hex()[:7], "from",
+			c.String(), err)
+	}
+
+	return
+}
+
+// feed getter
+func (n *Node) getter(feed cipher.PubKey, c *Conn) skyobject.Getter {
+	return &fget{n: n, feed: feed, c: c}
+}
+
+// LazyPack returns skyobject.PartialPack of given
+// Root. The Root can be full or partially filled (see
+// SetFillFilter of Conn). The PartialPack gets objects
+// the DB doesn't have from connections of the feed of
+// the Root. Received objects are saved and kept while
+// the Root exists. The request is blocking
+func (n *Node) LazyPack(
+	r *registry.Root, //             : the Root
+) (
+	pp *skyobject.PartialPack, //    : the pack
+	err error, //                    : an error
+) {
+
+	return n.c.PartialPack(r, n.getter(r.Pub, nil))
+}
+
+// Lazy gets latest Root of given feed from remote peer
+// and adds it to the Node without filling. The Node adds
+// the feed calling the Share method, but the Lazy doesn't
+// subscribe the connection to the feed. Returned pack gets
+// objects the DB doesn't have from this connection and
+// from other connections of the feed. Objects received
+// are saved and kept while the Root exists. Thus the Root
+// can be used right away, and it's filled on demand.
+//
+// To receive next Root objects of the feed lazily use
+// SubscribeFilter with skyobject.LazyFilter. Any Root
+// of the feed can be used with the LazyPack of the Node
+func (c *Conn) Lazy(
+	feed cipher.PubKey, //           : the feed
+) (
+	pp *skyobject.PartialPack, //    : the pack
+	err error, //                    : an error
+) {
+
+	if err = c.n.Share(feed); err != nil {
+		return
+	}
+
+	var reply msg.Msg
+	if reply, err = c.sendRequest(&msg.RqPreview{Feed: feed}); err != nil {
+		return
+	}
+
+	var r *registry.Root
+
+	switch x := reply.(type) {
+	case *msg.Err:
+		return nil, errors.New("error: " + x.Err)
+	case *msg.Root:
+		if r, err = c.n.c.PreviewRoot(x.Feed, x.Sig, x.Value); err != nil {
+			return
+		}
+	default:
+		return nil, fmt.Errorf("invalid msg type received: %T", reply)
+	}
+
+	return c.n.c.LazyRoot(r, c.n.getter(feed, c))
+}
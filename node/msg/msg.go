@@ -17,7 +17,7 @@ import (
 //
 
 // Version is current protocol version
-const Version uint16 = 3
+const Version uint16 = 4
 
 // be sure that all messages implements Msg interface compiler time
 var (
@@ -49,7 +49,7 @@ var (
 
 	// root (push and done)
 
-	_ Msg = &Root{} // <- Root (feed, nonce, seq, sig, val)
+	_ Msg = &Root{} // <- Root (feed, nonce, seq, sig, val, stamp)
 
 	// objects
 
@@ -245,7 +245,8 @@ type Root struct {
 
 	Value []byte // encoded Root in person
 
-	Sig cipher.Sig // signature
+	Sig   cipher.Sig // signature
+	Stamp uint64     // proof-of-work stamp (optional)
 }
 
 // Type implements Msg interface
@@ -68,6 +68,8 @@ type Node struct {
 
 	fillavg *statutil.Duration // filling average
 
+	fillings // progress of filling Root objects
+
 	//
 	// rpc
 	//
@@ -585,6 +587,10 @@ func (n *Node) Share(feed cipher.PubKey) (err error) {
 // method to prevent sharing feed that does not exist
 func (n *Node) DontShare(feed cipher.PubKey) (err error) {
 
+	for _, c := range n.ConnectionsOfFeed(feed) {
+		c.SetFillFilter(feed, nil) // remove filter
+	}
+
 	n.fs.delFeed(feed)
 	n.updateServiceDiscovery()
 
@@ -655,7 +661,8 @@ func (n *Node) hasPeer(id cipher.PubKey) (c *Conn, yep bool) {
 // A Stat represents Node stat
 type Stat struct {
 	*skyobject.Stat
-	Fillavg time.Duration
+	Fillavg  time.Duration
+	Fillings []*FillProgress // Root objects filling now
 }
 
 // Stat returns statistic of the Node
@@ -666,6 +673,7 @@ func (n *Node) Stat() (s *Stat) {
 	s = new(Stat)
 	s.Stat = n.c.Stat()
 	s.Fillavg = n.fillavg.Value()
+	s.Fillings = n.Fillings()
 
 	return
 }
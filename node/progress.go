@@ -0,0 +1,189 @@
+package node
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/skyobject"
+)
+
+// A FillProgress represents progress of a filling
+// Root. The embedded skyobject.FillProgress contains
+// received and known objects and estimated total
+type FillProgress struct {
+	skyobject.FillProgress
+
+	Pub   cipher.PubKey // feed
+	Nonce uint64        // head
+	Seq   uint64        // seq of the Root
+	Hash  cipher.SHA256 // hash of the Root
+
+	Requests int // running requests
+	Queued   int // objects waiting for request
+
+	Start   time.Time     // start of the filling
+	Elapsed time.Duration // since the start
+
+	Conns map[string]int // address -> objects received from
+}
+
+// String implements fmt.Stringer interface
+func (f *FillProgress) String() string {
+	return fmt.Sprintf("%s/%d/%d:%s %d/%d objects (%d bytes), "+
+		"%d requests, %d queued, %s, %d conns", f.Pub.Hex()[:7], f.Nonce,
+		f.Seq, f.Hash.Hex()[:7], f.Known, f.Estimated, f.Volume, f.Requests,
+		f.Queued, f.Elapsed, len(f.Conns))
+}
+
+// progress of filling of a head, the fields
+// are updated by goroutine of the head and
+// can be read by any goroutine
+type headProgress struct {
+	pmx    sync.Mutex
+	prog   *FillProgress     // nil if the head doesn't fill a Root
+	filler *skyobject.Filler // the filler
+}
+
+// start filling
+func (h *headProgress) start(f *skyobject.Filler, cr connRoot) {
+
+	h.pmx.Lock()
+	defer h.pmx.Unlock()
+
+	h.filler = f
+	h.prog = &FillProgress{
+		Pub:   cr.r.Pub,
+		Nonce: cr.r.Nonce,
+		Seq:   cr.r.Seq,
+		Hash:  cr.r.Hash,
+		Start: time.Now(),
+		Conns: make(map[string]int),
+	}
+
+}
+
+// stop filling
+func (h *headProgress) stop() {
+
+	h.pmx.Lock()
+	defer h.pmx.Unlock()
+
+	h.filler, h.prog = nil, nil
+}
+
+// set pending requests
+func (h *headProgress) pending(requests, queued int) {
+
+	h.pmx.Lock()
+	defer h.pmx.Unlock()
+
+	if h.prog != nil {
+		h.prog.Requests, h.prog.Queued = requests, queued
+	}
+
+}
+
+// object received from given connection
+func (h *headProgress) received(c *Conn) {
+
+	h.pmx.Lock()
+	defer h.pmx.Unlock()
+
+	if h.prog != nil {
+		h.prog.Conns[c.Address()]++
+	}
+
+}
+
+// copy of the progress or nil
+func (h *headProgress) progress() (fp *FillProgress) {
+
+	h.pmx.Lock()
+	defer h.pmx.Unlock()
+
+	if h.prog == nil {
+		return
+	}
+
+	fp = new(FillProgress)
+	*fp = *h.prog
+
+	fp.FillProgress = h.filler.Progress()
+	fp.Elapsed = time.Now().Sub(fp.Start)
+
+	fp.Conns = make(map[string]int, len(h.prog.Conns))
+	for addr, n := range h.prog.Conns {
+		fp.Conns[addr] = n
+	}
+
+	return
+}
+
+// filling heads of the Node
+type fillings struct {
+	fmx   sync.Mutex
+	fills map[*headProgress]struct{}
+}
+
+func (f *fillings) addFilling(h *headProgress) {
+
+	f.fmx.Lock()
+	defer f.fmx.Unlock()
+
+	if f.fills == nil {
+		f.fills = make(map[*headProgress]struct{})
+	}
+
+	f.fills[h] = struct{}{}
+}
+
+func (f *fillings) delFilling(h *headProgress) {
+
+	f.fmx.Lock()
+	defer f.fmx.Unlock()
+
+	delete(f.fills, h)
+}
+
+// Fillings returns progress of all Root objects
+// the Node fills, ordered by feed, nonce and seq
+func (f *fillings) Fillings() (fps []*FillProgress) {
+
+	f.fmx.Lock()
+	var hs = make([]*headProgress, 0, len(f.fills))
+	for h := range f.fills {
+		hs = append(hs, h)
+	}
+	f.fmx.Unlock()
+
+	for _, h := range hs {
+		if fp := h.progress(); fp != nil {
+			fps = append(fps, fp)
+		}
+	}
+
+	sort.Slice(fps, func(i, j int) bool {
+		if c := bytes.Compare(fps[i].Pub[:], fps[j].Pub[:]); c != 0 {
+			return c < 0
+		}
+		if fps[i].Nonce != fps[j].Nonce {
+			return fps[i].Nonce < fps[j].Nonce
+		}
+		return fps[i].Seq < fps[j].Seq
+	})
+
+	return
+}
+
+func (n *Node) onFillProgress(fp *FillProgress) {
+
+	if ofp := n.config.OnFillProgress; ofp != nil {
+		ofp(n, fp)
+	}
+
+}
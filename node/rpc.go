@@ -4,9 +4,11 @@ import (
 	"errors"
 	"net"
 	"net/rpc"
+	"time"
 
 	"github.com/skycoin/skycoin/src/cipher"
 
+	"github.com/skycoin/cxo/skyobject"
 	"github.com/skycoin/cxo/skyobject/registry"
 )
 
@@ -33,6 +35,7 @@ func (r *rpcServer) Listen(address string) (err error) {
 	r.r.RegisterName("udp", &UDPRPC{r.n})
 
 	r.r.RegisterName("root", &RootRPC{r.n})
+	r.r.RegisterName("pin", &PinRPC{r.n})
 
 	if r.l, err = net.Listen("tcp", address); err != nil {
 		return
@@ -152,6 +155,22 @@ func (r *RPC) Stat(_ struct{}, stat *Stat) (err error) {
 	return
 }
 
+// Fillings is RPC method
+func (r *RPC) Fillings(_ struct{}, fps *[]*FillProgress) (err error) {
+	*fps = r.n.Fillings()
+	return
+}
+
+// Evidence is RPC method
+func (r *RPC) Evidence(pk cipher.PubKey, evs *[]*skyobject.Evidence) (err error) {
+	var ev []*skyobject.Evidence
+	if ev, err = r.n.c.Evidence(pk); err != nil {
+		return
+	}
+	*evs = ev
+	return
+}
+
 // A TCPRPC represents RPC object
 // of TCP transport of the Node
 type TCPRPC struct {
@@ -350,3 +369,67 @@ func (r *RootRPC) Last(feed cipher.PubKey, z *registry.Root) (err error) {
 	*z = *x
 	return
 }
+
+// A PinRPC represents RPC object
+// of pins of the Node
+type PinRPC struct {
+	n *Node
+}
+
+// PinArgs represents arguments of Root and
+// Object methods of the PinRPC
+type PinArgs struct {
+	Root   RootSelector  // Root to pin
+	Hash   cipher.SHA256 // object to pin
+	Label  string        // optional label
+	Expire int64         // unix nano, zero is never
+}
+
+func (p *PinArgs) expire() (t time.Time) {
+	if p.Expire != 0 {
+		t = time.Unix(0, p.Expire)
+	}
+	return
+}
+
+// Root pins a Root (RPC method)
+func (p *PinRPC) Root(pa PinArgs, pin *skyobject.Pin) (err error) {
+	var x *skyobject.Pin
+	x, err = p.n.c.PinRoot(pa.Root.Feed, pa.Root.Nonce, pa.Root.Seq, pa.Label,
+		pa.expire())
+	if err != nil {
+		return
+	}
+	*pin = *x
+	return
+}
+
+// Object pins an object (RPC method)
+func (p *PinRPC) Object(pa PinArgs, pin *skyobject.Pin) (err error) {
+	var x *skyobject.Pin
+	if x, err = p.n.c.PinObject(pa.Hash, pa.Label, pa.expire()); err != nil {
+		return
+	}
+	*pin = *x
+	return
+}
+
+// UnpinRoot removes pin of a Root (RPC method)
+func (p *PinRPC) UnpinRoot(rs RootSelector, _ *struct{}) (err error) {
+	return p.n.c.UnpinRoot(rs.Feed, rs.Nonce, rs.Seq)
+}
+
+// UnpinObject removes pin of an object (RPC method)
+func (p *PinRPC) UnpinObject(hash cipher.SHA256, _ *struct{}) (err error) {
+	return p.n.c.UnpinObject(hash)
+}
+
+// List all pins (RPC method)
+func (p *PinRPC) List(_ struct{}, ps *[]*skyobject.Pin) (err error) {
+	var list []*skyobject.Pin
+	if list, err = p.n.c.Pins(); err != nil {
+		return
+	}
+	*ps = list
+	return
+}
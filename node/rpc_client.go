@@ -2,9 +2,11 @@ package node
 
 import (
 	"net/rpc"
+	"time"
 
 	"github.com/skycoin/skycoin/src/cipher"
 
+	"github.com/skycoin/cxo/skyobject"
 	"github.com/skycoin/cxo/skyobject/registry"
 )
 
@@ -39,6 +41,11 @@ func (r *RPCClient) Root() (t *RPCClientRoot) {
 	return &RPCClientRoot{r}
 }
 
+// Pins related methods
+func (r *RPCClient) Pin() (p *RPCClientPin) {
+	return &RPCClientPin{r}
+}
+
 // NewRPCClient creates RPC client connected to RPC server with
 // given address
 func NewRPCClient(address string) (rc *RPCClient, err error) {
@@ -115,6 +122,25 @@ func (r *RPCClientNode) Stat() (stat *Stat, err error) {
 	return &s, nil
 }
 
+// Fillings returns progress of Root
+// objects the Node fills
+func (r *RPCClientNode) Fillings() (fps []*FillProgress, err error) {
+	err = r.r.c.Call("node.Fillings", struct{}{}, &fps)
+	return
+}
+
+// Evidence of equivocation of given feed.
+// Use blank public key to get all evidence
+func (r *RPCClientNode) Evidence(
+	pk cipher.PubKey, //             :
+) (
+	evs []*skyobject.Evidence, //    :
+	err error, //                    :
+) {
+	err = r.r.c.Call("node.Evidence", pk, &evs)
+	return
+}
+
 // A RPCClientTCP implements RPC
 // methods related to TCP transport
 type RPCClientTCP struct {
@@ -262,3 +288,87 @@ func (r *RPCClientRoot) Last(
 	}
 	return &x, nil
 }
+
+// A RPCClientPin implements RPC
+// methods related to pins
+type RPCClientPin struct {
+	r *RPCClient
+}
+
+func pinExpire(expire time.Time) (t int64) {
+	if expire.IsZero() == false {
+		t = expire.UnixNano()
+	}
+	return
+}
+
+// Root pins Root object. The label is optional.
+// Use zero time for never expiring pin
+func (r *RPCClientPin) Root(
+	feed cipher.PubKey,
+	nonce uint64,
+	seq uint64,
+	label string,
+	expire time.Time,
+) (
+	p *skyobject.Pin,
+	err error,
+) {
+
+	var x skyobject.Pin
+	err = r.r.c.Call("pin.Root", PinArgs{
+		Root:   RootSelector{feed, nonce, seq},
+		Label:  label,
+		Expire: pinExpire(expire),
+	}, &x)
+	if err != nil {
+		return
+	}
+	return &x, nil
+}
+
+// Object pins object. The label is optional.
+// Use zero time for never expiring pin
+func (r *RPCClientPin) Object(
+	hash cipher.SHA256,
+	label string,
+	expire time.Time,
+) (
+	p *skyobject.Pin,
+	err error,
+) {
+
+	var x skyobject.Pin
+	err = r.r.c.Call("pin.Object", PinArgs{
+		Hash:   hash,
+		Label:  label,
+		Expire: pinExpire(expire),
+	}, &x)
+	if err != nil {
+		return
+	}
+	return &x, nil
+}
+
+// UnpinRoot removes pin of Root object
+func (r *RPCClientPin) UnpinRoot(
+	feed cipher.PubKey,
+	nonce uint64,
+	seq uint64,
+) (
+	err error,
+) {
+	return r.r.c.Call("pin.UnpinRoot", RootSelector{feed, nonce, seq},
+		&struct{}{})
+}
+
+// UnpinObject removes pin of object
+func (r *RPCClientPin) UnpinObject(hash cipher.SHA256) (err error) {
+	return r.r.c.Call("pin.UnpinObject", hash, &struct{}{})
+}
+
+// List all pins
+func (r *RPCClientPin) List() (ps []*skyobject.Pin, err error) {
+	err = r.r.c.Call("pin.List", struct{}{}, &ps)
+	return
+}
@@ -1,9 +1,12 @@
 package skyobject
 
 import (
+	"encoding/binary"
 	"fmt"
 	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/skycoin/skycoin/src/cipher"
@@ -18,8 +21,9 @@ type CachePolicy int
 
 // cache policies
 const (
-	LRU CachePolicy = iota // LRU cache
-	LFU                    // LFU cache
+	LRU  CachePolicy = iota // LRU cache
+	LFU                     // LFU cache
+	TwoQ                    // 2Q cache (scan resistant)
 )
 
 // String implements fmt.Stringer interface
@@ -29,10 +33,29 @@ func (c CachePolicy) String() string {
 		return "LRU"
 	case LFU:
 		return "LFU"
+	case TwoQ:
+		return "2Q"
 	}
 	return fmt.Sprintf("CachePolicy<%d>", c)
 }
 
+// Set implements flag.Value interface
+func (c *CachePolicy) Set(s string) (err error) {
+	for _, p := range []CachePolicy{LRU, LFU, TwoQ} {
+		if strings.EqualFold(s, p.String()) == true {
+			*c = p
+			return
+		}
+	}
+	return fmt.Errorf("unknown cache policy %q (choose LRU, LFU or 2Q)", s)
+}
+
+// 2Q related constants
+const (
+	twoQIn  float64 = 0.25 // max part of new items after cleaning (A1in)
+	twoQOut float64 = 1.0  // max ghosts, part of CacheMaxAmount (A1out)
+)
+
 // An Object represents DB object
 // that is []byte, its hash and
 // references counter
@@ -50,7 +73,7 @@ type cachePoints int
 func (c *cachePoints) touch(policy CachePolicy) {
 
 	switch policy {
-	case LRU:
+	case LRU, TwoQ:
 		*c = cachePoints(time.Now().Unix()) // last access
 	case LFU:
 		*c++ // access
@@ -66,9 +89,18 @@ type item struct {
 
 	// the points is time.Now().Unix() or number of acesses;
 	// e.g. the points is LRU or LFU number and depends on
-	// the cache policy
+	// the cache policy; for 2Q it's logical time of
+	// insertion (cold items) or last access (hot items)
 	cachePoints
 
+	hot bool // 2Q: frequent item (Am), or new (A1in)
+
+	// priority of the item is max priority of feeds
+	// the item used by (see SetFeedPriority); items
+	// with lower priority are removed first
+	prio    int
+	hasPrio bool
+
 	// hard rc = cc -fc
 	// sync rc = cc - rc
 
@@ -96,27 +128,63 @@ type itemRegistry struct {
 }
 
 // A Cache is internal and used by Container.
-// The Cache can't be created and used outside
+// The Cache can't be created and used outside.
+// The Cache is split to shards by keys. Every
+// shard has its own lock. But limits of the
+// Cache are shared by all shards, and cleaning
+// removes items of all shards. All wanted and
+// filling items with given key are in the same
+// shard, thus the Cache never locks two shards
+// at the same time
 type Cache struct {
-	mx sync.Mutex
+	// first for 64-bit alignment (atomic)
+	amount int64 // number of items of all shards
+	volume int64 // volume of items of all shards
+	clock  int64 // 2Q: logical time
 
 	c      *Container // back reference
 	enable bool       //
 
-	amount int // number of items in the Cache
-	volume int // volume of items in the Cache
-
 	amountc int // clean down to this (const)
 	volumec int // clean down to this (const)
 
-	is map[cipher.SHA256]*item
-	rs map[registry.RegistryRef]*itemRegistry
+	shards []*cacheShard
+
+	cmx sync.Mutex // one cleaning at a time
+
+	// 2Q: keys of items recently evicted from
+	// A1in (A1out); the keys is FIFO
+	gmx    sync.Mutex
+	ghosts map[cipher.SHA256]struct{}
+	ghostq []cipher.SHA256
+
+	rmx sync.Mutex // lock registries
+	rs  map[registry.RegistryRef]*itemRegistry
 
 	stat *cxdsStat
 
+	pmx   sync.RWMutex          // lock priorities
+	prios map[cipher.PubKey]int // priorities of feeds
+
+	// prefetching of hot keys (see CacheWarmUp)
+	warmq  chan struct{}  // stop prefetching
+	warmwg sync.WaitGroup // wait the prefetching
+
 	closeo sync.Once
 }
 
+// a shard of the Cache
+type cacheShard struct {
+	mx sync.Mutex
+
+	c      *Container // back reference
+	enable bool       //
+
+	is map[cipher.SHA256]*item
+
+	stat *cxdsStat
+}
+
 // initialize the Cache
 func (c *Container) initCache() {
 
@@ -124,44 +192,94 @@ func (c *Container) initCache() {
 
 	c.Cache.enable = !(c.conf.CacheMaxAmount == 0 || c.conf.CacheMaxVolume == 0)
 
-	c.Cache.amountc = int(
-		float64(c.conf.CacheMaxAmount) * (1.0 - c.conf.CacheCleaning),
-	)
-	c.Cache.volumec = int(
-		float64(c.conf.CacheMaxVolume) * (1.0 - c.conf.CacheCleaning),
-	)
-
-	c.Cache.amount = 0 // cache amount
-	c.Cache.volume = 0 // cache volume
-
-	c.Cache.is = make(map[cipher.SHA256]*item, c.conf.CacheMaxAmount)
 	c.Cache.rs = make(map[registry.RegistryRef]*itemRegistry,
 		c.conf.CacheRegistries)
 
 	c.Cache.stat = newCxdsStat(c.conf.RollAvgSamples)
+	c.Cache.warmq = make(chan struct{})
+
+	c.Cache.prios = make(map[cipher.PubKey]int, len(c.conf.FeedPriorities))
+
+	if c.conf.CachePolicy == TwoQ {
+		c.Cache.ghosts = make(map[cipher.SHA256]struct{})
+	}
+
+	c.Cache.amountc = int(float64(c.conf.CacheMaxAmount) *
+		(1.0 - c.conf.CacheCleaning))
+	c.Cache.volumec = int(float64(c.conf.CacheMaxVolume) *
+		(1.0 - c.conf.CacheCleaning))
+
+	for pk, prio := range c.conf.FeedPriorities {
+		if prio != 0 {
+			c.Cache.prios[pk] = prio
+		}
+	}
+
+	var n = c.conf.CacheShards
+
+	if n < 1 {
+		n = 1
+	}
+
+	c.Cache.shards = make([]*cacheShard, 0, n)
+
+	for i := 0; i < n; i++ {
+		c.Cache.shards = append(c.Cache.shards, c.newCacheShard(n))
+	}
+}
+
+// create shard of the Cache, the n is number of shards
+func (c *Container) newCacheShard(n int) (s *cacheShard) {
+
+	s = new(cacheShard)
+
+	s.c = c
+	s.enable = c.Cache.enable
+	s.stat = c.Cache.stat
+
+	s.is = make(map[cipher.SHA256]*item, (c.conf.CacheMaxAmount+n-1)/n)
+
+	return
+}
+
+// shard of given key
+func (c *Cache) shard(key cipher.SHA256) *cacheShard {
+	if len(c.shards) == 1 {
+		return c.shards[0]
+	}
+	return c.shards[binary.LittleEndian.Uint32(key[:])%uint32(len(c.shards))]
 }
 
 func (c *Cache) amountVolume() (a, v int) {
-	c.mx.Lock()
-	defer c.mx.Unlock()
+	return int(atomic.LoadInt64(&c.amount)), int(atomic.LoadInt64(&c.volume))
+}
+
+// add to amount and volume of the Cache
+func (c *Cache) addAmountVolume(a, v int) {
+	atomic.AddInt64(&c.amount, int64(a))
+	atomic.AddInt64(&c.volume, int64(v))
+}
 
-	return c.amount, c.volume
+// is the Cache full
+func (c *Cache) isFull() bool {
+	var a, v = c.amountVolume()
+	return a > c.c.conf.CacheMaxAmount || v > c.c.conf.CacheMaxVolume
 }
 
 // reset the Cache
 func (c *Cache) reset() {
-	c.is = nil
+	c.shards = nil
 	c.rs = nil
 	c.stat.Close()
 	c.stat = nil
 }
 
 // code readablility
-func (c *Cache) db() data.CXDS {
-	return c.c.db.CXDS()
+func (s *cacheShard) db() data.CXDS {
+	return s.c.db.CXDS()
 }
 
-// call it under lock
+// call it under lock of registries
 func (c *Cache) addRegistryToCache(r *registry.Registry) {
 
 	// if it already exists
@@ -206,8 +324,8 @@ func (c *Cache) AddRegistryToCache(r *registry.Registry) {
 		return
 	}
 
-	c.mx.Lock()
-	defer c.mx.Unlock()
+	c.rmx.Lock()
+	defer c.rmx.Unlock()
 
 	c.addRegistryToCache(r)
 
@@ -223,21 +341,23 @@ func (c *Cache) Registry(
 	err error, //               : an error
 ) {
 
-	c.mx.Lock()
-	defer c.mx.Unlock()
-
 	// check out cache first
 
+	c.rmx.Lock()
+
 	if ir, ok := c.rs[rr]; ok == true {
 		r = ir.r
 		ir.touch(c.c.conf.CachePolicy)
+		c.rmx.Unlock()
 		return
 	}
 
+	c.rmx.Unlock()
+
 	// get from DB and add to cache after
 
 	var val []byte
-	if val, _, err = c.get(cipher.SHA256(rr), 0); err != nil {
+	if val, _, err = c.Get(cipher.SHA256(rr), 0); err != nil {
 		return
 	}
 
@@ -246,7 +366,9 @@ func (c *Cache) Registry(
 	}
 
 	if c.c.conf.CacheRegistries >= 0 {
+		c.rmx.Lock()
 		c.addRegistryToCache(r)
+		c.rmx.Unlock()
 	}
 
 	return
@@ -256,11 +378,36 @@ func (c *Cache) Registry(
 // or nil
 func (c *Cache) Close() (err error) {
 
-	c.mx.Lock()
-	defer c.mx.Unlock()
+	c.stopWarmUp()
+
+	// keep hot keys before the syncing, the
+	// error is returned after the syncing
+	var werr = c.saveWarmUp()
+
+	for _, s := range c.shards {
+		if err = s.close(); err != nil {
+			return
+		}
+	}
+
+	// close the CXDS
+	err = c.c.db.CXDS().Close()
+	c.stat.Close() // close goroutine
+
+	if err == nil {
+		err = werr
+	}
+
+	return
+}
+
+// sync items of the shard
+func (s *cacheShard) close() (err error) {
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
 
-	// sync items
-	for key, it := range c.is {
+	for key, it := range s.is {
 
 		// make wanted items filling
 		if it.isWanted() == true {
@@ -273,28 +420,24 @@ func (c *Cache) Close() (err error) {
 		it.cc -= it.fc // remove all fincs
 		it.fc = 0
 
-		if err = c.delete(key, it); err != nil {
+		if err = s.delete(key, it); err != nil {
 			return
 		}
 
 	}
 
-	// close the CXDS
-	err = c.c.db.CXDS().Close()
-	c.stat.Close() // close goroutine
-
 	return
 }
 
 // delete item from the Cache
-func (c *Cache) delete(key cipher.SHA256, it *item) (err error) {
+func (s *cacheShard) delete(key cipher.SHA256, it *item) (err error) {
 
 	var inc = it.cc - it.rc // real rc
 
 	if inc != 0 {
 
-		_, err = c.db().Inc(key, inc)
-		c.stat.addWritingDBRequest() // write DB
+		_, err = s.db().Inc(key, inc)
+		s.stat.addWritingDBRequest() // write DB
 
 		if err != nil {
 			return
@@ -302,11 +445,10 @@ func (c *Cache) delete(key cipher.SHA256, it *item) (err error) {
 
 	}
 
-	c.amount--
-	c.volume -= len(it.val)
+	s.c.Cache.addAmountVolume(-1, -len(it.val))
 
 	if it.fc == 0 {
-		delete(c.is, key)
+		delete(s.is, key)
 		return
 	}
 
@@ -317,90 +459,259 @@ func (c *Cache) delete(key cipher.SHA256, it *item) (err error) {
 	it.rc = 0
 	it.cc = 0
 	it.cachePoints = 0
+	it.hot = false
+	it.prio, it.hasPrio = 0, false
 
 	return
 }
 
-// clean the Cache down to lower boundary
-func (c *Cache) cleanDown(vol int) (err error) {
+// touch item of the Cache
+func (s *cacheShard) touchItem(it *item) {
 
-	// stat (average cleaning time)
-	var tp = time.Now()
-	defer func() { c.stat.addCacheCleaning(time.Now().Sub(tp)) }()
+	if s.c.conf.CachePolicy != TwoQ {
+		it.touch(s.c.conf.CachePolicy)
+		return
+	}
+
+	// A1in is FIFO, thus a one-pass scan never
+	// promotes its items and never evicts hot ones
+
+	if it.hot == true {
+		it.cachePoints = s.c.Cache.tick() // LRU
+	}
+
+}
+
+// touch item that has been put to the Cache
+func (s *cacheShard) touchNewItem(key cipher.SHA256, it *item) {
 
-	type rankItem struct {
-		key cipher.SHA256
-		it  *item
+	if s.c.conf.CachePolicy != TwoQ {
+		it.touch(s.c.conf.CachePolicy)
+		return
 	}
 
-	var rank = make([]*rankItem, 0, len(c.is)) // rank items
+	// item evicted from A1in recently is hot
 
-	for key, it := range c.is {
+	if s.c.Cache.delGhost(key) == true {
+		it.hot = true
+	}
+
+	it.cachePoints = s.c.Cache.tick()
+}
+
+// 2Q: next logical time
+func (c *Cache) tick() cachePoints {
+	return cachePoints(atomic.AddInt64(&c.clock, 1))
+}
+
+// remember key of item evicted from A1in
+func (c *Cache) addGhost(key cipher.SHA256) {
+
+	c.gmx.Lock()
+	defer c.gmx.Unlock()
+
+	c.ghosts[key] = struct{}{}
+	c.ghostq = append(c.ghostq, key)
+
+	var max = int(float64(c.c.conf.CacheMaxAmount) * twoQOut)
+
+	for len(c.ghostq) > max {
+		delete(c.ghosts, c.ghostq[0]) // can be already removed
+		c.ghostq[0] = cipher.SHA256{}
+		c.ghostq = c.ghostq[1:]
+	}
+
+}
+
+// forget key of item evicted from A1in
+// returning true if the key was there
+func (c *Cache) delGhost(key cipher.SHA256) (ok bool) {
+
+	c.gmx.Lock()
+	defer c.gmx.Unlock()
+
+	if _, ok = c.ghosts[key]; ok == true {
+		delete(c.ghosts, key)
+	}
+
+	return
+}
+
+// sort items to remove using 2Q: oldest new items
+// (A1in) that exceed its part first, then hot items
+// (Am) in LRU order, and then rest of the new items
+func (c *Cache) rankTwoQ(rank []*rankItem) {
+
+	sort.Slice(rank, func(i, j int) bool {
+		return rank[i].points < rank[j].points
+	})
+
+	var cold, hot []*rankItem // A1in, Am
+
+	for _, ri := range rank {
+		if ri.hot == true {
+			hot = append(hot, ri)
+		} else {
+			cold = append(cold, ri)
+		}
+	}
+
+	var extra = len(cold) - int(float64(c.amountc)*twoQIn)
+
+	if extra < 0 {
+		extra = 0
+	}
+
+	var n = copy(rank, cold[:extra])
+	n += copy(rank[n:], hot)
+	copy(rank[n:], cold[extra:])
+}
+
+// item to remove from the Cache; the points, the
+// prio and the hot are copied under lock of the shard
+type rankItem struct {
+	key cipher.SHA256
+	it  *item
+	s   *cacheShard // shard of the item
+
+	points cachePoints
+	prio   int
+	hot    bool
+}
+
+// append items that can be removed to given rank
+func (s *cacheShard) rank(
+	rank []*rankItem, //   : append to
+	skip cipher.SHA256, // : don't remove this item
+) []*rankItem {
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	for key, it := range s.is {
+
+		if key == skip {
+			continue // just put
+		}
 
 		if it.isWanted() == true {
-			return // skip wanted
+			continue // skip wanted
 		}
 
 		if it.isFilling() == true {
-			return // skip filling (where val is nil)
+			continue // skip filling (where val is nil)
 		}
 
-		rank = append(rank, &rankItem{key, it})
+		rank = append(rank, &rankItem{
+			key:    key,
+			it:     it,
+			s:      s,
+			points: it.cachePoints,
+			prio:   it.prio,
+			hot:    it.hot,
+		})
 
 	}
 
-	// sort the rank using cachePoints; we
-	// are removing items with less points
+	return rank
+}
 
-	sort.Slice(rank, func(i, j int) bool {
-		return rank[i].it.cachePoints < rank[j].it.cachePoints
-	})
+// evict item from the Cache
+func (s *cacheShard) evict(ri *rankItem) (err error) {
 
-	// clean by amount first
+	s.mx.Lock()
+	defer s.mx.Unlock()
 
-	if c.amount+1 > c.c.conf.CacheMaxAmount {
+	// the item can be removed or turned
+	// to be wanted or filling meanwhile
 
-		var (
-			i  int       // to reduce the rank slice
-			ri *rankItem // for the range (we need the i)
-		)
+	if it, ok := s.is[ri.key]; ok == false || it != ri.it ||
+		it.isWanted() == true || it.isFilling() == true {
 
-		for i, ri = range rank {
+		return
+	}
 
-			if c.amount < c.amountc { // actually, `amount + 1 == ...`
-				break
-			}
+	var cold = s.c.conf.CachePolicy == TwoQ && ri.it.hot == false
 
-			// delete item from the Cache
-			if err = c.delete(ri.key, ri.it); err != nil {
-				return // fail on first error
-			}
+	if err = s.delete(ri.key, ri.it); err != nil {
+		return
+	}
 
-			rank[i].it = nil // GC
+	if cold == true {
+		s.c.Cache.addGhost(ri.key)
+	}
 
-		}
+	return
+}
 
-		rank = rank[i:] // shift
+// clean the Cache down to lower boundary if the Cache
+// is full; the skip is key of item that has been put
+// to the Cache, it's not removed; the cleanDown locks
+// shards one by one, thus it must be called without
+// lock of a shard
+func (c *Cache) cleanDown(skip cipher.SHA256) (err error) {
 
+	if c.isFull() == false {
+		return
 	}
 
-	// clean by volume if need
+	c.cmx.Lock()
+	defer c.cmx.Unlock()
 
-	if c.volume+vol < c.c.conf.CacheMaxVolume {
-		return // enough
+	if c.isFull() == false {
+		return // cleaned by another goroutine
 	}
 
+	// stat (average cleaning time)
+	var tp = time.Now()
+	defer func() { c.stat.addCacheCleaning(time.Now().Sub(tp)) }()
+
+	var rank []*rankItem // rank items of all shards
+
+	for _, s := range c.shards {
+		rank = s.rank(rank, skip)
+	}
+
+	// sort the rank using cachePoints; we
+	// are removing items with less points
+
+	if c.c.conf.CachePolicy == TwoQ {
+		c.rankTwoQ(rank)
+	} else {
+		sort.Slice(rank, func(i, j int) bool {
+			return rank[i].points < rank[j].points
+		})
+	}
+
+	// items of low priority feeds first
+	sort.SliceStable(rank, func(i, j int) bool {
+		return rank[i].prio < rank[j].prio
+	})
+
+	var (
+		amount, volume = c.amountVolume()
+
+		byAmount = amount > c.c.conf.CacheMaxAmount
+		byVolume = volume > c.c.conf.CacheMaxVolume
+	)
+
 	for i, ri := range rank {
 
-		if c.volume+vol <= c.volumec {
-			break
+		amount, volume = c.amountVolume()
+
+		byAmount = byAmount && amount > c.amountc
+		byVolume = byVolume && volume > c.volumec
+
+		if byAmount == false && byVolume == false {
+			break // enough
 		}
 
-		if err = c.delete(ri.key, ri.it); err != nil {
+		// delete item from shard of the item
+		if err = ri.s.evict(ri); err != nil {
 			return // fail on first error
 		}
 
-		rank[i].it = nil // GC
+		rank[i] = nil // GC
 
 	}
 
@@ -408,7 +719,7 @@ func (c *Cache) cleanDown(vol int) (err error) {
 }
 
 // create regular item in the cache
-func (c *Cache) putItem(
+func (s *cacheShard) putItem(
 	key cipher.SHA256,
 	val []byte,
 	rc int,
@@ -416,11 +727,11 @@ func (c *Cache) putItem(
 	err error,
 ) {
 
-	if c.enable == false {
+	if s.enable == false {
 		return
 	}
 
-	if len(val) > c.c.conf.CacheMaxItemSize {
+	if len(val) > s.c.conf.CacheMaxItemSize {
 		return
 	}
 
@@ -428,30 +739,20 @@ func (c *Cache) putItem(
 		return // don't cache stale values
 	}
 
-	switch {
-	case c.amount+1 > c.c.conf.CacheMaxAmount,
-		c.volume+len(val) > c.c.conf.CacheMaxVolume:
-
-		if err = c.cleanDown(len(val)); err != nil {
-			return
-		}
-
-	}
-
 	var it = &item{rc: rc, cc: rc, val: val}
 
-	it.touch(c.c.conf.CachePolicy)
-	c.is[key] = it
+	s.touchNewItem(key, it)
+	s.is[key] = it
 
-	c.amount++
-	c.volume += len(val)
+	s.c.Cache.addAmountVolume(1, len(val))
 
 	return
 }
 
 // create item with fc > 0; e.g.
 // add data to the filling item
-func (c *Cache) putFillingItem(
+func (s *cacheShard) putFillingItem(
+	key cipher.SHA256,
 	val []byte,
 	rc int,
 	it *item, // filling item
@@ -459,11 +760,11 @@ func (c *Cache) putFillingItem(
 	err error,
 ) {
 
-	if c.enable == false {
+	if s.enable == false {
 		return
 	}
 
-	if len(val) > c.c.conf.CacheMaxItemSize {
+	if len(val) > s.c.conf.CacheMaxItemSize {
 		return
 	}
 
@@ -471,24 +772,13 @@ func (c *Cache) putFillingItem(
 		return // don't cache stale values
 	}
 
-	switch {
-	case c.amount+1 > c.c.conf.CacheMaxAmount,
-		c.volume+len(val) > c.c.conf.CacheMaxVolume:
-
-		if err = c.cleanDown(len(val)); err != nil {
-			return
-		}
-
-	}
-
 	it.val = val
 	it.rc = rc // real
 	it.cc = rc // real
 
-	it.touch(c.c.conf.CachePolicy)
+	s.touchNewItem(key, it)
 
-	c.amount++
-	c.volume += len(val)
+	s.c.Cache.addAmountVolume(1, len(val))
 
 	return
 }
@@ -520,7 +810,7 @@ func incr(cc, inc int) (dcc int) {
 // subtree of another filler; because if the another
 // filler fails, it (the another filler) removes the
 // subtree from DB
-func (c *Cache) getFilling(
+func (s *cacheShard) getFilling(
 	key cipher.SHA256,
 	inc int,
 	it *item,
@@ -531,8 +821,8 @@ func (c *Cache) getFilling(
 ) {
 
 	var urc uint32
-	val, urc, err = c.db().Get(key, inc)
-	c.stat.addDBGet(inc)
+	val, urc, err = s.db().Get(key, inc)
+	s.stat.addDBGet(inc)
 
 	if err != nil {
 		return
@@ -540,7 +830,7 @@ func (c *Cache) getFilling(
 
 	rc = int(urc) - it.fc
 
-	err = c.putFillingItem(val, int(urc), it)
+	err = s.putFillingItem(key, val, int(urc), it)
 	return
 }
 
@@ -556,10 +846,14 @@ func (c *Cache) getNoCache(
 	err error,
 ) {
 
-	c.mx.Lock()
-	defer c.mx.Unlock()
+	var s = c.shard(key)
+
+	defer c.cleanAfter(key, &err) // after unlock
 
-	var it, ok = c.is[key]
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	var it, ok = s.is[key]
 
 	if ok == true {
 		if it.isWanted() == true {
@@ -569,7 +863,7 @@ func (c *Cache) getNoCache(
 		// nothing wrong with caching a filling item
 
 		if it.isFilling() == true {
-			return c.getFilling(key, inc, it)
+			return s.getFilling(key, inc, it)
 		}
 
 		// the delete below can clean the val field
@@ -577,9 +871,9 @@ func (c *Cache) getNoCache(
 
 		// remove item if it's cc is zero
 		if it.cc = incr(it.cc, inc); it.cc == 0 {
-			c.delete(key, it)
+			s.delete(key, it)
 		} else {
-			c.stat.addCacheGet(inc) // effective cache get
+			s.stat.addCacheGet(inc) // effective cache get
 		}
 
 		rc = it.cc - it.fc // hard rc
@@ -589,8 +883,8 @@ func (c *Cache) getNoCache(
 	// not found in the Cache
 
 	var urc uint32
-	val, urc, err = c.db().Get(key, inc)
-	c.stat.addDBGet(inc)
+	val, urc, err = s.db().Get(key, inc)
+	s.stat.addDBGet(inc)
 
 	if err != nil {
 		return
@@ -602,8 +896,17 @@ func (c *Cache) getNoCache(
 	return
 }
 
+// clean the Cache after an operation with item with
+// given key, if the Cache is full (see cleanDown); the
+// error of the cleaning is set to the err if it's nil
+func (c *Cache) cleanAfter(key cipher.SHA256, err *error) {
+	if cerr := c.cleanDown(key); *err == nil {
+		*err = cerr
+	}
+}
+
 // under lock
-func (c *Cache) get(
+func (s *cacheShard) get(
 	key cipher.SHA256,
 	inc int,
 ) (
@@ -612,7 +915,7 @@ func (c *Cache) get(
 	err error,
 ) {
 
-	var it, ok = c.is[key]
+	var it, ok = s.is[key]
 
 	if ok == true {
 		if it.isWanted() == true {
@@ -620,7 +923,7 @@ func (c *Cache) get(
 		}
 
 		if it.isFilling() == true {
-			return c.getFilling(key, inc, it)
+			return s.getFilling(key, inc, it)
 		}
 
 		// the delete below can clean the val field
@@ -628,10 +931,10 @@ func (c *Cache) get(
 
 		// remove item if it's cc is zero
 		if it.cc = incr(it.cc, inc); it.cc == 0 {
-			c.delete(key, it)
+			s.delete(key, it)
 		} else {
-			c.stat.addCacheGet(inc) // effective cache get
-			it.touch(c.c.conf.CachePolicy)
+			s.stat.addCacheGet(inc) // effective cache get
+			s.touchItem(it)
 		}
 
 		rc = it.cc - it.fc // hard rc
@@ -642,8 +945,8 @@ func (c *Cache) get(
 	// not found in the Cache
 
 	var urc uint32
-	val, urc, err = c.db().Get(key, inc)
-	c.stat.addDBGet(inc)
+	val, urc, err = s.db().Get(key, inc)
+	s.stat.addDBGet(inc)
 
 	if err != nil {
 		return
@@ -651,7 +954,7 @@ func (c *Cache) get(
 
 	rc = int(urc) // hard rc
 
-	err = c.putItem(key, val, rc)
+	err = s.putItem(key, val, rc)
 	return
 }
 
@@ -666,10 +969,12 @@ func (c *Cache) get(
 // Use this method cleaning up DB.
 func (c *Cache) IsCached(key cipher.SHA256) (yep bool) {
 
-	c.mx.Lock()
-	defer c.mx.Unlock()
+	var s = c.shard(key)
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
 
-	_, yep = c.is[key]
+	_, yep = s.is[key]
 	return
 }
 
@@ -696,10 +1001,14 @@ func (c *Cache) Get(
 	err error,
 ) {
 
-	c.mx.Lock()
-	defer c.mx.Unlock()
+	var s = c.shard(key)
+
+	defer c.cleanAfter(key, &err) // after unlock
 
-	return c.get(key, inc)
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	return s.get(key, inc)
 }
 
 // never block
@@ -710,7 +1019,7 @@ func sendWanted(gc chan<- Object, obj Object) {
 	}
 }
 
-func (c *Cache) setWanted(
+func (s *cacheShard) setWanted(
 	key cipher.SHA256,
 	val []byte,
 	inc int,
@@ -720,7 +1029,7 @@ func (c *Cache) setWanted(
 	err error,
 ) {
 
-	if len(val) > c.c.conf.MaxObjectSize {
+	if len(val) > s.c.conf.MaxObjectSize {
 		err = &ObjectIsTooLargeError{key}
 
 		// ignore the inc
@@ -731,7 +1040,7 @@ func (c *Cache) setWanted(
 			sendWanted(gc, obj)
 		}
 
-		delete(c.is, key) // force
+		delete(s.is, key) // force
 		return
 	}
 
@@ -744,8 +1053,8 @@ func (c *Cache) setWanted(
 
 	// save
 	var urc uint32
-	urc, err = c.db().Set(key, val, inc+wincs)
-	c.stat.addWritingDBRequest()
+	urc, err = s.db().Set(key, val, inc+wincs)
+	s.stat.addWritingDBRequest()
 
 	if err != nil {
 		return // DB failure
@@ -763,11 +1072,11 @@ func (c *Cache) setWanted(
 	it.fwant = nil // not wanted anymore (GC)
 	it.fc += wincs // incs of fillers (of wanters)
 
-	err = c.putFillingItem(val, int(urc), it)
+	err = s.putFillingItem(key, val, int(urc), it)
 	return
 }
 
-func (c *Cache) setFilling(
+func (s *cacheShard) setFilling(
 	key cipher.SHA256,
 	val []byte,
 	inc int,
@@ -790,7 +1099,7 @@ func (c *Cache) setFilling(
 	// incItem instead of the Set, but for filling items
 	// it's equal to call incFilling
 
-	return c.incFilling(key, inc, it)
+	return s.incFilling(key, inc, it)
 }
 
 // Set adds value to DB and to the Cache if it's enabled.
@@ -808,19 +1117,23 @@ func (c *Cache) Set(
 		panic("invalid inc argument of Set method: " + fmt.Sprint(inc))
 	}
 
-	c.mx.Lock()
-	defer c.mx.Unlock()
+	var s = c.shard(key)
+
+	defer c.cleanAfter(key, &err) // after unlock
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
 
-	var it, ok = c.is[key]
+	var it, ok = s.is[key]
 
 	if ok == true {
 
 		if it.isWanted() == true {
-			return c.setWanted(key, val, inc, it)
+			return s.setWanted(key, val, inc, it)
 		}
 
 		if it.isFilling() == true {
-			return c.setFilling(key, val, inc, it)
+			return s.setFilling(key, val, inc, it)
 		}
 
 		// the delete below can clean the it.val
@@ -828,10 +1141,10 @@ func (c *Cache) Set(
 
 		// remove item if it's cc is zero
 		if it.cc = incr(it.cc, inc); it.cc == 0 {
-			c.delete(key, it) // not effective cache set
+			s.delete(key, it) // not effective cache set
 		} else {
-			c.stat.addWritingCacheRequest() // effective cache set
-			it.touch(c.c.conf.CachePolicy)
+			s.stat.addWritingCacheRequest() // effective cache set
+			s.touchItem(it)
 		}
 
 		rc = it.cc - it.fc // hard rc
@@ -841,25 +1154,25 @@ func (c *Cache) Set(
 
 	// not found in the cache
 
-	if len(val) > c.c.conf.MaxObjectSize {
+	if len(val) > s.c.conf.MaxObjectSize {
 		err = &ObjectIsTooLargeError{key}
 		return
 	}
 
 	var urc uint32
-	urc, err = c.db().Set(key, val, inc)
-	c.stat.addWritingDBRequest()
+	urc, err = s.db().Set(key, val, inc)
+	s.stat.addWritingDBRequest()
 
 	if err != nil {
 		return
 	}
 
 	rc = int(urc)
-	err = c.putItem(key, val, rc)
+	err = s.putItem(key, val, rc)
 	return
 }
 
-func (c *Cache) incFilling(
+func (s *cacheShard) incFilling(
 	key cipher.SHA256,
 	inc int,
 	it *item,
@@ -873,13 +1186,13 @@ func (c *Cache) incFilling(
 		val []byte
 	)
 
-	if c.enable == true {
-		val, urc, err = c.db().Get(key, inc)
+	if s.enable == true {
+		val, urc, err = s.db().Get(key, inc)
 	} else {
-		urc, err = c.db().Inc(key, inc)
+		urc, err = s.db().Inc(key, inc)
 	}
 
-	c.stat.addDBGet(inc)
+	s.stat.addDBGet(inc)
 
 	if err != nil {
 		return
@@ -887,11 +1200,11 @@ func (c *Cache) incFilling(
 
 	rc = int(urc) - it.fc // hard rc
 
-	err = c.putFillingItem(val, int(urc), it)
+	err = s.putFillingItem(key, val, int(urc), it)
 	return
 }
 
-func (c *Cache) incItem(
+func (s *cacheShard) incItem(
 	key cipher.SHA256, // :
 	inc int, //           :
 	it *item, //          :
@@ -905,15 +1218,15 @@ func (c *Cache) incItem(
 	}
 
 	if it.isFilling() == true {
-		return c.incFilling(key, inc, it)
+		return s.incFilling(key, inc, it)
 	}
 
 	// remove item if it's cc is zero
 	if it.cc = incr(it.cc, inc); it.cc == 0 {
-		c.delete(key, it)
+		s.delete(key, it)
 	} else {
-		c.stat.addCacheGet(inc) // effective cache get
-		it.touch(c.c.conf.CachePolicy)
+		s.stat.addCacheGet(inc) // effective cache get
+		s.touchItem(it)
 	}
 
 	rc = it.cc - it.fc // hard rc
@@ -921,7 +1234,7 @@ func (c *Cache) incItem(
 }
 
 // under lock
-func (c *Cache) inc(
+func (s *cacheShard) inc(
 	key cipher.SHA256, // :
 	inc int, //           :
 ) (
@@ -929,10 +1242,10 @@ func (c *Cache) inc(
 	err error, //         :
 ) {
 
-	var it, ok = c.is[key]
+	var it, ok = s.is[key]
 
 	if ok == true {
-		return c.incItem(key, inc, it)
+		return s.incItem(key, inc, it)
 	}
 
 	// not found in the Cache
@@ -944,19 +1257,19 @@ func (c *Cache) inc(
 		val []byte
 	)
 
-	if c.enable == true {
-		val, urc, err = c.db().Get(key, inc)
+	if s.enable == true {
+		val, urc, err = s.db().Get(key, inc)
 	} else {
-		urc, err = c.db().Inc(key, inc)
+		urc, err = s.db().Inc(key, inc)
 	}
 
-	c.stat.addDBGet(inc)
+	s.stat.addDBGet(inc)
 
 	if err != nil {
 		return
 	}
 
-	err = c.putItem(key, val, int(urc))
+	err = s.putItem(key, val, int(urc))
 	return
 
 }
@@ -970,10 +1283,14 @@ func (c *Cache) Inc(
 	err error, //         :
 ) {
 
-	c.mx.Lock()
-	defer c.mx.Unlock()
+	var s = c.shard(key)
 
-	return c.inc(key, inc)
+	defer c.cleanAfter(key, &err) // after unlock
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	return s.inc(key, inc)
 }
 
 //
@@ -999,10 +1316,14 @@ func (c *Cache) Want(
 	err error,
 ) {
 
-	c.mx.Lock()
-	defer c.mx.Unlock()
+	var s = c.shard(key)
+
+	defer c.cleanAfter(key, &err) // after unlock
 
-	var it, ok = c.is[key]
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	var it, ok = s.is[key]
 
 	if ok == true {
 
@@ -1018,7 +1339,7 @@ func (c *Cache) Want(
 				rc  int
 			)
 
-			if val, rc, err = c.getFilling(key, inc, it); err != nil {
+			if val, rc, err = s.getFilling(key, inc, it); err != nil {
 
 				if err == data.ErrNotFound {
 					it.fwant = map[chan<- Object]int{gc: inc} // want
@@ -1045,12 +1366,12 @@ func (c *Cache) Want(
 		sendWanted(gc, Object{key, it.val, it.cc - it.fc, nil})
 
 		if inc == 0 {
-			c.stat.addReadingCacheRequest() // effective
+			s.stat.addReadingCacheRequest() // effective
 		} else {
-			c.stat.addWritingCacheRequest() // effective
+			s.stat.addWritingCacheRequest() // effective
 		}
 
-		it.touch(c.c.conf.CachePolicy)
+		s.touchItem(it)
 		return
 
 	}
@@ -1062,8 +1383,8 @@ func (c *Cache) Want(
 		val []byte
 	)
 
-	val, urc, err = c.db().Get(key, inc)
-	c.stat.addDBGet(inc)
+	val, urc, err = s.db().Get(key, inc)
+	s.stat.addDBGet(inc)
 
 	if err != nil {
 
@@ -1071,7 +1392,7 @@ func (c *Cache) Want(
 			// create wanted item
 			it = new(item)
 			it.fwant = map[chan<- Object]int{gc: inc}
-			c.is[key] = it
+			s.is[key] = it
 			err = nil // clear
 		}
 
@@ -1086,9 +1407,9 @@ func (c *Cache) Want(
 
 	it = new(item)
 	it.fc = inc
-	c.is[key] = it
+	s.is[key] = it
 
-	err = c.putFillingItem(val, int(urc), it)
+	err = s.putFillingItem(key, val, int(urc), it)
 	return
 }
 
@@ -1099,16 +1420,18 @@ func (c *Cache) Unwant(
 	gc chan<- Object,
 ) {
 
-	c.mx.Lock()
-	defer c.mx.Unlock()
+	var s = c.shard(key)
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
 
-	if it := c.is[key]; it != nil && it.fwant != nil {
+	if it := s.is[key]; it != nil && it.fwant != nil {
 		delete(it.fwant, gc)
 		if len(it.fwant) == 0 {
 			it.fwant = nil // GC
 			// not wanted, not filling, not regular
 			if it.fc == 0 && len(it.val) == 0 {
-				delete(c.is, key)
+				delete(s.is, key)
 			}
 		}
 	}
@@ -1126,10 +1449,14 @@ func (c *Cache) SetWanted(
 	err error,
 ) {
 
-	c.mx.Lock()
-	defer c.mx.Unlock()
+	var s = c.shard(key)
+
+	defer c.cleanAfter(key, &err) // after unlock
 
-	var it, ok = c.is[key]
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	var it, ok = s.is[key]
 
 	if ok == false {
 		return
@@ -1139,7 +1466,7 @@ func (c *Cache) SetWanted(
 		return
 	}
 
-	return c.setWanted(key, val, 0, it)
+	return s.setWanted(key, val, 0, it)
 }
 
 // Finc is like the Inc, but it used by fillers to
@@ -1163,10 +1490,14 @@ func (c *Cache) Finc(
 		panic("(Cache).Finc called with zero for: " + key.Hex()[:7])
 	}
 
-	c.mx.Lock()
-	defer c.mx.Unlock()
+	var s = c.shard(key)
+
+	defer c.cleanAfter(key, &err) // after unlock
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
 
-	var it, ok = c.is[key]
+	var it, ok = s.is[key]
 
 	if ok == false {
 		return
@@ -1199,13 +1530,13 @@ func (c *Cache) Finc(
 		}
 
 		if len(it.val) == 0 { // filling item (filling only)
-			delete(c.is, key)
+			delete(s.is, key)
 		}
 
 		// a filling items turns to be a regular (since the fc is zero)
 
 		// keep
-		it.touch(c.c.conf.CachePolicy)
+		s.touchItem(it)
 		return
 	}
 
@@ -1220,6 +1551,6 @@ func (c *Cache) Finc(
 	// and if it.fc turns to be zero, then the
 	// incItem removes it
 
-	_, err = c.incItem(key, inc, it) // in db
+	_, err = s.incItem(key, inc, it) // in db
 	return
 }
@@ -1,7 +1,410 @@
 package skyobject
 
 import (
-//"testing"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/data"
+	"github.com/skycoin/cxo/data/cxds"
+	"github.com/skycoin/cxo/data/idxdb"
 )
 
-//
+// CXDS that counts Get requests
+type countingCXDS struct {
+	data.CXDS
+	gets int
+}
+
+func (c *countingCXDS) Get(
+	key cipher.SHA256,
+	inc int,
+) (
+	val []byte,
+	rc uint32,
+	err error,
+) {
+	c.gets++
+	return c.CXDS.Get(key, inc)
+}
+
+func getTestCacheContainer(
+	policy CachePolicy,
+	amount int,
+) (
+	c *Container,
+	db *countingCXDS,
+) {
+
+	db = &countingCXDS{CXDS: cxds.NewMemoryCXDS()}
+
+	var conf = getTestConfig()
+
+	conf.DB = data.NewDB(db, idxdb.NewMemeoryDB())
+	conf.CachePolicy = policy
+	conf.CacheMaxAmount = amount
+
+	var err error
+	if c, err = NewContainer(conf); err != nil {
+		panic(err)
+	}
+
+	return
+}
+
+// n objects with given prefix
+func testCacheObjects(prefix string, n int) (keys []cipher.SHA256,
+	vals [][]byte) {
+
+	keys = make([]cipher.SHA256, 0, n)
+	vals = make([][]byte, 0, n)
+
+	for i := 0; i < n; i++ {
+		var val = make([]byte, len(prefix)+8)
+		copy(val, prefix)
+		binary.LittleEndian.PutUint64(val[len(prefix):], uint64(i))
+		keys = append(keys, cipher.SumSHA256(val))
+		vals = append(vals, val)
+	}
+
+	return
+}
+
+func testCacheSet(t testing.TB, c *Container, keys []cipher.SHA256,
+	vals [][]byte) {
+
+	for i, key := range keys {
+		if _, err := c.Set(key, vals[i], 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func testCacheGet(t testing.TB, c *Container, keys []cipher.SHA256) {
+	for _, key := range keys {
+		if _, _, err := c.Get(key, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCachePolicy_Set(t *testing.T) {
+
+	var p CachePolicy
+
+	for _, s := range []string{"LRU", "lfu", "2q"} {
+		if err := p.Set(s); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if p != TwoQ {
+		t.Error("wrong policy", p)
+	}
+
+	if err := p.Set("MRU"); err == nil {
+		t.Error("missing error")
+	}
+
+	var conf = getTestConfig()
+	conf.CachePolicy = TwoQ
+	assertNil(t, conf.Validate())
+
+}
+
+func TestCache_twoQ(t *testing.T) {
+
+	var c, _ = getTestCacheContainer(TwoQ, 100)
+	defer c.Close()
+
+	var (
+		hot, hotv   = testCacheObjects("hot", 10)
+		scan, scanv = testCacheObjects("scan", 1000)
+	)
+
+	testCacheSet(t, c, hot, hotv)
+	testCacheSet(t, c, scan[:91], scanv[:91]) // evicts the hot
+
+	for _, key := range hot {
+		if c.IsCached(key) == true {
+			t.Fatal("not evicted")
+		}
+	}
+
+	testCacheGet(t, c, hot) // the hot are hot now
+
+	// one-pass scan
+	testCacheSet(t, c, scan[91:], scanv[91:])
+
+	for _, key := range hot {
+		if c.IsCached(key) == false {
+			t.Fatal("hot item evicted by scan")
+		}
+	}
+
+}
+
+func TestCache_cleanDown(t *testing.T) {
+
+	var c, _ = getTestCacheContainer(LRU, 100)
+	defer c.Close()
+
+	// wanted items must not stop the cleaning
+
+	var (
+		wanted, _  = testCacheObjects("wanted", 10)
+		keys, vals = testCacheObjects("clean", 1000)
+		gc         = make(chan Object, len(wanted))
+	)
+
+	for _, key := range wanted {
+		if err := c.Want(key, gc, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCacheSet(t, c, keys, vals)
+
+	if amount, _ := c.Cache.amountVolume(); amount > 100 {
+		t.Error("cache is not cleaned", amount)
+	}
+
+}
+
+// reads of a working set and rare one-pass scans
+func benchmarkCacheRead(b *testing.B, policy CachePolicy) {
+
+	var c, db = getTestCacheContainer(policy, 512)
+	defer c.Close()
+
+	var (
+		keys, vals = testCacheObjects("read", 8192)
+		hot        = keys[:64]
+		rnd        = rand.New(rand.NewSource(42))
+	)
+
+	testCacheSet(b, c, keys, vals)
+	testCacheGet(b, c, hot)
+	testCacheGet(b, c, hot) // warm up
+
+	db.gets = 0
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+
+		if i%1000 == 999 {
+			testCacheGet(b, c, keys[rnd.Intn(len(keys)-1024):][:1024]) // scan
+			continue
+		}
+
+		testCacheGet(b, c, hot[rnd.Intn(len(hot)):][:1])
+
+	}
+
+	b.ReportMetric(float64(db.gets)/float64(b.N), "db-gets/op")
+}
+
+// fill new objects reading working set
+func benchmarkCacheFill(b *testing.B, policy CachePolicy) {
+
+	var c, db = getTestCacheContainer(policy, 512)
+	defer c.Close()
+
+	var (
+		keys, vals = testCacheObjects("fill", 64)
+		gc         = make(chan Object, 1)
+	)
+
+	testCacheSet(b, c, keys, vals)
+	testCacheGet(b, c, keys)
+	testCacheGet(b, c, keys) // warm up
+
+	db.gets = 0
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+
+		// filling of 64 new objects
+
+		var nk, nv = testCacheObjects("new"+string(rune(i)), 64)
+
+		for j, key := range nk {
+
+			if err := c.Want(key, gc, 1); err != nil {
+				b.Fatal(err)
+			}
+
+			if _, err := c.SetWanted(key, nv[j]); err != nil {
+				b.Fatal(err)
+			}
+
+			<-gc
+
+			if err := c.Finc(key, 1); err != nil {
+				b.Fatal(err)
+			}
+
+		}
+
+		// the working set
+
+		testCacheGet(b, c, keys)
+
+	}
+
+	b.ReportMetric(float64(db.gets)/float64(b.N), "db-gets/op")
+}
+
+func TestCache_shards(t *testing.T) {
+
+	var c = getTestContainer()
+	defer c.Close()
+
+	if len(c.Cache.shards) != CacheShards {
+		t.Fatal("wrong number of shards", len(c.Cache.shards))
+	}
+
+	var (
+		keys, vals = testCacheObjects("shards", 1000)
+		wg         sync.WaitGroup
+	)
+
+	// many fillers, keys of every filler are spread over the shards
+
+	for i := 0; i < 4; i++ {
+
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			var gc = make(chan Object, 1)
+
+			for j := i; j < len(keys); j += 4 {
+
+				var key = keys[j]
+
+				if err := c.Want(key, gc, 1); err != nil {
+					t.Error(err)
+					return
+				}
+
+				if _, err := c.SetWanted(key, vals[j]); err != nil {
+					t.Error(err)
+					return
+				}
+
+				if obj := <-gc; obj.Err != nil {
+					t.Error(obj.Err)
+					return
+				}
+
+				if err := c.Finc(key, 1); err != nil {
+					t.Error(err)
+					return
+				}
+
+			}
+		}(i)
+
+	}
+
+	wg.Wait()
+
+	var amount, _ = c.Cache.amountVolume()
+
+	if amount != len(keys) {
+		t.Error("wrong amount", amount)
+	}
+
+	for _, key := range keys {
+		if _, rc, err := c.Get(key, 0); err != nil {
+			t.Fatal(err)
+		} else if rc != 1 {
+			t.Fatal("wrong rc", rc)
+		}
+	}
+
+	t.Run("limits", func(t *testing.T) {
+
+		// limits of the Cache are not limits of a shard
+
+		var c, _ = getTestCacheContainer(LRU, 100)
+		defer c.Close()
+
+		var keys, vals = testCacheObjects("limits", 1000)
+
+		testCacheSet(t, c, keys[:100], vals[:100])
+
+		if amount, _ := c.Cache.amountVolume(); amount != 100 {
+			t.Error("wrong amount", amount)
+		}
+
+		testCacheSet(t, c, keys[100:], vals[100:])
+
+		if amount, _ := c.Cache.amountVolume(); amount > 100 {
+			t.Error("cache is not cleaned", amount)
+		}
+
+	})
+
+}
+
+// concurrent reads of a working set
+func benchmarkCacheParallel(b *testing.B, shards int) {
+
+	var conf = getTestConfig()
+
+	conf.CacheShards = shards
+
+	var c, err = NewContainer(conf)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer c.Close()
+
+	var keys, vals = testCacheObjects("parallel", 1024)
+
+	testCacheSet(b, c, keys, vals)
+
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for i := rand.Int(); pb.Next() == true; i++ {
+			// the b.Fatal can't be used inside RunParallel
+			if _, _, err := c.Get(keys[i%len(keys)], 0); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+
+}
+
+func BenchmarkCache_parallel(b *testing.B) {
+	for _, shards := range []int{1, 16} {
+		b.Run(fmt.Sprint(shards), func(b *testing.B) {
+			benchmarkCacheParallel(b, shards)
+		})
+	}
+}
+
+func BenchmarkCache_read(b *testing.B) {
+	for _, policy := range []CachePolicy{LRU, LFU, TwoQ} {
+		b.Run(policy.String(), func(b *testing.B) {
+			benchmarkCacheRead(b, policy)
+		})
+	}
+}
+
+func BenchmarkCache_fill(b *testing.B) {
+	for _, policy := range []CachePolicy{LRU, LFU, TwoQ} {
+		b.Run(policy.String(), func(b *testing.B) {
+			benchmarkCacheFill(b, policy)
+		})
+	}
+}
@@ -6,6 +6,9 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
 
 	"github.com/skycoin/cxo/data"
 	"github.com/skycoin/cxo/node/log"
@@ -23,6 +26,9 @@ const (
 	CacheMaxVolume  int     = 8192 * 1024 // 8M
 	CacheRegistries int     = 5           // 5
 	CacheCleaning   float64 = 0.8         // down to 80%
+	CacheShards     int     = 16          // 16 shards
+	MaxCacheShards  int     = 256         // max shards
+	CacheWarmUp     int     = 0           // don't keep hot keys
 
 	// CacheMaxItemSize is 1M (CacheMaxVolume*(1.0-CacheCleaning) / 2)
 	CacheMaxItemSize int = 1024 * 1024
@@ -33,7 +39,8 @@ const (
 
 	// filling
 
-	MaxFillingParallel int = 10 // ten parallel subtrees
+	MaxFillingParallel int           = 10             // ten parallel subtrees
+	FillingTTL         time.Duration = 24 * time.Hour // keep a day
 
 	// DB related constants
 	CXDS  string = "cxds.db" // default CXDS file name
@@ -113,7 +120,11 @@ type Config struct {
 	// the caceh. See also CacheCleaning field
 	CacheMaxVolume int
 	// CachePolicy is policy of the Cache. By default it's LRU,
-	// but it's possible to choose LFU if you want
+	// but it's possible to choose LFU or 2Q if you want. The
+	// 2Q keeps new items apart from frequently used, thus a
+	// one-pass scan (e.g. filling of a big Refs) doesn't
+	// evict the working set. Unlike the LFU, the 2Q removes
+	// items that was popular long ago
 	CachePolicy CachePolicy
 	// CacheRegistries is number of Registries the Cache
 	// will keep unpacked. A Registry is fast for access
@@ -140,6 +151,29 @@ type Config struct {
 	// CacheMaxItemSize can't be bigger then
 	// CacheMaxVolume*(1.0 - CacheCleaning)
 	CacheMaxItemSize int
+	// CacheShards is number of parts of the Cache. Every
+	// part (shard) has its own lock. Thus, many goroutines
+	// (e.g. fillers and connections) can access the Cache
+	// with less contention. The CacheMaxAmount and the
+	// CacheMaxVolume are limits of entire Cache, not of a
+	// shard, and cleaning removes items of all shards
+	// using the CachePolicy. Set it to 1 to use one lock
+	CacheShards int
+	// CacheWarmUp is max number of hot objects the Cache
+	// keeps across restarts. The Container saves keys of
+	// the most used objects, and references of unpacked
+	// Registries, on Close. And NewContainer prefetches
+	// them to the Cache in background. Thus, first minutes
+	// after restart are not so slow. Set it to zero to
+	// turn the warm-up off. The warm-up is off by default
+	CacheWarmUp int
+	// FeedPriorities is cache priorities of feeds. Cleaning
+	// the Cache removes objects of feeds with lower priority
+	// first. Default priority is zero. Thus, a big archive
+	// feed that is rarely read can't push out objects of
+	// small feeds that must be fast. See also method
+	// SetFeedPriority of the Container
+	FeedPriorities map[cipher.PubKey]int
 
 	// limits
 
@@ -167,6 +201,13 @@ type Config struct {
 	// goroutines. The MaxFillingParallel should be closer
 	// to number of connections that used to fill a Root.
 	MaxFillingParallel int
+	// FillingTTL is time to keep interrupted filling (see
+	// Filling) since its last break. Objects of expired
+	// Filling are not protected against cleaning, and the
+	// Filling is not resumed. Expired Fillings are removed
+	// by DelExpiredFillings. Set it to zero to keep the
+	// Fillings forever
+	FillingTTL time.Duration
 
 	// DB configs
 
@@ -218,9 +259,13 @@ func NewConfig() (conf *Config) {
 	conf.CachePolicy = LRU
 	conf.CacheCleaning = CacheCleaning
 	conf.CacheMaxItemSize = CacheMaxItemSize
+	conf.CacheShards = CacheShards
+	conf.CacheWarmUp = CacheWarmUp
 
 	conf.MaxObjectSize = MaxObjectSize
 
+	conf.FillingTTL = FillingTTL
+
 	// data dir
 	conf.DataDir = DataDir()
 
@@ -242,6 +287,21 @@ func (c *Config) FromFlags() {
 		"db-path",
 		c.DBPath,
 		"path to database")
+	flag.Var(&c.CachePolicy,
+		"cache-policy",
+		"cache policy: LRU, LFU or 2Q")
+	flag.IntVar(&c.CacheShards,
+		"cache-shards",
+		c.CacheShards,
+		"number of independent parts of cache")
+	flag.IntVar(&c.CacheWarmUp,
+		"cache-warm-up",
+		c.CacheWarmUp,
+		"number of hot objects to prefetch after restart, 0 is off")
+	flag.DurationVar(&c.FillingTTL,
+		"filling-ttl",
+		c.FillingTTL,
+		"time to keep interrupted filling, 0 is forever")
 }
 
 // Validate the Config
@@ -265,9 +325,11 @@ func (c *Config) Validate() error {
 			c.CacheMaxVolume)
 	}
 
-	if c.CachePolicy != LRU && c.CachePolicy != LFU {
+	switch c.CachePolicy {
+	case LRU, LFU, TwoQ:
+	default:
 		return fmt.Errorf(
-			"skyobject.Config.CachePolicy is unknown: %d (choose LRU or LFU)",
+			"skyobject.Config.CachePolicy is unknown: %d (choose LRU, LFU or 2Q)",
 			c.CachePolicy)
 	}
 
@@ -292,10 +354,26 @@ func (c *Config) Validate() error {
 			c.CacheMaxItemSize, cacheMaxItemSize)
 	}
 
+	if c.CacheShards < 1 || c.CacheShards > MaxCacheShards {
+		return fmt.Errorf(
+			"skyobject.Config.CacheShards out of range: %d (1 - %d)",
+			c.CacheShards, MaxCacheShards)
+	}
+
+	if c.CacheWarmUp < 0 {
+		return fmt.Errorf("skyobject.Config.CacheWarmUp is negative: %d",
+			c.CacheWarmUp)
+	}
+
 	if c.MaxObjectSize < 1024 {
 		return fmt.Errorf("skyobject.Config.MAxObjectSize is too small: %d",
 			c.MaxObjectSize)
 	}
 
+	if c.FillingTTL < 0 {
+		return fmt.Errorf("skyobject.Config.FillingTTL is negative: %v",
+			c.FillingTTL)
+	}
+
 	return nil
 }
@@ -1,8 +1,10 @@
 package skyobject
 
 import (
+	"context"
 	"log"
 	"path/filepath"
+	"sync"
 
 	"github.com/skycoin/skycoin/src/cipher"
 
@@ -23,8 +25,15 @@ type Container struct {
 
 	db *data.DB // database
 
+	fi fieldIndexes // secondary indexes
+
 	conf *Config // configurations
 
+	subscriptions // events
+
+	rmx     sync.Mutex                      // lock readers
+	readers map[cipher.PubKey]cipher.SecKey // keys of readers
+
 	// human readable (used by node for debugging)
 	cxPath, idxPath string
 }
@@ -75,10 +84,24 @@ func NewContainer(conf *Config) (c *Container, err error) {
 	// initialize cache
 	c.initCache()
 
+	// changes of rc of saved Roots interrupted by a failure
+	if err = c.replayRCJournals(); err != nil {
+		return
+	}
+
 	if err = c.Index.load(c); err != nil {
 		return
 	}
 
+	if err = c.loadFieldIndexes(); err != nil {
+		return
+	}
+
+	// prefetch hot objects in background
+	if err = c.Cache.warmUp(); err != nil {
+		return
+	}
+
 	return // done
 }
 
@@ -199,7 +222,7 @@ func (c *Container) initRoot(cr *cxdsRCs, rootHash cipher.SHA256) (err error) {
 
 	var pack = c.getPack(reg)
 
-	err = r.Walk(pack,
+	err = c.walkRefs(pack, r,
 		func(
 			hash cipher.SHA256,
 			_ int,
@@ -256,14 +279,30 @@ func (c *Container) DB() (db *data.DB) {
 // hash of the Root and Registry (depending on the
 // deepper reply of the WalkFunc).
 //
-// The Walk obtains objects of the Root from DB
+// The Walk obtains objects of the Root from DB. For
+// encrypted Root, the Walk walks envelope of the Root
+// and links of encrypted objects (without decryption).
+//
+// By default, the Walk walks depth-first in one goroutine.
+// Use WalkInParallel option to walk using many goroutines
+// (see WalkParallel)
 func (c *Container) Walk(
-	r *registry.Root,
-	walkFunc registry.WalkFunc,
+	r *registry.Root, //           : the Root
+	walkFunc registry.WalkFunc, // : the function
+	opts ...WalkOption, //         : options
 ) (
-	err error,
+	err error, //                  : an error
 ) {
 
+	var wo walkOptions
+	for _, opt := range opts {
+		opt(&wo)
+	}
+
+	if wo.parallel == true {
+		return c.WalkParallel(wo.ctx, r, wo.maxParall, walkFunc)
+	}
+
 	var reg *registry.Registry
 	if reg, err = c.Registry(r.Reg); err != nil {
 		return
@@ -288,17 +327,128 @@ func (c *Container) walkRoot(
 		}
 	}()
 
+	if err = walkRootHead(r, walkFunc); err != nil {
+		return
+	}
+
+	return c.walkRefs(pack, r, walkFunc)
+}
+
+// walkRootHead calls given walkFunc for hash of the Root
+// and hash of its Registry ignoring the deepper reply
+func walkRootHead(r *registry.Root, walkFunc registry.WalkFunc) (err error) {
+
 	// hash of the Root is first (ignore deepper)
 	if _, err = walkFunc(r.Hash, 0); err != nil {
 		return
 	}
 
 	// registry is second (ignore deepper)
-	if _, err = walkFunc(cipher.SHA256(r.Reg), 0); err != nil {
+	_, err = walkFunc(cipher.SHA256(r.Reg), 0)
+	return
+}
+
+// walkRefs walks through objects of given Root; if the
+// Root is encrypted, then it walks envelope of the Root
+// and links of the encrypted objects (the pack must
+// return encrypted values)
+func (c *Container) walkRefs(
+	pack registry.Pack,
+	r *registry.Root,
+	walkFunc registry.WalkFunc,
+) (
+	err error,
+) {
+
+	if r.IsEncrypted() == false {
+		return r.Walk(pack, walkFunc)
+	}
+
+	// envelope (ignore deepper)
+	if _, err = walkFunc(r.Envelope, 0); err != nil {
 		return
 	}
 
-	return r.Walk(pack, walkFunc)
+	for _, dr := range r.Refs {
+		if err = walkSealed(pack.Get, dr.Hash, walkFunc); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// A WalkOption changes behaviour of the Walk
+type WalkOption func(wo *walkOptions)
+
+// options of the Walk
+type walkOptions struct {
+	parallel  bool
+	ctx       context.Context
+	maxParall int
+}
+
+// WalkInParallel is WalkOption that makes the Walk walking
+// using many goroutines, like the WalkParallel. The maxParall
+// limits number of goroutines (zero means number of CPUs)
+// and the ctx can be used to cancel the walking
+func WalkInParallel(ctx context.Context, maxParall int) WalkOption {
+	return func(wo *walkOptions) {
+		wo.parallel, wo.ctx, wo.maxParall = true, ctx, maxParall
+	}
+}
+
+// WalkParallel is the same as the Walk but it walks
+// using many goroutines and can be cancelled using
+// given context. The maxParall limits number of the
+// goroutines (zero means number of CPUs). Given walkFunc
+// must be safe for concurrent use. Hashes of the Root
+// and Registry are first and the rest are in undefined
+// order. If the walking cancelled, then the
+// WalkParallel returns error of the context. See
+// (*registry.Root).WalkParallel for details. An
+// encrypted Root is walked in one goroutine
+//
+// The Walk with WalkInParallel option is the same.
+// The parallel walking is not default, because the
+// Walk guarantees depth-first order and calls the
+// walkFunc from one goroutine by default. Existing
+// callers of the Walk rely on it and use walkFunc
+// that is not safe for concurrent use
+func (c *Container) WalkParallel(
+	ctx context.Context, //          : the context
+	r *registry.Root, //             : the Root
+	maxParall int, //                : max goroutines
+	walkFunc registry.WalkFunc, //   : the function
+) (
+	err error, //                    : an error
+) {
+
+	var reg *registry.Registry
+	if reg, err = c.Registry(r.Reg); err != nil {
+		return
+	}
+
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	if err = walkRootHead(r, walkFunc); err != nil {
+		if err == registry.ErrStopIteration {
+			err = nil
+		}
+		return
+	}
+
+	if r.IsEncrypted() == true {
+		// walk links of encrypted objects (single goroutine)
+		if err = c.walkRefs(c.getPack(reg), r, walkFunc); err == registry.ErrStopIteration {
+			err = nil
+		}
+		return
+	}
+
+	return r.WalkParallel(ctx, c.getPack(reg), maxParall, walkFunc)
 }
 
 // Config returns configs of the Container.
@@ -318,8 +468,12 @@ func (c *Container) rootByHash(
 		return
 	}
 
-	r, err = registry.DecodeRoot(val)
+	if r, err = registry.DecodeRoot(val); err != nil {
+		return
+	}
+
 	r.Hash = hash
+	r.IsPartial, err = c.isPartial(hash)
 	return
 }
 
@@ -340,7 +494,7 @@ func (c *Container) RootByHash(
 	// signature
 
 	var dr *data.Root
-	if dr, err = c.dataRoot(r.Pub, r.Nonce, r.Seq); err != nil {
+	if dr, err = c.dataRootLock(r.Pub, r.Nonce, r.Seq); err != nil {
 		return
 	}
 
@@ -354,6 +508,8 @@ func (c *Container) RootByHash(
 // with user-provided DB.
 func (c *Container) Close() (err error) {
 
+	c.closeSubscriptions()
+
 	// the Cache.Close closes CXDS
 	if err = c.Cache.Close(); err == nil {
 		err = c.db.Close()
@@ -0,0 +1,109 @@
+package skyobject
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+func TestContainer_WalkParallel(t *testing.T) {
+
+	var (
+		c      = getTestContainer()
+		pk, sk = cipher.GenerateKeyPair()
+	)
+
+	defer c.Close()
+
+	assertNil(t, c.AddFeed(pk))
+
+	var up, err = c.Unpack(sk, testRegistry)
+	assertNil(t, err)
+
+	var (
+		r    = new(registry.Root)
+		feed = Feed{Head: "feed"}
+	)
+
+	r.Pub = pk
+	r.Nonce = 1
+
+	for i := 0; i < 20; i++ {
+		assertNil(t, feed.Posts.AppendValues(up, Post{Head: "post", Body: "body"},
+			Post{Head: "post", Body: string(rune('a' + i))}))
+	}
+
+	r.Refs = []registry.Dynamic{
+		createDynamic(up, testRegistry, "test.User", &User{"Alice", 21}),
+		createDynamic(up, testRegistry, "test.Feed", &feed),
+	}
+
+	assertNil(t, c.Save(up, r))
+
+	var want = make(map[cipher.SHA256]int)
+
+	assertNil(t, c.Walk(r, func(hash cipher.SHA256, _ int) (bool, error) {
+		want[hash]++
+		return true, nil
+	}))
+
+	var (
+		mx  sync.Mutex
+		got = make(map[cipher.SHA256]int)
+	)
+
+	err = c.WalkParallel(context.Background(), r, 4,
+		func(hash cipher.SHA256, _ int) (bool, error) {
+			mx.Lock()
+			defer mx.Unlock()
+			got[hash]++
+			return true, nil
+		})
+
+	assertNil(t, err)
+
+	if len(got) != len(want) {
+		t.Fatalf("wrong number of hashes %d, want %d", len(got), len(want))
+	}
+
+	for hash, n := range want {
+		if got[hash] != n {
+			t.Error("wrong times of", hash.Hex()[:7])
+		}
+	}
+
+	// the same using option of the Walk
+
+	var opt = make(map[cipher.SHA256]int)
+
+	err = c.Walk(r, func(hash cipher.SHA256, _ int) (bool, error) {
+		mx.Lock()
+		defer mx.Unlock()
+		opt[hash]++
+		return true, nil
+	}, WalkInParallel(context.Background(), 0))
+
+	assertNil(t, err)
+
+	if len(opt) != len(want) {
+		t.Fatalf("wrong number of hashes %d, want %d", len(opt), len(want))
+	}
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+
+	err = c.WalkParallel(ctx, r, 4,
+		func(cipher.SHA256, int) (bool, error) {
+			t.Error("called")
+			return true, nil
+		})
+
+	if err != context.Canceled {
+		t.Error("wrong error:", err)
+	}
+
+}
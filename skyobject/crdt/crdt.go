@@ -0,0 +1,80 @@
+// Package crdt implements convergent replicated data
+// types on top of the skyobject/registry. Every type is
+// a regular CXO object that can be saved in a Root. Two
+// replicas (heads or feeds of different writers) of the
+// same object can be merged using the Merge method, and
+// the result doesn't depend on order of merging. E.g. if
+// replicas exchange changes, then they converge into the
+// same content, and thus the same encoded object.
+//
+// The types are
+//
+//     GSet  - grow-only set
+//     ORSet - observed-remove set
+//     LWW   - last-writer-wins register
+//     RGA   - replicated growable array (sequence)
+//
+// Elements of the types are Dynamic references to any
+// registered objects. Use the Register to register the
+// types in a Registry
+//
+//     var reg = registry.NewRegistry(func(r *registry.Reg) {
+//         crdt.Register(r)
+//         r.Register("app.Post", Post{})
+//         r.Register("app.Feed", Feed{})
+//     })
+//
+// A replica is identified by public key. It's enough to
+// use public key of feed, but if many writers share the
+// same feed, then every writer should use its own key.
+// Values of the types are not thread safe
+package crdt
+
+import (
+	"bytes"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+// Register types of the package in given Reg
+func Register(r *registry.Reg) {
+	r.Register("crdt.Tag", Tag{})
+	r.Register("crdt.GSet", GSet{})
+	r.Register("crdt.ORElement", ORElement{})
+	r.Register("crdt.ORSet", ORSet{})
+	r.Register("crdt.LWW", LWW{})
+	r.Register("crdt.RGAElement", RGAElement{})
+	r.Register("crdt.RGA", RGA{})
+}
+
+// A Tag is unique identifier of an operation. The
+// Clock is Lamport clock, e.g. it's greater than
+// clock of all Tags known by a replica when the
+// replica creates new Tag
+type Tag struct {
+	Replica cipher.PubKey // replica created the Tag
+	Clock   uint64        // Lamport clock
+}
+
+// IsBlank returns true if the Tag is blank
+func (t Tag) IsBlank() bool {
+	return t == (Tag{})
+}
+
+// Less compares Tags by Clock and then by Replica
+func (t Tag) Less(o Tag) bool {
+	if t.Clock != o.Clock {
+		return t.Clock < o.Clock
+	}
+	return bytes.Compare(t.Replica[:], o.Replica[:]) < 0
+}
+
+// order of Dynamic references
+func lessDynamic(a, b registry.Dynamic) bool {
+	if c := bytes.Compare(a.Hash[:], b.Hash[:]); c != 0 {
+		return c < 0
+	}
+	return bytes.Compare(a.Schema[:], b.Schema[:]) < 0
+}
@@ -0,0 +1,454 @@
+package crdt
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+type testPack struct {
+	reg    *registry.Registry
+	flags  registry.Flags
+	degree registry.Degree
+	vals   map[cipher.SHA256][]byte
+}
+
+func newTestPack() (tp *testPack) {
+	tp = new(testPack)
+	tp.reg = registry.NewRegistry(Register)
+	tp.degree = 3
+	tp.vals = make(map[cipher.SHA256][]byte)
+	return
+}
+
+func (t *testPack) Registry() *registry.Registry {
+	return t.reg
+}
+
+func (t *testPack) Get(key cipher.SHA256) (val []byte, err error) {
+	var ok bool
+	if val, ok = t.vals[key]; !ok {
+		err = registry.ErrNotFound
+	}
+	return
+}
+
+func (t *testPack) Set(key cipher.SHA256, val []byte) (_ error) {
+	t.vals[key] = val
+	return
+}
+
+func (t *testPack) Add(val []byte) (key cipher.SHA256, err error) {
+	key = cipher.SumSHA256(val)
+	err = t.Set(key, val)
+	return
+}
+
+func (t *testPack) Degree() registry.Degree {
+	return t.degree
+}
+
+func (t *testPack) SetDegree(degree registry.Degree) (err error) {
+	if err = degree.Validate(); err != nil {
+		return
+	}
+	t.degree = degree
+	return
+}
+
+func (t *testPack) Flags() registry.Flags {
+	return t.flags
+}
+
+func (t *testPack) AddFlags(flags registry.Flags) {
+	t.flags |= flags
+}
+
+func (t *testPack) ClearFlags(flags registry.Flags) {
+	t.flags &^= flags
+}
+
+// small set of values to have collisions
+func testValue(rnd *rand.Rand) (dr registry.Dynamic) {
+	dr.Hash = cipher.SumSHA256([]byte{byte(rnd.Intn(8))})
+	dr.Schema = registry.SchemaRef{1}
+	return
+}
+
+func testReplicas(n int) (pks []cipher.PubKey) {
+	for i := 0; i < n; i++ {
+		var pk, _ = cipher.GenerateKeyPair()
+		pks = append(pks, pk)
+	}
+	return
+}
+
+func testEqual(t *testing.T, a, b interface{}) {
+	t.Helper()
+	if bytes.Equal(encoder.Serialize(a), encoder.Serialize(b)) == false {
+		t.Errorf("not converged:\n%v\n%v", a, b)
+	}
+}
+
+func TestRegister(t *testing.T) {
+
+	var reg = registry.NewRegistry(Register)
+
+	for _, name := range []string{
+		"crdt.GSet",
+		"crdt.ORSet",
+		"crdt.LWW",
+		"crdt.RGA",
+	} {
+		if _, err := reg.SchemaByName(name); err != nil {
+			t.Error(err)
+		}
+	}
+
+}
+
+// the f performs random operations on given replica
+// of a type and the merge merges two replicas; all
+// replicas should converge after exchanging of
+// changes in random order
+func testConvergence(
+	t *testing.T,
+	seed int64,
+	replicas int,
+	newf func() interface{},
+	opf func(rnd *rand.Rand, replica int, x interface{}),
+	merge func(x, y interface{}),
+) bool {
+
+	t.Helper()
+
+	var (
+		rnd = rand.New(rand.NewSource(seed))
+		xs  = make([]interface{}, replicas)
+	)
+
+	for i := range xs {
+		xs[i] = newf()
+	}
+
+	// rounds of changes and partial synchronizations
+	for round := 0; round < 5; round++ {
+
+		for k := rnd.Intn(10); k >= 0; k-- {
+			var i = rnd.Intn(replicas)
+			opf(rnd, i, xs[i])
+		}
+
+		var i, j = rnd.Intn(replicas), rnd.Intn(replicas)
+		merge(xs[i], xs[j])
+
+	}
+
+	// full synchronization in random order
+	var final = newf()
+	for _, i := range rnd.Perm(replicas) {
+		merge(final, xs[i])
+	}
+
+	for _, i := range rnd.Perm(replicas) {
+		merge(xs[i], final)
+	}
+
+	var ok = true
+	for _, x := range xs {
+		if bytes.Equal(encoder.Serialize(x),
+			encoder.Serialize(final)) == false {
+
+			ok = false
+		}
+	}
+
+	return ok
+}
+
+func TestGSet_convergence(t *testing.T) {
+
+	var check = func(seed int64) bool {
+		return testConvergence(t, seed, 3,
+			func() interface{} { return new(GSet) },
+			func(rnd *rand.Rand, _ int, x interface{}) {
+				x.(*GSet).Add(testValue(rnd))
+			},
+			func(x, y interface{}) {
+				x.(*GSet).Merge(y.(*GSet))
+			})
+	}
+
+	if err := quick.Check(check, nil); err != nil {
+		t.Error(err)
+	}
+
+	var a, b GSet
+	a.Add(registry.Dynamic{Hash: cipher.SumSHA256([]byte{1})})
+	a.Add(registry.Dynamic{Hash: cipher.SumSHA256([]byte{1})})
+
+	if a.Len() != 1 {
+		t.Error("duplicate element")
+	}
+
+	b.Merge(&a)
+	b.Merge(&a) // idempotent
+
+	testEqual(t, &a, &b)
+
+}
+
+func TestORSet_convergence(t *testing.T) {
+
+	var pks = testReplicas(3)
+
+	var check = func(seed int64) bool {
+		return testConvergence(t, seed, len(pks),
+			func() interface{} { return new(ORSet) },
+			func(rnd *rand.Rand, i int, x interface{}) {
+				var o = x.(*ORSet)
+				if rnd.Intn(3) == 0 {
+					o.Remove(testValue(rnd))
+				} else {
+					o.Add(pks[i], testValue(rnd))
+				}
+			},
+			func(x, y interface{}) {
+				x.(*ORSet).Merge(y.(*ORSet))
+			})
+	}
+
+	if err := quick.Check(check, nil); err != nil {
+		t.Error(err)
+	}
+
+	t.Run("add wins", func(t *testing.T) {
+
+		var (
+			a, b ORSet
+			val  = registry.Dynamic{Hash: cipher.SumSHA256([]byte{1})}
+		)
+
+		a.Add(pks[0], val)
+		b.Merge(&a)
+
+		// concurrent remove and add
+		a.Remove(val)
+		b.Add(pks[1], val)
+
+		a.Merge(&b)
+		b.Merge(&a)
+
+		if a.Has(val) == false || b.Has(val) == false {
+			t.Error("concurrent add lost")
+		}
+
+		if vals := a.Values(); len(vals) != 1 || vals[0] != val {
+			t.Error("wrong values", vals)
+		}
+
+		testEqual(t, &a, &b)
+
+	})
+
+}
+
+func TestLWW_convergence(t *testing.T) {
+
+	var pks = testReplicas(3)
+
+	var check = func(seed int64) bool {
+		return testConvergence(t, seed, len(pks),
+			func() interface{} { return new(LWW) },
+			func(rnd *rand.Rand, i int, x interface{}) {
+				var tm = time.Unix(0, int64(rnd.Intn(4))) // collisions
+				x.(*LWW).Set(pks[i], testValue(rnd), tm)
+			},
+			func(x, y interface{}) {
+				x.(*LWW).Merge(y.(*LWW))
+			})
+	}
+
+	if err := quick.Check(check, nil); err != nil {
+		t.Error(err)
+	}
+
+	var (
+		l   LWW
+		now = time.Now()
+		v1  = registry.Dynamic{Hash: cipher.SumSHA256([]byte{1})}
+		v2  = registry.Dynamic{Hash: cipher.SumSHA256([]byte{2})}
+	)
+
+	l.Set(pks[0], v1, now)
+	l.Set(pks[0], v2, now.Add(-time.Second)) // clock goes back
+
+	if l.Value != v2 {
+		t.Error("value not set")
+	}
+
+}
+
+func TestRGA(t *testing.T) {
+
+	var (
+		pack = newTestPack()
+		pk   = testReplicas(1)[0]
+		rga  RGA
+	)
+
+	var val = func(b byte) registry.Dynamic {
+		return registry.Dynamic{Hash: cipher.SHA256{b}, Schema: registry.SchemaRef{1}}
+	}
+
+	var check = func(want ...byte) {
+		t.Helper()
+
+		var vals, err = rga.Values(pack)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(vals) != len(want) {
+			t.Fatalf("wrong length %d, want %d", len(vals), len(want))
+		}
+
+		for i, w := range want {
+			if vals[i] != val(w) {
+				t.Errorf("wrong value %d: %d, want %d", i, vals[i].Hash[0], w)
+			}
+		}
+	}
+
+	for i, v := range []byte{1, 3} {
+		if _, err := rga.Insert(pack, pk, i, val(v)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := rga.Insert(pack, pk, 1, val(2)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := rga.Insert(pack, pk, 0, val(0)); err != nil {
+		t.Fatal(err)
+	}
+
+	check(0, 1, 2, 3)
+
+	if err := rga.Delete(pack, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	check(0, 1, 3)
+
+	if err := rga.Delete(pack, 3); err != registry.ErrIndexOutOfRange {
+		t.Error("wrong error:", err)
+	}
+
+	if _, err := rga.Insert(pack, pk, 4, val(4)); err != registry.ErrIndexOutOfRange {
+		t.Error("wrong error:", err)
+	}
+
+	if degree, err := rga.Elements.Degree(pack); err != nil {
+		t.Fatal(err)
+	} else if degree != RGADegree {
+		t.Error("wrong degree", degree)
+	}
+
+}
+
+func TestRGA_convergence(t *testing.T) {
+
+	var (
+		pack = newTestPack()
+		pks  = testReplicas(3)
+	)
+
+	var check = func(seed int64) bool {
+		return testConvergence(t, seed, len(pks),
+			func() interface{} { return new(RGA) },
+			func(rnd *rand.Rand, i int, x interface{}) {
+
+				var (
+					r         = x.(*RGA)
+					vals, err = r.Values(pack)
+				)
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(vals) > 0 && rnd.Intn(3) == 0 {
+					err = r.Delete(pack, rnd.Intn(len(vals)))
+				} else {
+					_, err = r.Insert(pack, pks[i], rnd.Intn(len(vals)+1),
+						testValue(rnd))
+				}
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+			},
+			func(x, y interface{}) {
+				if err := x.(*RGA).Merge(pack, y.(*RGA)); err != nil {
+					t.Fatal(err)
+				}
+			})
+	}
+
+	if err := quick.Check(check, &quick.Config{MaxCount: 50}); err != nil {
+		t.Error(err)
+	}
+
+	t.Run("concurrent inserts", func(t *testing.T) {
+
+		var a, b RGA
+
+		var v = func(b byte) registry.Dynamic {
+			return registry.Dynamic{Hash: cipher.SHA256{b}}
+		}
+
+		a.Insert(pack, pks[0], 0, v(1))
+		b.Merge(pack, &a)
+
+		// both insert after the first, the b is newer
+		a.Insert(pack, pks[0], 1, v(2))
+		a.Insert(pack, pks[0], 2, v(3))
+		b.Insert(pack, pks[1], 1, v(4))
+		b.Insert(pack, pks[1], 2, v(5))
+		b.Insert(pack, pks[1], 3, v(6))
+
+		a.Merge(pack, &b)
+		b.Merge(pack, &a)
+
+		testEqual(t, &a, &b)
+
+		var vals, err = a.Values(pack)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// runs of replicas are not interleaved
+		var got []byte
+		for _, val := range vals {
+			got = append(got, val.Hash[0])
+		}
+
+		if bytes.Equal(got, []byte{1, 4, 5, 6, 2, 3}) == false &&
+			bytes.Equal(got, []byte{1, 2, 3, 4, 5, 6}) == false {
+
+			t.Error("interleaved:", got)
+		}
+
+	})
+
+}
@@ -0,0 +1,78 @@
+package crdt
+
+import (
+	"sort"
+
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+// A GSet is grow-only set. Elements can be added
+// but can't be removed. The Elements are sorted
+// by hash, thus the same set has the same encoded
+// representation regardless order of adding
+type GSet struct {
+	Elements []registry.Dynamic
+}
+
+// search index of given element or index to insert
+func (g *GSet) search(dr registry.Dynamic) (i int, ok bool) {
+	i = sort.Search(len(g.Elements), func(i int) bool {
+		return lessDynamic(g.Elements[i], dr) == false
+	})
+	ok = i < len(g.Elements) && g.Elements[i] == dr
+	return
+}
+
+// Add element to the GSet. It returns false if
+// the element already exists in the set
+func (g *GSet) Add(dr registry.Dynamic) (added bool) {
+
+	var i, ok = g.search(dr)
+
+	if ok == true {
+		return
+	}
+
+	g.Elements = append(g.Elements, registry.Dynamic{})
+	copy(g.Elements[i+1:], g.Elements[i:])
+	g.Elements[i] = dr
+
+	return true
+}
+
+// Has returns true if the GSet contains given element
+func (g *GSet) Has(dr registry.Dynamic) (ok bool) {
+	_, ok = g.search(dr)
+	return
+}
+
+// Len returns number of elements
+func (g *GSet) Len() int {
+	return len(g.Elements)
+}
+
+// Merge another replica of the GSet to this one
+func (g *GSet) Merge(o *GSet) {
+
+	var merged = make([]registry.Dynamic, 0, len(g.Elements)+len(o.Elements))
+
+	var i, j int
+	for i < len(g.Elements) && j < len(o.Elements) {
+		switch a, b := g.Elements[i], o.Elements[j]; {
+		case a == b:
+			merged = append(merged, a)
+			i, j = i+1, j+1
+		case lessDynamic(a, b):
+			merged = append(merged, a)
+			i++
+		default:
+			merged = append(merged, b)
+			j++
+		}
+	}
+
+	merged = append(merged, g.Elements[i:]...)
+	merged = append(merged, o.Elements[j:]...)
+
+	g.Elements = merged
+}
@@ -0,0 +1,58 @@
+package crdt
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+// A LWW is last-writer-wins register. It keeps value
+// with greatest Time. Concurrent changes with the same
+// Time ordered by Replica and then by the Value
+type LWW struct {
+	Value   registry.Dynamic
+	Time    int64         // unix nano
+	Replica cipher.PubKey // last writer
+}
+
+// IsBlank returns true if the LWW has not been set
+func (l *LWW) IsBlank() bool {
+	return l.Time == 0 && l.Value.IsBlank() == true
+}
+
+// is the l older then given
+func (l *LWW) less(o *LWW) bool {
+	if l.Time != o.Time {
+		return l.Time < o.Time
+	}
+	if c := bytes.Compare(l.Replica[:], o.Replica[:]); c != 0 {
+		return c < 0
+	}
+	return lessDynamic(l.Value, o.Value)
+}
+
+// Set value of the register by given replica. If current
+// value of the LWW is not older then given time (e.g. clocks
+// of replicas are not synchronized), then the time will be
+// increased to be newer. Thus the Set always changes the
+// value locally
+func (l *LWW) Set(replica cipher.PubKey, dr registry.Dynamic, t time.Time) {
+
+	var tn = t.UnixNano()
+
+	if tn <= l.Time {
+		tn = l.Time + 1
+	}
+
+	l.Value, l.Time, l.Replica = dr, tn, replica
+}
+
+// Merge another replica of the LWW to this one
+func (l *LWW) Merge(o *LWW) {
+	if l.less(o) == true {
+		*l = *o
+	}
+}
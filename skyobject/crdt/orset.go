@@ -0,0 +1,162 @@
+package crdt
+
+import (
+	"sort"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+// An ORElement is element of ORSet
+// with unique Tag of adding
+type ORElement struct {
+	Value registry.Dynamic
+	Tag   Tag
+}
+
+// An ORSet is observed-remove set. The Remove removes
+// only elements observed by a replica. E.g. if the same
+// element added by another replica concurrently, then
+// after merging the element will be in the set. Tags of
+// removed elements are kept forever (tombstones). The
+// Elements and the Removed are sorted by Tag
+type ORSet struct {
+	Elements []ORElement
+	Removed  []Tag
+}
+
+// next Lamport clock of the ORSet
+func (o *ORSet) clock() (clock uint64) {
+	if ln := len(o.Elements); ln > 0 {
+		clock = o.Elements[ln-1].Tag.Clock
+	}
+	if ln := len(o.Removed); ln > 0 && o.Removed[ln-1].Clock > clock {
+		clock = o.Removed[ln-1].Clock
+	}
+	return clock + 1
+}
+
+// Add element to the ORSet by given replica.
+// The same element can be added many times
+func (o *ORSet) Add(replica cipher.PubKey, dr registry.Dynamic) (tag Tag) {
+	tag = Tag{Replica: replica, Clock: o.clock()}
+	// the tag is greatest
+	o.Elements = append(o.Elements, ORElement{Value: dr, Tag: tag})
+	return
+}
+
+// Remove all observed copies of given element.
+// It returns false if the element is not found
+func (o *ORSet) Remove(dr registry.Dynamic) (removed bool) {
+
+	var (
+		kept = make([]ORElement, 0, len(o.Elements))
+		rm   []Tag
+	)
+
+	for _, el := range o.Elements {
+		if el.Value == dr {
+			rm = append(rm, el.Tag)
+			continue
+		}
+		kept = append(kept, el)
+	}
+
+	if len(rm) == 0 {
+		return
+	}
+
+	o.Elements = kept
+	o.Removed = mergeTags(o.Removed, rm) // the rm is sorted
+
+	return true
+}
+
+// Has returns true if the ORSet contains given element
+func (o *ORSet) Has(dr registry.Dynamic) bool {
+	for _, el := range o.Elements {
+		if el.Value == dr {
+			return true
+		}
+	}
+	return false
+}
+
+// Values returns sorted unique elements of the ORSet
+func (o *ORSet) Values() (vals []registry.Dynamic) {
+
+	var set GSet
+	for _, el := range o.Elements {
+		set.Add(el.Value)
+	}
+
+	return set.Elements
+}
+
+// Merge another replica of the ORSet to this one
+func (o *ORSet) Merge(x *ORSet) {
+
+	o.Removed = mergeTags(o.Removed, x.Removed)
+
+	var merged = make([]ORElement, 0, len(o.Elements)+len(x.Elements))
+
+	var add = func(el ORElement) {
+		var i = sort.Search(len(o.Removed), func(i int) bool {
+			return o.Removed[i].Less(el.Tag) == false
+		})
+		if i < len(o.Removed) && o.Removed[i] == el.Tag {
+			return // removed
+		}
+		merged = append(merged, el)
+	}
+
+	var i, j int
+	for i < len(o.Elements) && j < len(x.Elements) {
+		switch a, b := o.Elements[i], x.Elements[j]; {
+		case a.Tag == b.Tag:
+			add(a)
+			i, j = i+1, j+1
+		case a.Tag.Less(b.Tag):
+			add(a)
+			i++
+		default:
+			add(b)
+			j++
+		}
+	}
+
+	for ; i < len(o.Elements); i++ {
+		add(o.Elements[i])
+	}
+
+	for ; j < len(x.Elements); j++ {
+		add(x.Elements[j])
+	}
+
+	o.Elements = merged
+}
+
+// union of sorted lists of Tags
+func mergeTags(a, b []Tag) (merged []Tag) {
+
+	merged = make([]Tag, 0, len(a)+len(b))
+
+	var i, j int
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			merged = append(merged, a[i])
+			i, j = i+1, j+1
+		case a[i].Less(b[j]):
+			merged = append(merged, a[i])
+			i++
+		default:
+			merged = append(merged, b[j])
+			j++
+		}
+	}
+
+	merged = append(merged, a[i:]...)
+	return append(merged, b[j:]...)
+}
@@ -0,0 +1,232 @@
+package crdt
+
+import (
+	"sort"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+// RGADegree is degree of Refs of RGA
+const RGADegree registry.Degree = 16
+
+// An RGAElement is element of RGA. Removed
+// elements are kept with Deleted flag
+type RGAElement struct {
+	ID      Tag              // unique ID of the element
+	Parent  Tag              // inserted after, blank is head
+	Value   registry.Dynamic // the value
+	Deleted bool             // removed
+}
+
+// An RGA is replicated growable array, e.g. sequence of
+// elements. Every element is inserted after another one
+// (its parent). Concurrent insertions after the same parent
+// ordered by their IDs, newer first. The Elements are sorted
+// by ID, and order of values is calculated from parents. Every
+// change rebuilds the Elements, thus the RGA fits short lists
+// (comments, playlists, etc) better
+type RGA struct {
+	Elements registry.Refs `skyobject:"schema=crdt.RGAElement,degree=16"`
+}
+
+// elements of the RGA sorted by ID
+func (r *RGA) elements(pack registry.Pack) (els []*RGAElement, err error) {
+
+	err = r.Elements.Ascend(pack, func(_ int, hash cipher.SHA256) (err error) {
+
+		var val []byte
+		if val, err = pack.Get(hash); err != nil {
+			return
+		}
+
+		var el = new(RGAElement)
+		if _, err = encoder.DeserializeRaw(val, el); err != nil {
+			return
+		}
+
+		els = append(els, el)
+		return
+	})
+
+	return
+}
+
+// store given elements
+func (r *RGA) store(pack registry.Pack, els []*RGAElement) (err error) {
+
+	sort.Slice(els, func(i, j int) bool {
+		return els[i].ID.Less(els[j].ID)
+	})
+
+	var vals = make([]interface{}, 0, len(els))
+	for _, el := range els {
+		vals = append(vals, el)
+	}
+
+	r.Elements.Clear()
+
+	if err = r.Elements.SetDegree(pack, RGADegree); err != nil {
+		return
+	}
+
+	return r.Elements.AppendValues(pack, vals...)
+}
+
+// rgaOrder returns elements in order of the sequence
+// including deleted; the els must be sorted by ID
+func rgaOrder(els []*RGAElement) (ord []*RGAElement) {
+
+	var children = make(map[Tag][]*RGAElement)
+
+	// from newest to oldest
+	for i := len(els) - 1; i >= 0; i-- {
+		var el = els[i]
+		children[el.Parent] = append(children[el.Parent], el)
+	}
+
+	ord = make([]*RGAElement, 0, len(els))
+
+	var visit func(parent Tag)
+	visit = func(parent Tag) {
+		for _, el := range children[parent] {
+			ord = append(ord, el)
+			visit(el.ID)
+		}
+	}
+
+	visit(Tag{})
+	return
+}
+
+// visible elements in order
+func (r *RGA) visible(
+	pack registry.Pack,
+) (
+	els []*RGAElement, //  : all elements sorted by ID
+	vis []*RGAElement, //  : visible elements in order
+	err error, //          : an error
+) {
+
+	if els, err = r.elements(pack); err != nil {
+		return
+	}
+
+	for _, el := range rgaOrder(els) {
+		if el.Deleted == false {
+			vis = append(vis, el)
+		}
+	}
+
+	return
+}
+
+// Values returns values of the RGA in order
+func (r *RGA) Values(pack registry.Pack) (vals []registry.Dynamic, err error) {
+
+	var vis []*RGAElement
+	if _, vis, err = r.visible(pack); err != nil {
+		return
+	}
+
+	for _, el := range vis {
+		vals = append(vals, el.Value)
+	}
+
+	return
+}
+
+// Insert value by given replica to given position. The
+// position is index in list of values (see Values) and
+// it can be equal to length of the list to append value
+func (r *RGA) Insert(
+	pack registry.Pack, //        : pack
+	replica cipher.PubKey, //     : the replica
+	i int, //                     : position
+	dr registry.Dynamic, //       : value to insert
+) (
+	id Tag, //                    : ID of the element
+	err error, //                 : an error
+) {
+
+	var els, vis []*RGAElement
+	if els, vis, err = r.visible(pack); err != nil {
+		return
+	}
+
+	if i < 0 || i > len(vis) {
+		err = registry.ErrIndexOutOfRange
+		return
+	}
+
+	var el = &RGAElement{Value: dr}
+
+	if i > 0 {
+		el.Parent = vis[i-1].ID
+	}
+
+	el.ID.Replica = replica
+	if ln := len(els); ln > 0 {
+		el.ID.Clock = els[ln-1].ID.Clock // greatest
+	}
+	el.ID.Clock++
+
+	if err = r.store(pack, append(els, el)); err != nil {
+		return
+	}
+
+	return el.ID, nil
+}
+
+// Delete value by given index. The index is
+// index in list of values (see Values)
+func (r *RGA) Delete(pack registry.Pack, i int) (err error) {
+
+	var els, vis []*RGAElement
+	if els, vis, err = r.visible(pack); err != nil {
+		return
+	}
+
+	if i < 0 || i >= len(vis) {
+		return registry.ErrIndexOutOfRange
+	}
+
+	vis[i].Deleted = true
+	return r.store(pack, els)
+}
+
+// Merge another replica of the RGA to this one
+func (r *RGA) Merge(pack registry.Pack, o *RGA) (err error) {
+
+	if r.Elements.Hash == o.Elements.Hash {
+		return // the same
+	}
+
+	var els, oels []*RGAElement
+
+	if els, err = r.elements(pack); err != nil {
+		return
+	}
+
+	if oels, err = o.elements(pack); err != nil {
+		return
+	}
+
+	var ids = make(map[Tag]*RGAElement, len(els))
+
+	for _, el := range els {
+		ids[el.ID] = el
+	}
+
+	for _, el := range oels {
+		if ex, ok := ids[el.ID]; ok == true {
+			ex.Deleted = ex.Deleted || el.Deleted
+			continue
+		}
+		els = append(els, el)
+	}
+
+	return r.store(pack, els)
+}
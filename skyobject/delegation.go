@@ -0,0 +1,304 @@
+package skyobject
+
+import (
+	"errors"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+
+	"github.com/skycoin/cxo/data"
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+// name of IdxDB bucket with known revocations
+var revocationsBucket = []byte("skyobject.revocations")
+
+// UnpackDelegate creates Unpack that signs Roots using
+// given secret key of a delegate. The certs is chain of
+// certificates from a feed to the delegate (see
+// registry.Certificate). Roots saved using the Unpack
+// carry the chain. Feed of the Roots must be issuer of
+// first certificate. The UnpackDelegate returns error
+// if the chain is not valid or if one of certificates
+// is revoked (revocations known by the Container)
+func (c *Container) UnpackDelegate(
+	sk cipher.SecKey, //                   : delegate
+	reg *registry.Registry, //             : registry to use
+	certs ...registry.Certificate, //      : chain
+) (
+	up *Unpack, //                         : the Unpack
+	err error, //                          : an error
+) {
+
+	if len(certs) == 0 {
+		return nil, errors.New("missing certificates")
+	}
+
+	var s Signer
+	if s, err = NewSecKeySigner(sk); err != nil {
+		return
+	}
+
+	return c.UnpackSigner(s, reg, certs...)
+}
+
+// check chain of certificates of given delegate
+func (c *Container) checkCertificates(
+	pk cipher.PubKey, //                 : delegate
+	certs []registry.Certificate, //     : chain
+) (
+	err error, //                        : an error
+) {
+
+	var r = new(registry.Root)
+
+	r.Pub = certs[0].Issuer
+	r.Delegation = &registry.Delegation{Certs: certs}
+
+	if err = r.VerifyDelegation(); err != nil {
+		return
+	}
+
+	if r.Signer() != pk {
+		return errors.New("the key is not the last delegate of the chain")
+	}
+
+	for _, cert := range certs {
+		var rev *registry.Revocation
+		if rev, err = c.revocation(cert.Issuer, cert.Delegate); err != nil {
+			return
+		}
+		if rev != nil {
+			return ErrRevoked
+		}
+	}
+
+	return
+}
+
+// Revoke adds given Revocations to the Root that will be
+// saved using the Unpack. Thus, the Revocations will be
+// sent to peers with the Root. The Revocations stored
+// in the Container after the Root saved
+func (u *Unpack) Revoke(revs ...registry.Revocation) (err error) {
+
+	for i := range revs {
+		if err = revs[i].Verify(); err != nil {
+			return
+		}
+	}
+
+	u.revs = append(u.revs, revs...)
+	return
+}
+
+// delegation of a Root saved using the Unpack, or nil
+func (u *Unpack) delegation() *registry.Delegation {
+
+	if len(u.certs) == 0 && len(u.revs) == 0 {
+		return nil
+	}
+
+	return &registry.Delegation{
+		Certs:       u.certs,
+		Revocations: u.revs,
+	}
+}
+
+// revocation key: issuer + delegate
+func revocationKey(issuer, delegate cipher.PubKey) []byte {
+	return append(append([]byte{}, issuer[:]...), delegate[:]...)
+}
+
+// stored revocation or nil
+func (c *Container) revocation(
+	issuer cipher.PubKey,
+	delegate cipher.PubKey,
+) (
+	rev *registry.Revocation,
+	err error,
+) {
+
+	err = c.db.IdxDB().BucketsView(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(revocationsBucket); err != nil {
+			return
+		}
+
+		var val []byte
+		switch val, err = bk.Get(revocationKey(issuer, delegate)); err {
+		case nil:
+		case data.ErrNotFound:
+			return nil
+		default:
+			return
+		}
+
+		rev = new(registry.Revocation)
+		_, err = encoder.DeserializeRaw(val, rev)
+		return
+
+	})
+
+	return
+}
+
+// Revoke stores given Revocation. Received Roots signed
+// using revoked certificate will be rejected, even if
+// they are older than the Revocation. If the Container
+// already has Revocation of the same issuer and
+// delegate, then the Revoke does nothing. To publish
+// the Revocation use (*Unpack).Revoke
+func (c *Container) Revoke(rev *registry.Revocation) (err error) {
+
+	if err = rev.Verify(); err != nil {
+		return
+	}
+
+	return c.db.IdxDB().BucketsTx(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(revocationsBucket); err != nil {
+			return
+		}
+
+		var key = revocationKey(rev.Issuer, rev.Delegate)
+
+		switch _, err = bk.Get(key); err {
+		case nil:
+			return // already revoked
+		case data.ErrNotFound:
+		default:
+			return
+		}
+
+		return bk.Set(key, encoder.Serialize(rev))
+
+	})
+
+}
+
+// Revocations returns Revocations issued by given
+// key. Use blank public key to get all Revocations
+func (c *Container) Revocations(
+	issuer cipher.PubKey,
+) (
+	revs []*registry.Revocation,
+	err error,
+) {
+
+	err = c.db.IdxDB().BucketsView(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(revocationsBucket); err != nil {
+			return
+		}
+
+		var prefix []byte
+		if issuer != (cipher.PubKey{}) {
+			prefix = issuer[:]
+		}
+
+		return bk.Ascend(prefix, func(_, val []byte) (err error) {
+			var rev = new(registry.Revocation)
+			if _, err = encoder.DeserializeRaw(val, rev); err != nil {
+				return
+			}
+			revs = append(revs, rev)
+			return
+		})
+
+	})
+
+	return
+}
+
+// store Revocations carried by given Root
+func (c *Container) addRevocations(r *registry.Root) (err error) {
+
+	if r.Delegation == nil {
+		return
+	}
+
+	for i := range r.Delegation.Revocations {
+		if err = c.Revoke(&r.Delegation.Revocations[i]); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// checkRevoked returns ErrRevoked if a certificate of
+// given Root is revoked by known Revocation or by a
+// Revocation carried by the Root
+func (c *Container) checkRevoked(r *registry.Root) (err error) {
+
+	if r.Delegation == nil {
+		return
+	}
+
+	for i := range r.Delegation.Revocations {
+		if r.IsRevokedBy(&r.Delegation.Revocations[i]) == true {
+			return ErrRevoked
+		}
+	}
+
+	for _, cert := range r.Delegation.Certs {
+
+		var rev *registry.Revocation
+		if rev, err = c.revocation(cert.Issuer, cert.Delegate); err != nil {
+			return
+		}
+
+		if rev != nil && r.IsRevokedBy(rev) == true {
+			return ErrRevoked
+		}
+
+	}
+
+	return
+}
+
+// verifyRoot decodes given encoded Root and verifies its
+// signature. The Root can be signed by its feed or by a
+// delegate of the feed. In the last case, the Root must
+// carry valid chain of certificates
+func verifyRoot(
+	pk cipher.PubKey, //     : feed
+	sig cipher.Sig, //       : signature
+	val []byte, //           : encoded Root
+) (
+	r *registry.Root, //     : decoded Root
+	err error, //            : an error
+) {
+
+	if r, err = registry.DecodeRoot(val); err != nil {
+		return
+	}
+
+	if err = r.VerifyDelegation(); err != nil {
+		return nil, err
+	}
+
+	var signer = pk
+
+	if r.Signer() != r.Pub {
+		if r.Pub != pk {
+			return nil, errors.New("the Root doesn't match its feed")
+		}
+		signer = r.Signer() // delegate
+	}
+
+	var hash = cipher.SumSHA256(val)
+
+	if err = cipher.VerifyPubKeySignedHash(signer, sig, hash); err != nil {
+		return nil, err
+	}
+
+	r.Hash = hash // set the hash
+	r.Sig = sig   // set the signature
+
+	return
+}
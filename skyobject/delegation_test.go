@@ -0,0 +1,136 @@
+package skyobject
+
+import (
+	"testing"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+func TestContainer_UnpackDelegate(t *testing.T) {
+
+	var (
+		c        = getTestContainer()
+		rc       = getTestContainer() // receiver
+		pk, sk   = cipher.GenerateKeyPair()
+		dpk, dsk = cipher.GenerateKeyPair()
+	)
+
+	defer c.Close()
+	defer rc.Close()
+
+	assertNil(t, c.AddFeed(pk))
+	assertNil(t, rc.AddFeed(pk))
+
+	var cert, err = registry.NewCertificate(sk, dpk, time.Time{})
+	assertNil(t, err)
+
+	var save = func(up *Unpack) (r *registry.Root, val []byte) {
+		t.Helper()
+
+		defer up.Close()
+
+		r = new(registry.Root)
+		r.Pub = pk
+		r.Nonce = 1
+
+		assertNil(t, c.Save(up, r))
+
+		var err error
+		if val, _, err = c.Get(r.Hash, 0); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	var up *Unpack
+	up, err = c.UnpackDelegate(dsk, testRegistry, *cert)
+	assertNil(t, err)
+
+	var r, val = save(up)
+
+	if r.Signer() != dpk {
+		t.Fatal("wrong signer")
+	}
+
+	t.Run("received", func(t *testing.T) {
+
+		var x, err = rc.ReceivedRoot(pk, r.Sig, val)
+		assertNil(t, err)
+
+		if x.Signer() != dpk {
+			t.Error("wrong signer")
+		}
+
+		// signed by the delegate, but the feed is another
+		var opk, _ = cipher.GenerateKeyPair()
+		if _, err = rc.PreviewRoot(opk, r.Sig, val); err == nil {
+			t.Error("missing error")
+		}
+
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+
+		if _, err = c.UnpackDelegate(sk, testRegistry, *cert); err == nil {
+			t.Error("missing error")
+		}
+
+	})
+
+	t.Run("revoked", func(t *testing.T) {
+
+		var rev *registry.Revocation
+		rev, err = registry.NewRevocation(sk, dpk)
+		assertNil(t, err)
+
+		// publish the revocation by the feed
+		var up *Unpack
+		up, err = c.Unpack(sk, testRegistry)
+		assertNil(t, err)
+		assertNil(t, up.Revoke(*rev))
+
+		var fr, fval = save(up)
+
+		var revs []*registry.Revocation
+		revs, err = c.Revocations(pk)
+		assertNil(t, err)
+
+		if len(revs) != 1 {
+			t.Fatal("revocation is not stored")
+		}
+
+		if _, err = c.UnpackDelegate(dsk, testRegistry, *cert); err != ErrRevoked {
+			t.Error("wrong error:", err)
+		}
+
+		// the receiver gets the Root with the revocation
+		var x *registry.Root
+		x, err = rc.ReceivedRoot(pk, fr.Sig, fval)
+		assertNil(t, err)
+		x.IsFull = true
+		_, err = rc.AddRoot(x)
+		assertNil(t, err)
+
+		// a Root signed by the delegate after the revocation
+		var dr = new(registry.Root)
+		dr.Pub = pk
+		dr.Nonce = 1
+		dr.Seq = fr.Seq + 1
+		dr.Prev = fr.Hash
+		dr.Reg = testRegistry.Reference()
+		dr.Delegation = &registry.Delegation{
+			Certs: []registry.Certificate{*cert},
+		}
+
+		var dval, dsig = testSignRoot(t, dr, dsk)
+
+		if _, err = rc.ReceivedRoot(pk, dsig, dval); err != ErrRevoked {
+			t.Error("wrong error:", err)
+		}
+
+	})
+
+}
@@ -0,0 +1,634 @@
+package skyobject
+
+import (
+	"crypto/aes"
+	stdcipher "crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"sync"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+
+	"github.com/skycoin/cxo/data"
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+// encrypted feeds
+//
+// Objects of an encrypted Root are encrypted using
+// content key of the feed before hashing. Thus, nodes
+// that don't have the key (relays) store and share
+// ciphertext. An encrypted object (sealed object) is
+//
+//     [4]byte      - number of links (little-endian)
+//     [n][32]byte  - links (plaintext)
+//     [12]byte     - nonce
+//     []byte       - ciphertext (AES-256-GCM)
+//
+// Links are hashes of objects the object refers to.
+// The links used to walk, fill and remove Roots
+// without the key, since schema of an object can't
+// be used to find references of encrypted object.
+// The links authenticated with the ciphertext. The
+// nonce is derived from the key and plaintext, thus
+// the same objects have the same hashes
+//
+// The content key is sent to readers inside key
+// envelope (see Envelope). Hash of the envelope is
+// in the Root (see registry.Root.Envelope)
+
+// A ContentKey represents symmetric
+// key used to encrypt a feed
+type ContentKey [32]byte
+
+// NewContentKey generates random ContentKey
+func NewContentKey() (ck ContentKey) {
+	if _, err := rand.Read(ck[:]); err != nil {
+		panic(err) // never happens
+	}
+	return
+}
+
+// An EnvelopeKey is ContentKey encrypted for a reader
+type EnvelopeKey struct {
+	Reader cipher.PubKey // public key of the reader
+	Key    []byte        // encrypted ContentKey
+}
+
+// An Envelope delivers ContentKey of encrypted
+// Root to its readers. The ContentKey encrypted for
+// every reader using shared secret of reader's key
+// and ephemeral key of the Envelope. The Envelope is
+// object referenced from the Root
+type Envelope struct {
+	Ephemeral cipher.PubKey // ephemeral public key
+	Keys      []EnvelopeKey // the key for every reader
+}
+
+// AEAD for key of given reader
+func envelopeAEAD(
+	pk cipher.PubKey, //      : public key
+	sk cipher.SecKey, //      : secret key
+) (
+	aead stdcipher.AEAD, //   : AES-256-GCM
+	err error, //             : an error
+) {
+
+	var shared []byte
+	if shared, err = cipher.ECDH(pk, sk); err != nil {
+		return
+	}
+
+	var key = cipher.SumSHA256(shared)
+	return newAEAD(key[:])
+}
+
+func newAEAD(key []byte) (aead stdcipher.AEAD, err error) {
+
+	var block stdcipher.Block
+	if block, err = aes.NewCipher(key); err != nil {
+		return
+	}
+
+	return stdcipher.NewGCM(block)
+}
+
+// newEnvelope creates Envelope of given
+// ContentKey for given readers
+func newEnvelope(
+	ck ContentKey, //            : the key
+	readers []cipher.PubKey, //  : readers
+) (
+	e *Envelope, //              : the envelope
+	err error, //                : an error
+) {
+
+	var pk, sk = cipher.GenerateKeyPair()
+
+	e = &Envelope{
+		Ephemeral: pk,
+		Keys:      make([]EnvelopeKey, 0, len(readers)),
+	}
+
+	// every shared secret is unique, since the
+	// ephemeral key is unique, thus zero nonce
+	var nonce [12]byte
+
+	for _, reader := range readers {
+
+		var aead stdcipher.AEAD
+		if aead, err = envelopeAEAD(reader, sk); err != nil {
+			return nil, err
+		}
+
+		e.Keys = append(e.Keys, EnvelopeKey{
+			Reader: reader,
+			Key:    aead.Seal(nil, nonce[:], ck[:], reader[:]),
+		})
+
+	}
+
+	return
+}
+
+// Open the Envelope using secret key of a reader
+func (e *Envelope) Open(
+	sk cipher.SecKey, //  : secret key of a reader
+) (
+	ck ContentKey, //     : the key
+	err error, //         : an error
+) {
+
+	var pk cipher.PubKey
+	if pk, err = cipher.PubKeyFromSecKey(sk); err != nil {
+		return
+	}
+
+	for _, ek := range e.Keys {
+
+		if ek.Reader != pk {
+			continue
+		}
+
+		var aead stdcipher.AEAD
+		if aead, err = envelopeAEAD(e.Ephemeral, sk); err != nil {
+			return
+		}
+
+		var (
+			nonce [12]byte
+			key   []byte
+		)
+
+		if key, err = aead.Open(nil, nonce[:], ek.Key, pk[:]); err != nil {
+			return
+		}
+
+		if len(key) != len(ck) {
+			err = errors.New("invalid length of content key")
+			return
+		}
+
+		copy(ck[:], key)
+		return
+	}
+
+	err = ErrNoContentKey
+	return
+}
+
+// Readers returns public keys of readers
+func (e *Envelope) Readers() (readers []cipher.PubKey) {
+	readers = make([]cipher.PubKey, 0, len(e.Keys))
+	for _, ek := range e.Keys {
+		readers = append(readers, ek.Reader)
+	}
+	return
+}
+
+// a packCipher encrypts and decrypts
+// objects of a Pack or an Unpack
+type packCipher struct {
+	ck   ContentKey
+	aead stdcipher.AEAD
+
+	// known hashes: created or linked by opened
+	// objects; a new object links known hashes
+	// it contains
+	mx    sync.Mutex
+	known map[cipher.SHA256]struct{}
+}
+
+func newPackCipher(ck ContentKey) (pc *packCipher, err error) {
+
+	pc = new(packCipher)
+	pc.ck = ck
+
+	if pc.aead, err = newAEAD(ck[:]); err != nil {
+		return nil, err
+	}
+
+	pc.known = make(map[cipher.SHA256]struct{})
+	return
+}
+
+func (p *packCipher) addKnown(hashes ...cipher.SHA256) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	for _, hash := range hashes {
+		p.known[hash] = struct{}{}
+	}
+}
+
+// links of given plaintext
+func (p *packCipher) links(plain []byte) (links []cipher.SHA256) {
+
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	var (
+		seen = make(map[cipher.SHA256]struct{})
+		hash cipher.SHA256
+	)
+
+	for i := 0; i+len(hash) <= len(plain); i++ {
+
+		copy(hash[:], plain[i:])
+
+		if _, ok := p.known[hash]; ok == false {
+			continue
+		}
+
+		if _, ok := seen[hash]; ok == true {
+			continue
+		}
+
+		seen[hash] = struct{}{}
+		links = append(links, hash)
+
+	}
+
+	return
+}
+
+// seal given plaintext
+func (p *packCipher) seal(plain []byte) (key cipher.SHA256, val []byte) {
+
+	var links = p.links(plain)
+
+	var head = make([]byte, 4, 4+len(links)*len(key))
+	binary.LittleEndian.PutUint32(head, uint32(len(links)))
+
+	for _, link := range links {
+		head = append(head, link[:]...)
+	}
+
+	// the nonce is derived from the key, the head (additional
+	// data) and the plaintext (SIV); thus the same nonce is
+	// never used for different head or plaintext, and the
+	// same object is sealed the same way
+	var sum = cipher.SumSHA256(append(append(append(
+		make([]byte, 0, len(p.ck)+len(head)+len(plain)),
+		p.ck[:]...), head...), plain...))
+	var nonce = sum[:p.aead.NonceSize()]
+
+	val = append(head, nonce...)
+	val = p.aead.Seal(val, nonce, plain, head)
+
+	key = cipher.SumSHA256(val)
+
+	p.addKnown(key)
+	return
+}
+
+// split sealed object
+func splitSealed(
+	val []byte, //              : sealed object
+) (
+	head []byte, //             : links with length
+	links []cipher.SHA256, //   : links
+	rest []byte, //             : nonce and ciphertext
+	err error, //               : an error
+) {
+
+	if len(val) < 4 {
+		err = ErrInvalidSealedObject
+		return
+	}
+
+	var (
+		ln  = int(binary.LittleEndian.Uint32(val))
+		end = 4 + ln*len(cipher.SHA256{})
+	)
+
+	if ln < 0 || end > len(val) || end < 4 {
+		err = ErrInvalidSealedObject
+		return
+	}
+
+	links = make([]cipher.SHA256, ln)
+	for i := range links {
+		copy(links[i][:], val[4+i*len(links[i]):])
+	}
+
+	return val[:end], links, val[end:], nil
+}
+
+// open sealed object
+func (p *packCipher) open(val []byte) (plain []byte, err error) {
+
+	var (
+		head, rest []byte
+		links      []cipher.SHA256
+	)
+
+	if head, links, rest, err = splitSealed(val); err != nil {
+		return
+	}
+
+	var ns = p.aead.NonceSize()
+
+	if len(rest) < ns {
+		return nil, ErrInvalidSealedObject
+	}
+
+	if plain, err = p.aead.Open(nil, rest[:ns], rest[ns:], head); err != nil {
+		return
+	}
+
+	p.addKnown(links...)
+	return
+}
+
+// walkSealed walks through sealed objects using their
+// links; given get function must return sealed values
+func walkSealed(
+	get func(key cipher.SHA256) ([]byte, error), // : get sealed
+	hash cipher.SHA256, //                           : object to walk
+	walkFunc registry.WalkFunc, //                   : the function
+) (
+	err error, //                                    : an error
+) {
+
+	if hash == (cipher.SHA256{}) {
+		return
+	}
+
+	var deepper bool
+	if deepper, err = walkFunc(hash, 0); err != nil || deepper == false {
+		return
+	}
+
+	var val []byte
+	if val, err = get(hash); err != nil {
+		return
+	}
+
+	var links []cipher.SHA256
+	if _, links, _, err = splitSealed(val); err != nil {
+		return
+	}
+
+	for _, link := range links {
+		if err = walkSealed(get, link, walkFunc); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// get encrypted value from DB
+func (c *Container) getSealed(key cipher.SHA256) (val []byte, err error) {
+	val, _, err = c.Get(key, 0)
+	return
+}
+
+// split (fill) sealed object using its links
+func splitSealedHash(s registry.Splitter, hash cipher.SHA256) {
+
+	if hash == (cipher.SHA256{}) {
+		return
+	}
+
+	var val, rc, err = s.Get(hash)
+
+	if err != nil {
+		s.Fail(err)
+		return
+	}
+
+	if rc > 1 {
+		return // already have
+	}
+
+	var links []cipher.SHA256
+	if _, links, _, err = splitSealed(val); err != nil {
+		s.Fail(err)
+		return
+	}
+
+	for _, link := range links {
+		func(link cipher.SHA256) {
+			s.Go(func() { splitSealedHash(s, link) })
+		}(link)
+	}
+
+}
+
+// AddReader adds secret key of a reader of encrypted
+// feeds. The keys used to open envelopes of encrypted
+// Roots (see Pack and Preview). The keys are not
+// stored in DB and should be added after every start
+func (c *Container) AddReader(sk cipher.SecKey) (err error) {
+
+	var pk cipher.PubKey
+	if pk, err = cipher.PubKeyFromSecKey(sk); err != nil {
+		return
+	}
+
+	c.rmx.Lock()
+	defer c.rmx.Unlock()
+
+	if c.readers == nil {
+		c.readers = make(map[cipher.PubKey]cipher.SecKey)
+	}
+
+	c.readers[pk] = sk
+	return
+}
+
+// DelReader removes key of a reader
+func (c *Container) DelReader(pk cipher.PubKey) {
+	c.rmx.Lock()
+	defer c.rmx.Unlock()
+
+	delete(c.readers, pk)
+}
+
+// Envelope returns key-envelope of given encrypted
+// Root. It returns ErrNotEncrypted if the Root is
+// not encrypted
+func (c *Container) Envelope(r *registry.Root) (e *Envelope, err error) {
+
+	if r.IsEncrypted() == false {
+		return nil, ErrNotEncrypted
+	}
+
+	var val []byte
+	if val, _, err = c.Get(r.Envelope, 0); err != nil {
+		return
+	}
+
+	return decodeEnvelope(val)
+}
+
+func decodeEnvelope(val []byte) (e *Envelope, err error) {
+	e = new(Envelope)
+	if _, err = encoder.DeserializeRaw(val, e); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// open given envelope using keys of readers
+func (c *Container) openEnvelope(e *Envelope) (ck ContentKey, err error) {
+
+	c.rmx.Lock()
+	defer c.rmx.Unlock()
+
+	for _, ek := range e.Keys {
+		if sk, ok := c.readers[ek.Reader]; ok == true {
+			return e.Open(sk)
+		}
+	}
+
+	err = ErrNoContentKey
+	return
+}
+
+// ContentKey returns ContentKey of given encrypted
+// Root if the Container has key of a reader of the
+// Root (see AddReader)
+func (c *Container) ContentKey(r *registry.Root) (ck ContentKey, err error) {
+
+	var e *Envelope
+	if e, err = c.Envelope(r); err != nil {
+		return
+	}
+
+	return c.openEnvelope(e)
+}
+
+// decrypting Pack of given Root, or the same pack if
+// the Root is not encrypted; if the Container doesn't
+// have ContentKey, then nil returned
+func (c *Container) rootPack(
+	pack *Pack,
+	r *registry.Root,
+) (
+	rp *Pack,
+	err error,
+) {
+
+	if r.IsEncrypted() == false {
+		return pack, nil
+	}
+
+	var ck ContentKey
+	switch ck, err = c.ContentKey(r); err {
+	case nil:
+	case ErrNoContentKey, data.ErrNotFound:
+		return nil, nil // can't decrypt
+	default:
+		return
+	}
+
+	rp = c.getPack(pack.reg)
+
+	if rp.pc, err = newPackCipher(ck); err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+// Encrypt makes the Unpack encrypted. All objects
+// created using the Unpack will be encrypted using
+// given ContentKey, and saved Root will have envelope
+// of the key for given readers. Publisher should be
+// reader of its feed to read it (see AddReader). The
+// Encrypt must be called before any object created.
+// Objects referenced by new objects must be created
+// or obtained using the Unpack, otherwise the Save
+// returns error. It's impossible to add a reader to
+// old objects, since the key is the same; but it's
+// impossible to remove reader without new key and
+// reencrypting all objects of a feed
+func (u *Unpack) Encrypt(
+	ck ContentKey, //               : the key
+	readers ...cipher.PubKey, //    : readers
+) (
+	err error, //                   : an error
+) {
+
+	if len(u.m) != 0 {
+		return errors.New("the Unpack already has objects")
+	}
+
+	if len(readers) == 0 {
+		return errors.New("no readers")
+	}
+
+	if u.Pack.pc, err = newPackCipher(ck); err != nil {
+		return
+	}
+
+	u.readers = append([]cipher.PubKey{}, readers...)
+	return
+}
+
+// seal the envelope of the Unpack
+func (u *Unpack) saveEnvelope(r *registry.Root) (err error) {
+
+	if u.Pack.pc == nil {
+		r.Envelope = cipher.SHA256{}
+		return
+	}
+
+	var e *Envelope
+	if e, err = newEnvelope(u.Pack.pc.ck, u.readers); err != nil {
+		return
+	}
+
+	var val = encoder.Serialize(e)
+
+	r.Envelope = cipher.SumSHA256(val)
+	return u.Set(r.Envelope, val)
+}
+
+// checkSealedRoot checks that links of new objects of
+// given encrypted Root contain all references; the
+// sealed is set of objects visited by the walkSealed
+func checkSealedRoot(
+	up *Unpack,
+	r *registry.Root,
+	sealed map[cipher.SHA256]struct{},
+) (
+	err error,
+) {
+
+	for _, dr := range r.Refs {
+
+		err = dr.Walk(up, func(
+			hash cipher.SHA256,
+			_ int,
+		) (
+			deepper bool,
+			err error,
+		) {
+
+			if hash == (cipher.SHA256{}) {
+				return
+			}
+
+			if _, ok := sealed[hash]; ok == false {
+				return false, &UnknownLinkError{hash}
+			}
+
+			var ui, ok = up.m[hash]
+			return ok && ui.created, nil
+
+		})
+
+		if err != nil {
+			return
+		}
+
+	}
+
+	return
+}
@@ -0,0 +1,181 @@
+package skyobject
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/data"
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+func Test_encrypted(t *testing.T) {
+
+	var (
+		sc     = getTestContainer() // publisher
+		rc     = getTestContainer() // relay
+		dc     = getTestContainer() // reader
+		pk, sk = cipher.GenerateKeyPair()
+
+		rpk, rsk = cipher.GenerateKeyPair() // reader
+		ck       = NewContentKey()
+	)
+
+	defer sc.Close()
+	defer rc.Close()
+	defer dc.Close()
+
+	for _, c := range []*Container{sc, rc, dc} {
+		assertNil(t, c.AddFeed(pk))
+	}
+
+	var up, err = sc.Unpack(sk, testRegistry)
+	assertNil(t, err)
+	defer up.Close()
+
+	assertNil(t, up.Encrypt(ck, pk, rpk))
+
+	var feed = Feed{
+		Head: "Alices' feed",
+		Info: "private",
+	}
+
+	for i := 0; i < 20; i++ {
+		assertNil(t, feed.Posts.AppendValues(up, Post{
+			Head: fmt.Sprintf("Head #%d", i),
+			Body: fmt.Sprintf("Body #%d", i),
+		}))
+	}
+
+	var r = new(registry.Root)
+	r.Pub = pk
+	r.Nonce = 1
+	r.Refs = []registry.Dynamic{
+		createDynamic(up, testRegistry, "test.Feed", &feed),
+	}
+
+	assertNil(t, sc.Save(up, r))
+
+	if r.IsEncrypted() == false {
+		t.Fatal("not encrypted")
+	}
+
+	t.Run("ciphertext", func(t *testing.T) {
+
+		var val, _, err = sc.Get(r.Refs[0].Hash, 0)
+		assertNil(t, err)
+
+		if bytes.Contains(val, []byte(feed.Head)) == true {
+			t.Error("plaintext stored")
+		}
+
+		if _, err = sc.Pack(r, nil); err != ErrNoContentKey {
+			t.Error("wrong error:", err)
+		}
+
+	})
+
+	// the relay doesn't have the key
+	testFillRoot(t, sc, rc, r)
+
+	if _, err = rc.Pack(r, nil); err != ErrNoContentKey {
+		t.Error("wrong error:", err)
+	}
+
+	// the reader
+	testFillRoot(t, rc, dc, r)
+	assertNil(t, dc.AddReader(rsk))
+
+	t.Run("read", func(t *testing.T) {
+
+		var pack, err = dc.Pack(r, nil)
+		assertNil(t, err)
+
+		var x Feed
+		assertNil(t, r.Refs[0].Value(pack, &x))
+
+		if x.Head != feed.Head {
+			t.Error("wrong feed")
+		}
+
+		var ln int
+		if ln, err = x.Posts.Len(pack); err != nil {
+			t.Fatal(err)
+		} else if ln != 20 {
+			t.Error("wrong number of posts", ln)
+		}
+
+	})
+
+	t.Run("update", func(t *testing.T) {
+
+		assertNil(t, sc.AddReader(sk)) // publisher is reader
+
+		var nr, err = sc.Update(sk, testRegistry, pk, 1, 1,
+			func(up *Unpack, r *registry.Root) (err error) {
+
+				var x = new(Feed)
+				if err = r.Refs[0].Value(up, x); err != nil {
+					return
+				}
+
+				if err = x.Posts.AppendValues(up, Post{Head: "next"}); err != nil {
+					return
+				}
+
+				return r.Refs[0].SetValue(up, x)
+			})
+
+		assertNil(t, err)
+
+		if nr.IsEncrypted() == false {
+			t.Fatal("not encrypted")
+		}
+
+		testFillRoot(t, sc, rc, nr)
+
+	})
+
+	t.Run("unknown link", func(t *testing.T) {
+
+		var up, err = sc.Unpack(sk, testRegistry)
+		assertNil(t, err)
+		defer up.Close()
+
+		assertNil(t, up.Encrypt(ck, pk))
+
+		// reference to object the Unpack doesn't know
+		var x = Feed{Posts: feed.Posts}
+
+		var nr = new(registry.Root)
+		nr.Pub = pk
+		nr.Nonce = 2
+		nr.Refs = []registry.Dynamic{
+			createDynamic(up, testRegistry, "test.Feed", &x),
+		}
+
+		if err = sc.Save(up, nr); err == nil {
+			t.Fatal("missing error")
+		} else if _, ok := err.(*UnknownLinkError); ok == false {
+			t.Fatal("wrong error:", err)
+		}
+
+	})
+
+	t.Run("delete", func(t *testing.T) {
+
+		assertNil(t, rc.DelFeed(pk))
+
+		// objects without references are removed by cleaning
+		var rrc int
+		if _, rrc, err = rc.Get(r.Refs[0].Hash, 0); err == nil && rrc != 0 {
+			t.Error("not removed", rrc)
+		} else if err != nil && err != data.ErrNotFound {
+			t.Error(err)
+		}
+
+	})
+
+}
@@ -2,6 +2,7 @@ package skyobject
 
 import (
 	"errors"
+	"strconv"
 
 	"github.com/skycoin/skycoin/src/cipher"
 )
@@ -11,8 +12,29 @@ var (
 	ErrObjectIsTooLarge = errors.New("object is too large (see MaxObjectSize)")
 	ErrTerminated       = errors.New("terminated")
 	ErrBlankRegistryRef = errors.New("blank registry reference")
+	ErrNoSuchFieldIndex = errors.New("no such field index")
+	ErrReadOnlyPack     = errors.New("read-only pack")
+	ErrRegistryMismatch = errors.New("registries of Roots are different")
+	ErrRevoked          = errors.New("certificate of the Root is revoked")
+
+	ErrNotEncrypted        = errors.New("the Root is not encrypted")
+	ErrNoContentKey        = errors.New("no content key for encrypted Root")
+	ErrInvalidSealedObject = errors.New("invalid encrypted object")
+
+	ErrRootIsPinned = errors.New("the Root is pinned")
+
+	ErrWrongObject = errors.New("wrong object received (different hash)")
 )
 
+// errHeadChanged is internal error, that means the head
+// has been changed between signing and saving a Root
+var errHeadChanged = errors.New("head has been changed")
+
+// errInvalidPartialValue is internal error, that means
+// the DB contains malformed value of partially filled
+// Root or of its object
+var errInvalidPartialValue = errors.New("invalid value of partial Root")
+
 // ObjectIsTooLargeError represents error that
 // occurs when an object exceed max object size
 // limit. The error contains hash of the object
@@ -29,3 +51,86 @@ func (o *ObjectIsTooLargeError) Hash() cipher.SHA256 {
 func (o *ObjectIsTooLargeError) Error() string {
 	return "object is too large: " + o.Hash().Hex()[:7]
 }
+
+// InvalidObjectError represents error that occurs
+// when an object violates constraints of its Schema
+// (see registry.Constraints). The error contains
+// hash of the object and the reason
+type InvalidObjectError struct {
+	hash cipher.SHA256
+	err  error
+}
+
+// Hash of the invalid object
+func (i *InvalidObjectError) Hash() cipher.SHA256 {
+	return i.hash
+}
+
+// Err returns underlying error, that is
+// *registry.ConstraintError usually
+func (i *InvalidObjectError) Err() error {
+	return i.err
+}
+
+// Error implements error interface
+func (i *InvalidObjectError) Error() string {
+	return "invalid object " + i.Hash().Hex()[:7] + ": " + i.err.Error()
+}
+
+// IndexRootError returned by Save, SaveIf and SaveTx
+// if the Root is saved, but field indexes (see
+// AddFieldIndex) can't be updated. The Root is saved
+// and the Save should not be repeated in this case
+type IndexRootError struct {
+	Root cipher.SHA256 // hash of the saved Root
+	Err  error         // the indexing error
+}
+
+// Error implements error interface
+func (i *IndexRootError) Error() string {
+	return "can't index saved Root " + i.Root.Hex()[:7] + ": " + i.Err.Error()
+}
+
+// UnknownLinkError returned by the Save if a new object
+// of encrypted Root refers to an object that was not
+// created or obtained using the Unpack; links of the
+// object are incomplete in this case (see Encrypt
+// method of the Unpack)
+type UnknownLinkError struct {
+	Hash cipher.SHA256 // referenced object
+}
+
+// Error implements error interface
+func (u *UnknownLinkError) Error() string {
+	return "reference to object unknown to the Unpack: " + u.Hash.Hex()[:7]
+}
+
+// SaveConflictError returned by SaveIf if the head
+// has been changed, e.g. if last Root of the head is
+// not the expected one. The Actual is hash of last
+// Root of the head, or blank if the head is blank
+type SaveConflictError struct {
+	Pub      cipher.PubKey // feed
+	Nonce    uint64        // head
+	Expected cipher.SHA256 // expected last Root
+	Actual   cipher.SHA256 // actual last Root
+}
+
+// Error implements error interface
+func (s *SaveConflictError) Error() string {
+	return "save conflict: head " + s.Pub.Hex()[:7] + "/" +
+		strconv.FormatUint(s.Nonce, 10) + " moved to " + s.Actual.Hex()[:7] +
+		", expected " + s.Expected.Hex()[:7]
+}
+
+// EquivocationError returned by ReceivedRoot and
+// CheckOldRoot if a received Root conflicts with
+// stored one. The Evidence is already stored
+type EquivocationError struct {
+	Evidence *Evidence
+}
+
+// Error implements error interface
+func (e *EquivocationError) Error() string {
+	return "equivocation: " + e.Evidence.String()
+}
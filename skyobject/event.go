@@ -0,0 +1,258 @@
+package skyobject
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+// EventsBuffer is default size of channel of a Subscription
+const EventsBuffer int = 128
+
+// An EventKind represents kind of Event
+type EventKind int
+
+// kinds of events
+const (
+	RootSaved        EventKind = iota + 1 // saved by Save or SaveTx
+	RootFilled                            // received and filled (AddRoot)
+	RootDeleted                           // deleted by DelRoot
+	FeedAdded                             // AddFeed
+	FeedDeleted                           // DelFeed
+	HeadAdded                             // AddHead
+	HeadDeleted                           // DelHead
+	ObjectsCollected                      // objects removed by cleaning
+)
+
+// String implements fmt.Stringer interface
+func (e EventKind) String() string {
+	switch e {
+	case RootSaved:
+		return "RootSaved"
+	case RootFilled:
+		return "RootFilled"
+	case RootDeleted:
+		return "RootDeleted"
+	case FeedAdded:
+		return "FeedAdded"
+	case FeedDeleted:
+		return "FeedDeleted"
+	case HeadAdded:
+		return "HeadAdded"
+	case HeadDeleted:
+		return "HeadDeleted"
+	case ObjectsCollected:
+		return "ObjectsCollected"
+	}
+	return fmt.Sprintf("EventKind<%d>", e)
+}
+
+// An Event represents a change of the Container.
+// Fields that don't relate to the Kind are blank.
+// The DelHead and the DelFeed don't send RootDeleted
+// events for removed Roots, the Amount is number of
+// the Roots instead. And a head created by a Root
+// doesn't produce HeadAdded event
+type Event struct {
+	Kind EventKind // kind of the event
+
+	Pub   cipher.PubKey // feed
+	Nonce uint64        // head
+	Seq   uint64        // seq of a Root
+	Hash  cipher.SHA256 // hash of a Root
+
+	Amount int // removed Roots or collected objects
+	Volume int // volume of collected objects
+
+	Time int64 // unix nano
+}
+
+// String implements fmt.Stringer interface
+func (e *Event) String() (s string) {
+
+	s = e.Kind.String()
+
+	switch e.Kind {
+	case RootSaved, RootFilled, RootDeleted:
+		s += fmt.Sprintf(" %s/%d/%d:%s", e.Pub.Hex()[:7], e.Nonce, e.Seq,
+			e.Hash.Hex()[:7])
+	case FeedAdded:
+		s += " " + e.Pub.Hex()[:7]
+	case FeedDeleted:
+		s += fmt.Sprintf(" %s (%d roots)", e.Pub.Hex()[:7], e.Amount)
+	case HeadAdded:
+		s += fmt.Sprintf(" %s/%d", e.Pub.Hex()[:7], e.Nonce)
+	case HeadDeleted:
+		s += fmt.Sprintf(" %s/%d (%d roots)", e.Pub.Hex()[:7], e.Nonce,
+			e.Amount)
+	case ObjectsCollected:
+		s += fmt.Sprintf(" %d (%d bytes)", e.Amount, e.Volume)
+	}
+
+	return
+}
+
+// An Overflow represents behaviour of a Subscription
+// if its channel is full. The Container never blocks
+// sending an event
+type Overflow int
+
+// overflow behaviours
+const (
+	DropNewest      Overflow = iota // drop event that doesn't fit
+	DropOldest                      // drop oldest event of the channel
+	CloseOnOverflow                 // close the channel and unsubscribe
+)
+
+// A Subscription represents subscription to
+// events of Container. Use Unsubscribe to
+// release resources. The C channel is closed
+// after the Unsubscribe, after Close of the
+// Container and on overflow if the Overflow
+// is CloseOnOverflow
+type Subscription struct {
+	C <-chan Event // events
+
+	c        *Container
+	ch       chan Event
+	overflow Overflow
+	dropped  int  // dropped events
+	closed   bool // the ch is closed
+}
+
+// Dropped returns number of events dropped
+// by the Subscription because of overflow
+func (s *Subscription) Dropped() (dropped int) {
+
+	s.c.emx.Lock()
+	defer s.c.emx.Unlock()
+
+	return s.dropped
+}
+
+// Unsubscribe closes the Subscription
+func (s *Subscription) Unsubscribe() {
+
+	s.c.emx.Lock()
+	defer s.c.emx.Unlock()
+
+	s.close()
+}
+
+// under lock
+func (s *Subscription) close() {
+
+	if s.closed == true {
+		return
+	}
+
+	close(s.ch)
+	s.closed = true
+	delete(s.c.subs, s)
+}
+
+// under lock
+func (s *Subscription) send(ev Event) {
+
+	select {
+	case s.ch <- ev:
+		return
+	default:
+	}
+
+	s.dropped++
+
+	switch s.overflow {
+	case DropOldest:
+
+		select {
+		case <-s.ch: // drop the oldest
+		default:
+		}
+
+		select {
+		case s.ch <- ev:
+		default:
+			s.dropped++ // the new one too
+		}
+
+	case CloseOnOverflow:
+		s.close()
+	}
+
+}
+
+// subscriptions of Container
+type subscriptions struct {
+	emx  sync.Mutex
+	subs map[*Subscription]struct{}
+}
+
+// Subscribe returns Subscription to events of the
+// Container. The size is size of channel of the
+// Subscription, if the size is zero or less, then
+// EventsBuffer is used. The overflow is behaviour of
+// the Subscription if the channel is full. Events of
+// local changes (Save, DelRoot, AddFeed, etc) and of
+// received Roots (AddRoot) are sent after the changes
+func (c *Container) Subscribe(size int, overflow Overflow) (s *Subscription) {
+
+	if size <= 0 {
+		size = EventsBuffer
+	}
+
+	s = new(Subscription)
+	s.c = c
+	s.ch = make(chan Event, size)
+	s.C = s.ch
+	s.overflow = overflow
+
+	c.emx.Lock()
+	defer c.emx.Unlock()
+
+	if c.subs == nil {
+		c.subs = make(map[*Subscription]struct{})
+	}
+
+	c.subs[s] = struct{}{}
+	return
+}
+
+// Emit sends given event to all subscribers. The
+// Container emits its events itself. The Emit used
+// by cxoutils package to notify about collected
+// objects. If Time of the Event is zero, then the
+// Emit sets it to now
+func (c *Container) Emit(ev Event) {
+
+	if ev.Time == 0 {
+		ev.Time = time.Now().UnixNano()
+	}
+
+	c.emx.Lock()
+	defer c.emx.Unlock()
+
+	for s := range c.subs {
+		s.send(ev)
+	}
+}
+
+// close all subscriptions
+func (c *Container) closeSubscriptions() {
+
+	c.emx.Lock()
+	defer c.emx.Unlock()
+
+	for s := range c.subs {
+		s.close()
+	}
+}
+
+// event of a Root
+func rootEvent(kind EventKind, pk cipher.PubKey, nonce, seq uint64,
+	hash cipher.SHA256) Event {
+
+	return Event{Kind: kind, Pub: pk, Nonce: nonce, Seq: seq, Hash: hash}
+}
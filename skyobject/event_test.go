@@ -0,0 +1,132 @@
+package skyobject
+
+import (
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+func expectEvent(t *testing.T, s *Subscription, kind EventKind) (ev Event) {
+	t.Helper()
+
+	select {
+	case ev = <-s.C:
+	default:
+		t.Fatal("missing event", kind)
+	}
+
+	if ev.Kind != kind {
+		t.Fatalf("wrong event %s, expected %s", ev.String(), kind)
+	}
+
+	if ev.Time == 0 {
+		t.Error("missing time of event")
+	}
+
+	return
+}
+
+func TestContainer_Subscribe(t *testing.T) {
+
+	var (
+		c      = getTestContainer()
+		s      = c.Subscribe(0, DropNewest)
+		pk, sk = cipher.GenerateKeyPair()
+	)
+
+	defer c.Close()
+
+	assertNil(t, c.AddFeed(pk))
+	assertNil(t, c.AddFeed(pk)) // already have
+
+	if ev := expectEvent(t, s, FeedAdded); ev.Pub != pk {
+		t.Error("wrong feed")
+	}
+
+	assertNil(t, c.AddHead(pk, 2))
+	expectEvent(t, s, HeadAdded)
+
+	var up, err = c.Unpack(sk, testRegistry)
+	assertNil(t, err)
+	defer up.Close()
+
+	var r = new(registry.Root)
+	r.Pub = pk
+	r.Nonce = 1
+
+	assertNil(t, c.Save(up, r))
+
+	if ev := expectEvent(t, s, RootSaved); ev.Hash != r.Hash {
+		t.Error("wrong Root")
+	}
+
+	assertNil(t, c.DelRoot(pk, 1, 0))
+
+	if ev := expectEvent(t, s, RootDeleted); ev.Hash != r.Hash || ev.Seq != 0 {
+		t.Error("wrong Root")
+	}
+
+	assertNil(t, c.DelHead(pk, 2))
+	expectEvent(t, s, HeadDeleted)
+
+	assertNil(t, c.DelFeed(pk))
+	expectEvent(t, s, FeedDeleted)
+
+	select {
+	case ev := <-s.C:
+		t.Error("unexpected event", ev.String())
+	default:
+	}
+
+	t.Run("overflow", func(t *testing.T) {
+
+		var (
+			drop    = c.Subscribe(1, DropNewest)
+			old     = c.Subscribe(1, DropOldest)
+			closing = c.Subscribe(1, CloseOnOverflow)
+		)
+
+		c.Emit(Event{Kind: ObjectsCollected, Amount: 1})
+		c.Emit(Event{Kind: ObjectsCollected, Amount: 2})
+
+		if ev := <-drop.C; ev.Amount != 1 || drop.Dropped() != 1 {
+			t.Error("wrong DropNewest")
+		}
+
+		if ev := <-old.C; ev.Amount != 2 || old.Dropped() != 1 {
+			t.Error("wrong DropOldest")
+		}
+
+		<-closing.C // first
+
+		if _, ok := <-closing.C; ok == true {
+			t.Error("not closed")
+		}
+
+		drop.Unsubscribe()
+		drop.Unsubscribe() // twice
+
+		if _, ok := <-drop.C; ok == true {
+			t.Error("not closed")
+		}
+
+		old.Unsubscribe()
+
+	})
+
+	t.Run("close", func(t *testing.T) {
+
+		var nc = getTestContainer()
+		var s = nc.Subscribe(1, DropNewest)
+
+		assertNil(t, nc.Close())
+
+		if _, ok := <-s.C; ok == true {
+			t.Error("not closed")
+		}
+
+	})
+
+}
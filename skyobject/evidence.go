@@ -0,0 +1,410 @@
+package skyobject
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+
+	"github.com/skycoin/cxo/data"
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+// name of IdxDB bucket with evidence
+var evidenceBucket = []byte("skyobject.evidence")
+
+// An EvidenceKind represents kind of equivocation
+type EvidenceKind uint8
+
+// kinds of equivocation
+const (
+	// ForkedSeq means that two different Roots
+	// have the same feed, nonce and seq
+	ForkedSeq EvidenceKind = iota + 1
+	// BrokenPrev means that Prev field of a Root
+	// doesn't point to Root with previous seq; e.g.
+	// the publisher has signed another previous Root
+	BrokenPrev
+)
+
+// String implements fmt.Stringer interface
+func (e EvidenceKind) String() string {
+	switch e {
+	case ForkedSeq:
+		return "forked seq"
+	case BrokenPrev:
+		return "broken prev"
+	}
+	return fmt.Sprintf("EvidenceKind<%d>", e)
+}
+
+// A SignedRoot represents encoded Root with signature
+type SignedRoot struct {
+	Value []byte     // encoded Root
+	Sig   cipher.Sig // signature
+}
+
+// Root verifies signature of the SignedRoot using
+// given public key of feed and returns decoded Root.
+// The Root can be signed by a delegate of the feed
+func (s *SignedRoot) Root(pk cipher.PubKey) (r *registry.Root, err error) {
+	return verifyRoot(pk, s.Sig, s.Value)
+}
+
+// An Evidence is cryptographic evidence of equivocation.
+// It contains two conflicting Roots signed by the same
+// feed. For the ForkedSeq the Roots have the same seq.
+// For the BrokenPrev, the Second Root is next after the
+// First, but Prev of the Second doesn't point to the
+// First. Anyone can check the Evidence using the Verify
+// method
+type Evidence struct {
+	Kind  EvidenceKind
+	Pub   cipher.PubKey // feed
+	Nonce uint64        // head
+	Seq   uint64        // seq of the First
+
+	First  SignedRoot
+	Second SignedRoot
+
+	Time int64 // detected at (unix nano)
+}
+
+// Hash of the Evidence, that doesn't depend on Time
+func (e *Evidence) Hash() cipher.SHA256 {
+	return cipher.SumSHA256(encoder.Serialize(struct {
+		Kind          EvidenceKind
+		First, Second SignedRoot
+	}{e.Kind, e.First, e.Second}))
+}
+
+// Verify the Evidence. The Verify returns
+// error if the Evidence is not valid
+func (e *Evidence) Verify() (err error) {
+
+	var first, second *registry.Root
+
+	if first, err = e.First.Root(e.Pub); err != nil {
+		return fmt.Errorf("first Root: %v", err)
+	}
+
+	if second, err = e.Second.Root(e.Pub); err != nil {
+		return fmt.Errorf("second Root: %v", err)
+	}
+
+	for _, r := range []*registry.Root{first, second} {
+		if r.Pub != e.Pub || r.Nonce != e.Nonce {
+			return errors.New("Root of another feed or head")
+		}
+	}
+
+	if first.Seq != e.Seq {
+		return errors.New("wrong seq of the first Root")
+	}
+
+	switch e.Kind {
+	case ForkedSeq:
+		if second.Seq != first.Seq {
+			return errors.New("different seq")
+		}
+		if second.Hash == first.Hash {
+			return errors.New("the same Root")
+		}
+	case BrokenPrev:
+		if second.Seq != first.Seq+1 {
+			return errors.New("the second Root is not next")
+		}
+		if second.Prev == first.Hash {
+			return errors.New("the Roots are linked")
+		}
+	default:
+		return fmt.Errorf("invalid kind %d", e.Kind)
+	}
+
+	return
+}
+
+// String implements fmt.Stringer interface
+func (e *Evidence) String() string {
+	return fmt.Sprintf("%s %s/%d/%d at %s", e.Kind, e.Pub.Hex()[:7], e.Nonce,
+		e.Seq, time.Unix(0, e.Time).Format(time.Stamp))
+}
+
+// signed Root stored in the Container
+func (c *Container) signedRoot(dr *data.Root) (sr SignedRoot, err error) {
+	if sr.Value, _, err = c.Get(dr.Hash, 0); err != nil {
+		return
+	}
+	sr.Sig = dr.Sig
+	return
+}
+
+// checkChain checks given received Root (that is not
+// stored) against stored Roots of its head; the feed
+// must be locked; the checkChain returns Evidence if
+// the Root conflicts with stored one
+func (i *Index) checkChain(
+	r *registry.Root, //    : received Root (verified)
+	val []byte, //          : encoded Root
+) (
+	ev *Evidence, //        : evidence if any
+	err error, //           : an error
+) {
+
+	if r.Seq == 0 && r.Prev != (cipher.SHA256{}) {
+		return nil, errors.New("unexpected Prev of first Root " + r.Short())
+	} else if r.Seq != 0 && r.Prev == (cipher.SHA256{}) {
+		return nil, errors.New("missing Prev of Root " + r.Short())
+	}
+
+	var received = SignedRoot{Value: val, Sig: r.Sig}
+
+	// stored Root of given seq or nil
+	var stored = func(seq uint64) (dr *data.Root, err error) {
+		switch dr, err = i.dataRoot(r.Pub, r.Nonce, seq); err {
+		case data.ErrNotFound, data.ErrNoSuchHead:
+			return nil, nil
+		}
+		return
+	}
+
+	var newEvidence = func(
+		kind EvidenceKind,
+		seq uint64,
+		first, second *SignedRoot,
+	) *Evidence {
+		return &Evidence{
+			Kind:   kind,
+			Pub:    r.Pub,
+			Nonce:  r.Nonce,
+			Seq:    seq,
+			First:  *first,
+			Second: *second,
+			Time:   time.Now().UnixNano(),
+		}
+	}
+
+	var (
+		dr *data.Root
+		sr SignedRoot
+	)
+
+	// the same seq
+
+	if dr, err = stored(r.Seq); err != nil {
+		return
+	}
+
+	if dr != nil && dr.Hash != r.Hash {
+		if sr, err = i.c.signedRoot(dr); err != nil {
+			return
+		}
+		return newEvidence(ForkedSeq, r.Seq, &sr, &received), nil
+	}
+
+	// previous
+
+	if r.Seq > 0 {
+
+		if dr, err = stored(r.Seq - 1); err != nil {
+			return
+		}
+
+		if dr != nil && dr.Hash != r.Prev {
+			if sr, err = i.c.signedRoot(dr); err != nil {
+				return
+			}
+			return newEvidence(BrokenPrev, dr.Seq, &sr, &received), nil
+		}
+
+	}
+
+	// next
+
+	if dr, err = stored(r.Seq + 1); err != nil {
+		return
+	}
+
+	if dr != nil && dr.Prev != r.Hash {
+		if sr, err = i.c.signedRoot(dr); err != nil {
+			return
+		}
+		return newEvidence(BrokenPrev, r.Seq, &received, &sr), nil
+	}
+
+	return
+}
+
+// CheckOldRoot used by the node package to check a
+// received Root that is not newer than last Root of
+// its head. The Root is not going to be filled, but
+// it can be conflicting. If a stored Root with the
+// same seq exists, and it is the same, then the
+// CheckOldRoot does nothing (and doesn't verify the
+// signature). Otherwise, it verifies the Root and
+// returns *EquivocationError if the Root conflicts
+// with stored one. The Evidence is stored in the
+// Container
+func (i *Index) CheckOldRoot(
+	pk cipher.PubKey, //  : feed
+	nonce uint64, //      : head
+	seq uint64, //        : seq
+	sig cipher.Sig, //    : signature
+	val []byte, //        : encoded Root
+) (
+	err error, //         : an error
+) {
+
+	defer i.lockFeed(pk)()
+
+	var dr *data.Root
+	switch dr, err = i.dataRoot(pk, nonce, seq); err {
+	case nil:
+		if dr.Hash == cipher.SumSHA256(val) {
+			return // the same
+		}
+	case data.ErrNotFound, data.ErrNoSuchHead:
+		// removed or not received, can be linked with
+		// stored ones, but it's too expensive to check
+		// every old Root
+		return nil
+	default:
+		return
+	}
+
+	var r *registry.Root
+	if r, err = i.receivedRoot(pk, sig, val); err != nil {
+		return
+	}
+
+	if r.Pub != pk || r.Nonce != nonce || r.Seq != seq {
+		return errors.New("the Root doesn't match its feed, head or seq")
+	}
+
+	return i.equivocation(r, val)
+}
+
+// check the chain, and store evidence if any
+func (i *Index) equivocation(r *registry.Root, val []byte) (err error) {
+
+	var ev *Evidence
+	if ev, err = i.checkChain(r, val); err != nil || ev == nil {
+		return
+	}
+
+	if err = i.c.addEvidence(ev); err != nil {
+		return
+	}
+
+	return &EquivocationError{ev}
+}
+
+// evidence key: feed + hash
+func evidenceKey(ev *Evidence) []byte {
+	var hash = ev.Hash()
+	return append(append([]byte{}, ev.Pub[:]...), hash[:]...)
+}
+
+// store given evidence, the Time of
+// first detected evidence is kept
+func (c *Container) addEvidence(ev *Evidence) (err error) {
+
+	return c.db.IdxDB().BucketsTx(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(evidenceBucket); err != nil {
+			return
+		}
+
+		var key = evidenceKey(ev)
+
+		if _, err = bk.Get(key); err == nil {
+			return // already have
+		} else if err != data.ErrNotFound {
+			return
+		}
+
+		return bk.Set(key, encoder.Serialize(ev))
+
+	})
+
+}
+
+// Evidence returns stored evidence of equivocation of
+// given feed. Use blank public key to get evidence of
+// all feeds. The evidence is kept even if the feed
+// removed from the Container. Use DelEvidence to
+// remove evidence
+func (c *Container) Evidence(pk cipher.PubKey) (evs []*Evidence, err error) {
+
+	err = c.db.IdxDB().BucketsView(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(evidenceBucket); err != nil {
+			return
+		}
+
+		var prefix []byte
+		if pk != (cipher.PubKey{}) {
+			prefix = pk[:]
+		}
+
+		return bk.Ascend(prefix, func(_, val []byte) (err error) {
+			var ev = new(Evidence)
+			if _, err = encoder.DeserializeRaw(val, ev); err != nil {
+				return
+			}
+			evs = append(evs, ev)
+			return
+		})
+
+	})
+
+	return
+}
+
+// DelEvidence removes all stored evidence of given feed
+func (c *Container) DelEvidence(pk cipher.PubKey) (err error) {
+
+	return c.db.IdxDB().BucketsTx(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(evidenceBucket); err != nil {
+			return
+		}
+
+		return bk.Ascend(pk[:], func(key, _ []byte) (err error) {
+			return bk.Del(key)
+		})
+
+	})
+
+}
+
+// number of evidence by feeds
+func (c *Container) evidenceStat() (total int, feeds map[cipher.PubKey]int) {
+
+	feeds = make(map[cipher.PubKey]int)
+
+	// ignore error
+	c.db.IdxDB().BucketsView(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(evidenceBucket); err != nil {
+			return
+		}
+
+		return bk.Ascend(nil, func(key, _ []byte) (_ error) {
+			var pk cipher.PubKey
+			copy(pk[:], key)
+			feeds[pk]++
+			total++
+			return
+		})
+
+	})
+
+	return
+}
@@ -0,0 +1,183 @@
+package skyobject
+
+import (
+	"testing"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+// sign given Root without saving
+func testSignRoot(
+	t *testing.T,
+	r *registry.Root,
+	sk cipher.SecKey,
+) (
+	val []byte,
+	sig cipher.Sig,
+) {
+
+	t.Helper()
+
+	r.Time = time.Now().UnixNano()
+
+	val = r.Encode()
+
+	var err error
+	if sig, err = cipher.SignHash(cipher.SumSHA256(val), sk); err != nil {
+		t.Fatal(err)
+	}
+
+	return
+}
+
+func TestIndex_ReceivedRoot_equivocation(t *testing.T) {
+
+	var (
+		c      = getTestContainer()
+		pk, sk = cipher.GenerateKeyPair()
+	)
+
+	defer c.Close()
+
+	assertNil(t, c.AddFeed(pk))
+
+	var saved []*registry.Root
+
+	for i := 0; i < 2; i++ {
+		var up, err = c.Unpack(sk, testRegistry)
+		assertNil(t, err)
+
+		var r = new(registry.Root)
+		r.Pub = pk
+		r.Nonce = 1
+		r.Descriptor = []byte{byte(i)}
+
+		assertNil(t, c.Save(up, r))
+		saved = append(saved, r)
+	}
+
+	var fork = func(seq uint64, prev cipher.SHA256) (val []byte, sig cipher.Sig) {
+		var r = new(registry.Root)
+		r.Pub = pk
+		r.Nonce = 1
+		r.Seq = seq
+		r.Prev = prev
+		r.Reg = testRegistry.Reference()
+		r.Descriptor = []byte("fork")
+		return testSignRoot(t, r, sk)
+	}
+
+	var evidence = func(err error, kind EvidenceKind, seq uint64) {
+		t.Helper()
+
+		var ee, ok = err.(*EquivocationError)
+
+		if ok == false {
+			t.Fatal("wrong error:", err)
+		}
+
+		if ee.Evidence.Kind != kind || ee.Evidence.Seq != seq {
+			t.Error("wrong evidence", ee.Evidence)
+		}
+
+		if err = ee.Evidence.Verify(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	t.Run("the same", func(t *testing.T) {
+
+		var val, _, err = c.Get(saved[1].Hash, 0)
+		assertNil(t, err)
+
+		var r *registry.Root
+		r, err = c.ReceivedRoot(pk, saved[1].Sig, val)
+		assertNil(t, err)
+
+		if r.IsFull == false {
+			t.Error("not full")
+		}
+
+		assertNil(t, c.CheckOldRoot(pk, 1, 1, saved[1].Sig, val))
+
+	})
+
+	t.Run("forked seq", func(t *testing.T) {
+
+		var val, sig = fork(1, saved[0].Hash)
+
+		var _, err = c.ReceivedRoot(pk, sig, val)
+		evidence(err, ForkedSeq, 1)
+
+		evidence(c.CheckOldRoot(pk, 1, 1, sig, val), ForkedSeq, 1)
+
+	})
+
+	t.Run("broken prev", func(t *testing.T) {
+
+		var val, sig = fork(2, cipher.SumSHA256([]byte("another")))
+
+		var _, err = c.ReceivedRoot(pk, sig, val)
+		evidence(err, BrokenPrev, 1)
+
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+
+		var val, sig = fork(3, cipher.SHA256{})
+
+		if _, err := c.ReceivedRoot(pk, sig, val); err == nil {
+			t.Error("missing error")
+		} else if _, ok := err.(*EquivocationError); ok == true {
+			t.Error("unexpected evidence")
+		}
+
+	})
+
+	t.Run("stored", func(t *testing.T) {
+
+		var evs, err = c.Evidence(pk)
+		assertNil(t, err)
+
+		if len(evs) != 2 { // the forked seq stored once
+			t.Fatal("wrong number of evidence:", len(evs))
+		}
+
+		for _, ev := range evs {
+			assertNil(t, ev.Verify())
+		}
+
+		// tamper
+		var ev = *evs[0]
+		ev.Second = ev.First
+
+		if err = ev.Verify(); err == nil {
+			t.Error("invalid evidence verified")
+		}
+
+		var s = c.Stat()
+
+		if s.Evidence != 2 || s.Feeds[pk].Evidence != 2 {
+			t.Error("wrong stat", s.Evidence, s.Feeds[pk].Evidence)
+		}
+
+		if evs, err = c.Evidence(cipher.PubKey{}); err != nil {
+			t.Fatal(err)
+		} else if len(evs) != 2 {
+			t.Error("wrong number of all evidence:", len(evs))
+		}
+
+		assertNil(t, c.DelEvidence(pk))
+
+		if evs, err = c.Evidence(pk); err != nil {
+			t.Fatal(err)
+		} else if len(evs) != 0 {
+			t.Error("evidence not removed")
+		}
+
+	})
+
+}
@@ -0,0 +1,585 @@
+package skyobject
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+
+	"github.com/skycoin/cxo/data"
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+// names of IdxDB buckets used by field indexes
+var (
+	fieldIndexesBucket    = []byte("skyobject.field_indexes")
+	fieldIndexRootsBucket = []byte("skyobject.field_index_roots")
+
+	fieldIndexBucketPrefix = "skyobject.field_index."
+)
+
+// A FieldIndex represents declaration of secondary
+// index over field of objects of a named Schema.
+// The index maps encoded value of the field to
+// hashes of objects and Root objects they belong to
+type FieldIndex struct {
+	Schema string // name of the Schema (e.g. "app.Post")
+	Field  string // name of field of the Schema (e.g. "Author")
+}
+
+// String implements fmt.Stringer interface
+func (f FieldIndex) String() string {
+	return f.Schema + "." + f.Field
+}
+
+func (f FieldIndex) bucket() []byte {
+	return []byte(fieldIndexBucketPrefix + f.String())
+}
+
+func fieldIndexFromString(s string) (f FieldIndex, err error) {
+	var i = strings.LastIndexByte(s, '.')
+	if i <= 0 || i == len(s)-1 {
+		err = errors.New("malformed field index: " + s)
+		return
+	}
+	f.Schema, f.Field = s[:i], s[i+1:]
+	return
+}
+
+// A FieldIndexEntry represents an object
+// found by a field index
+type FieldIndexEntry struct {
+	Object cipher.SHA256 // hash of the object
+
+	Root  cipher.SHA256 // hash of the Root the object belongs to
+	Feed  cipher.PubKey // feed of the Root
+	Nonce uint64        // head of the Root
+	Seq   uint64        // seq number of the Root
+}
+
+// value of an entry of a field index
+type fieldIndexEntryValue struct {
+	Feed  cipher.PubKey
+	Nonce uint64
+	Seq   uint64
+}
+
+// key of reverse index (root -> entries)
+type fieldIndexLocation struct {
+	Bucket []byte // bucket of a field index
+	Key    []byte // key of an entry
+}
+
+// key of an entry is length of the value,
+// the value, hash of object and hash of Root;
+// thus the entries of a value can be found
+// by prefix: length + value
+func fieldIndexValuePrefix(fv []byte) (prefix []byte) {
+	prefix = make([]byte, 4, 4+len(fv)+2*len(cipher.SHA256{}))
+	binary.BigEndian.PutUint32(prefix, uint32(len(fv)))
+	return append(prefix, fv...)
+}
+
+func fieldIndexEntryKey(fv []byte, obj, root cipher.SHA256) (key []byte) {
+	key = fieldIndexValuePrefix(fv)
+	key = append(key, obj[:]...)
+	return append(key, root[:]...)
+}
+
+// field indexes of the Container
+type fieldIndexes struct {
+	mx  sync.Mutex
+	fis map[FieldIndex]struct{} // declared
+}
+
+func (c *Container) loadFieldIndexes() (err error) {
+
+	c.fi.fis = make(map[FieldIndex]struct{})
+
+	return c.db.IdxDB().BucketsView(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(fieldIndexesBucket); err != nil {
+			return
+		}
+
+		return bk.Ascend(nil, func(key, _ []byte) (err error) {
+			var fi FieldIndex
+			if fi, err = fieldIndexFromString(string(key)); err != nil {
+				return
+			}
+			c.fi.fis[fi] = struct{}{}
+			return
+		})
+
+	})
+
+}
+
+// FieldIndexes returns list of declared field indexes
+func (c *Container) FieldIndexes() (fis []FieldIndex) {
+
+	c.fi.mx.Lock()
+	defer c.fi.mx.Unlock()
+
+	fis = make([]FieldIndex, 0, len(c.fi.fis))
+
+	for fi := range c.fi.fis {
+		fis = append(fis, fi)
+	}
+
+	return
+}
+
+// AddFieldIndex declares index over given field of objects
+// of Schema with given name. The declaration is persistent
+// and the index is updated every time a Root is saved or
+// filled, and every time a Root is removed. If the index
+// is new, then the AddFieldIndex indexes all Root objects
+// the Container already has. Objects of Registries that
+// have not such Schema or such field are not indexed.
+// Adding an index twice does nothing
+func (c *Container) AddFieldIndex(schemaName, field string) (err error) {
+
+	if schemaName == "" || field == "" {
+		return errors.New("empty name of Schema or field")
+	}
+
+	if strings.IndexByte(field, '.') >= 0 {
+		return errors.New("invalid field name: " + field)
+	}
+
+	var fi = FieldIndex{Schema: schemaName, Field: field}
+
+	var added bool
+	if added, err = c.declareFieldIndex(fi); err != nil || added == false {
+		return
+	}
+
+	// index existing Root objects without the lock, since Root
+	// objects saved or filled meanwhile are indexed anyway
+
+	var rhs []cipher.SHA256
+	if rhs, err = c.rootHashes(); err != nil {
+		return
+	}
+
+	var fis = []FieldIndex{fi}
+
+	for _, hash := range rhs {
+
+		var r *registry.Root
+		switch r, err = c.savedRoot(hash); err {
+		case nil:
+		case data.ErrNotFound, data.ErrNoSuchHead, data.ErrNoSuchFeed:
+			err = nil
+			continue // removed meanwhile
+		default:
+			return
+		}
+
+		if r.IsPartial == true {
+			continue // not indexed
+		}
+
+		var pack *Pack
+		switch pack, err = c.Pack(r, nil); err {
+		case nil:
+		case ErrNoContentKey:
+			continue // encrypted
+		default:
+			return
+		}
+
+		if err = c.indexRootFields(pack, r, fis); err != nil {
+			return
+		}
+
+	}
+
+	return
+}
+
+// persist declaration of given field index and add it
+// to declared; the added is false if it already exists
+func (c *Container) declareFieldIndex(fi FieldIndex) (added bool, err error) {
+
+	c.fi.mx.Lock()
+	defer c.fi.mx.Unlock()
+
+	if _, ok := c.fi.fis[fi]; ok {
+		return // already exists
+	}
+
+	err = c.db.IdxDB().BucketsTx(func(bs data.Buckets) (err error) {
+		var bk data.Bucket
+		if bk, err = bs.Bucket(fieldIndexesBucket); err != nil {
+			return
+		}
+		return bk.Set([]byte(fi.String()), []byte{})
+	})
+
+	if err != nil {
+		return
+	}
+
+	c.fi.fis[fi] = struct{}{}
+	return true, nil
+}
+
+// hashes of all Root objects of the Container
+func (c *Container) rootHashes() (rhs []cipher.SHA256, err error) {
+
+	err = c.db.IdxDB().Tx(func(feeds data.Feeds) (err error) {
+		return feeds.Iterate(func(pk cipher.PubKey) (err error) {
+
+			var hs data.Heads
+			if hs, err = feeds.Heads(pk); err != nil {
+				return
+			}
+
+			return hs.Iterate(func(nonce uint64) (err error) {
+
+				var rs data.Roots
+				if rs, err = hs.Roots(nonce); err != nil {
+					return
+				}
+
+				return rs.Ascend(func(dr *data.Root) (err error) {
+					rhs = append(rhs, dr.Hash)
+					return
+				})
+
+			})
+
+		})
+	})
+
+	return
+}
+
+// DelFieldIndex removes index with all its entries. It
+// returns ErrNoSuchFieldIndex if the index doesn't exist
+func (c *Container) DelFieldIndex(schemaName, field string) (err error) {
+
+	var fi = FieldIndex{Schema: schemaName, Field: field}
+
+	c.fi.mx.Lock()
+	defer c.fi.mx.Unlock()
+
+	if _, ok := c.fi.fis[fi]; ok == false {
+		return ErrNoSuchFieldIndex
+	}
+
+	var name = fi.bucket()
+
+	err = c.db.IdxDB().BucketsTx(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(fieldIndexesBucket); err != nil {
+			return
+		}
+
+		if err = bk.Del([]byte(fi.String())); err != nil {
+			return
+		}
+
+		// remove reverse entries of the index; the reverse
+		// index is not ordered by index, thus we have to
+		// walk through all entries
+
+		if bk, err = bs.Bucket(fieldIndexRootsBucket); err != nil {
+			return
+		}
+
+		err = bk.Ascend(nil, func(key, _ []byte) (err error) {
+			var loc fieldIndexLocation
+			_, err = encoder.DeserializeRaw(key[len(cipher.SHA256{}):], &loc)
+			if err != nil {
+				return
+			}
+			if string(loc.Bucket) == string(name) {
+				return bk.Del(key)
+			}
+			return
+		})
+
+		if err != nil {
+			return
+		}
+
+		return bs.Del(name)
+	})
+
+	if err != nil {
+		return
+	}
+
+	delete(c.fi.fis, fi)
+	return
+}
+
+// indexRoot adds given Root to all declared field indexes
+func (c *Container) indexRoot(
+	pack registry.Pack,
+	r *registry.Root,
+) (
+	err error,
+) {
+
+	// the walking doesn't hold the lock, an index
+	// removed meanwhile is skipped by indexRootFields
+
+	var fis = c.FieldIndexes()
+
+	if len(fis) == 0 {
+		return // fast path
+	}
+
+	return c.indexRootFields(pack, r, fis)
+}
+
+// add given Root to given field indexes; entries of
+// indexes removed while the Root is walked are not
+// added (declaration is checked inside transaction)
+func (c *Container) indexRootFields(
+	pack registry.Pack,
+	r *registry.Root,
+	fis []FieldIndex,
+) (
+	err error,
+) {
+
+	// Schema -> list of field indexes of the Schema;
+	// the Registry can have not all Schemas, thus we
+	// are using names of Schemas
+
+	var (
+		bySchema = make(map[string][]FieldIndex)
+		names    = make(map[string]string) // bucket -> declaration
+	)
+
+	for _, fi := range fis {
+		if _, err := pack.Registry().SchemaByName(fi.Schema); err != nil {
+			continue // the Registry has not such Schema
+		}
+		bySchema[fi.Schema] = append(bySchema[fi.Schema], fi)
+		names[string(fi.bucket())] = fi.String()
+	}
+
+	if len(bySchema) == 0 {
+		return // nothing to index
+	}
+
+	var (
+		locs []fieldIndexLocation
+		ev   = encoder.Serialize(fieldIndexEntryValue{
+			Feed:  r.Pub,
+			Nonce: r.Nonce,
+			Seq:   r.Seq,
+		})
+	)
+
+	err = walkSchemaRoot(pack, r, func(
+		hash cipher.SHA256,
+		sch registry.Schema,
+		val []byte,
+	) (
+		deepper bool,
+		err error,
+	) {
+
+		deepper = true
+
+		for _, fi := range bySchema[sch.Name()] {
+
+			var fv []byte
+			if fv, err = fieldValue(sch, val, fi.Field); err != nil {
+				return
+			}
+
+			if fv == nil {
+				continue // has not such field
+			}
+
+			locs = append(locs, fieldIndexLocation{
+				Bucket: fi.bucket(),
+				Key:    fieldIndexEntryKey(fv, hash, r.Hash),
+			})
+
+		}
+
+		return
+
+	})
+
+	if err != nil || len(locs) == 0 {
+		return
+	}
+
+	return c.db.IdxDB().BucketsTx(func(bs data.Buckets) (err error) {
+
+		var rev, decl data.Bucket
+		if rev, err = bs.Bucket(fieldIndexRootsBucket); err != nil {
+			return
+		}
+
+		if decl, err = bs.Bucket(fieldIndexesBucket); err != nil {
+			return
+		}
+
+		var declared = make(map[string]bool)
+
+		for bucket, name := range names {
+			switch _, err = decl.Get([]byte(name)); err {
+			case nil:
+				declared[bucket] = true
+			case data.ErrNotFound:
+				err = nil // removed
+			default:
+				return
+			}
+		}
+
+		for _, loc := range locs {
+
+			if declared[string(loc.Bucket)] == false {
+				continue // the index has been removed
+			}
+
+			var bk data.Bucket
+			if bk, err = bs.Bucket(loc.Bucket); err != nil {
+				return
+			}
+
+			if err = bk.Set(loc.Key, ev); err != nil {
+				return
+			}
+
+			var rk = append(r.Hash[:], encoder.Serialize(loc)...)
+
+			if err = rev.Set(rk, []byte{}); err != nil {
+				return
+			}
+
+		}
+
+		return
+	})
+
+}
+
+// unindexRoot removes all entries of given
+// Root from all field indexes
+func (c *Container) unindexRoot(rootHash cipher.SHA256) (err error) {
+
+	if len(c.FieldIndexes()) == 0 {
+		return // fast path
+	}
+
+	return c.db.IdxDB().BucketsTx(func(bs data.Buckets) (err error) {
+
+		var rev data.Bucket
+		if rev, err = bs.Bucket(fieldIndexRootsBucket); err != nil {
+			return
+		}
+
+		return rev.Ascend(rootHash[:], func(key, _ []byte) (err error) {
+
+			var loc fieldIndexLocation
+			_, err = encoder.DeserializeRaw(key[len(rootHash):], &loc)
+			if err != nil {
+				return
+			}
+
+			var bk data.Bucket
+			if bk, err = bs.Bucket(loc.Bucket); err != nil {
+				return
+			}
+
+			if err = bk.Del(loc.Key); err != nil {
+				return
+			}
+
+			return rev.Del(key)
+		})
+
+	})
+
+}
+
+// Lookup returns all objects of Schema with given name, field
+// of which is equal to given value. The value must be of the
+// same type as the field, because the value is compared in
+// its encoded form. The Lookup returns ErrNoSuchFieldIndex
+// if the field index is not declared (see AddFieldIndex)
+func (c *Container) Lookup(
+	schemaName string, //       : name of Schema
+	field string, //            : name of field
+	value interface{}, //       : value of the field
+) (
+	es []FieldIndexEntry, //    : found objects
+	err error, //               : an error
+) {
+	return c.LookupRaw(schemaName, field, encoder.Serialize(value))
+}
+
+// LookupRaw is the same as the Lookup, but
+// it uses encoded value of the field
+func (c *Container) LookupRaw(
+	schemaName string, //       : name of Schema
+	field string, //            : name of field
+	fv []byte, //               : encoded value of the field
+) (
+	es []FieldIndexEntry, //    : found objects
+	err error, //               : an error
+) {
+
+	var fi = FieldIndex{Schema: schemaName, Field: field}
+
+	c.fi.mx.Lock()
+	var _, ok = c.fi.fis[fi]
+	c.fi.mx.Unlock()
+
+	if ok == false {
+		return nil, ErrNoSuchFieldIndex
+	}
+
+	var prefix = fieldIndexValuePrefix(fv)
+
+	err = c.db.IdxDB().BucketsView(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(fi.bucket()); err != nil {
+			return
+		}
+
+		return bk.Ascend(prefix, func(key, val []byte) (err error) {
+
+			var (
+				ev fieldIndexEntryValue
+				fe FieldIndexEntry
+			)
+
+			if _, err = encoder.DeserializeRaw(val, &ev); err != nil {
+				return
+			}
+
+			key = key[len(prefix):]
+
+			copy(fe.Object[:], key)
+			copy(fe.Root[:], key[len(fe.Object):])
+
+			fe.Feed, fe.Nonce, fe.Seq = ev.Feed, ev.Nonce, ev.Seq
+
+			es = append(es, fe)
+			return
+		})
+
+	})
+
+	return
+}
@@ -0,0 +1,157 @@
+package skyobject
+
+import (
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+func TestContainer_AddFieldIndex(t *testing.T) {
+
+	var (
+		sc, rc = getTestContainer(), getTestContainer()
+		pk, sk = cipher.GenerateKeyPair()
+	)
+
+	defer sc.Close()
+	defer rc.Close()
+
+	assertNil(t, sc.AddFeed(pk))
+	assertNil(t, rc.AddFeed(pk))
+
+	var up, err = sc.Unpack(sk, testRegistry)
+	assertNil(t, err)
+
+	var r = new(registry.Root)
+
+	r.Pub = pk
+	r.Nonce = 9021
+
+	var feed = Feed{Head: "feed"}
+
+	assertNil(t, feed.Posts.AppendValues(up,
+		Post{Head: "first", Body: "one"},
+		Post{Head: "second", Body: "two"},
+		Post{Head: "first", Body: "three"},
+	))
+
+	r.Refs = []registry.Dynamic{
+		createDynamic(up, testRegistry, "test.User", &User{"Alice", 19}),
+		createDynamic(up, testRegistry, "test.Feed", &feed),
+	}
+
+	// declared before the Save
+	assertNil(t, sc.AddFieldIndex("test.Post", "Head"))
+	assertNil(t, sc.Save(up, r))
+
+	var es []FieldIndexEntry
+
+	t.Run("lookup", func(t *testing.T) {
+
+		es, err = sc.Lookup("test.Post", "Head", "first")
+		assertNil(t, err)
+
+		if len(es) != 2 {
+			t.Fatal("wrong number of entries:", len(es))
+		}
+
+		for _, e := range es {
+
+			if e.Root != r.Hash || e.Feed != pk || e.Nonce != r.Nonce ||
+				e.Seq != r.Seq {
+
+				t.Error("wrong entry", e)
+			}
+
+			var val, _, err = sc.Get(e.Object, 0)
+			assertNil(t, err)
+
+			var post Post
+			_, err = encoder.DeserializeRaw(val, &post)
+			assertNil(t, err)
+
+			if post.Head != "first" {
+				t.Error("wrong object found", post)
+			}
+
+		}
+
+		if es, err = sc.Lookup("test.Post", "Head", "third"); err != nil {
+			t.Fatal(err)
+		} else if len(es) != 0 {
+			t.Error("unexpected entries", len(es))
+		}
+
+		if _, err = sc.Lookup("test.Post", "Body", "one"); err == nil {
+			t.Error("missing error")
+		} else if err != ErrNoSuchFieldIndex {
+			t.Error("wrong error:", err)
+		}
+
+	})
+
+	t.Run("existing", func(t *testing.T) {
+
+		// declared after the Save
+		assertNil(t, sc.AddFieldIndex("test.User", "Name"))
+
+		es, err = sc.Lookup("test.User", "Name", "Alice")
+		assertNil(t, err)
+
+		if len(es) != 1 {
+			t.Fatal("wrong number of entries:", len(es))
+		}
+
+		if len(sc.FieldIndexes()) != 2 {
+			t.Error("wrong number of field indexes")
+		}
+
+	})
+
+	t.Run("fill", func(t *testing.T) {
+
+		assertNil(t, rc.AddFieldIndex("test.Post", "Head"))
+		testFillRoot(t, sc, rc, r)
+
+		es, err = rc.Lookup("test.Post", "Head", "second")
+		assertNil(t, err)
+
+		if len(es) != 1 {
+			t.Fatal("wrong number of entries:", len(es))
+		}
+
+	})
+
+	t.Run("del root", func(t *testing.T) {
+
+		assertNil(t, sc.DelRoot(r.Pub, r.Nonce, r.Seq))
+
+		es, err = sc.Lookup("test.Post", "Head", "first")
+		assertNil(t, err)
+
+		if len(es) != 0 {
+			t.Fatal("entries of removed Root are not removed:", len(es))
+		}
+
+	})
+
+	t.Run("del field index", func(t *testing.T) {
+
+		assertNil(t, sc.DelFieldIndex("test.User", "Name"))
+
+		if _, err = sc.Lookup("test.User", "Name", "Alice"); err == nil {
+			t.Error("missing error")
+		}
+
+		if err = sc.DelFieldIndex("test.User", "Name"); err == nil {
+			t.Error("missing error")
+		} else if err != ErrNoSuchFieldIndex {
+			t.Error("wrong error:", err)
+		}
+
+	})
+
+}
@@ -21,8 +21,17 @@ type Filler struct {
 
 	mx   sync.Mutex
 	incs map[cipher.SHA256]int
+	hard map[cipher.SHA256]int      // part of the incs found in DB
 	pre  map[cipher.SHA256]struct{} // prerequested by RC
 
+	received int // objects received from peers
+	volume   int // and their volume
+	expect   int // expected elements of Refs (estimation)
+
+	filter  *FillFilter // nil if not filtered
+	partial bool        // some objects skipped by the filter
+	have    bool        // the partially filled Root already exists
+
 	limit chan struct{} // max
 
 	errq chan error
@@ -57,7 +66,9 @@ func (f *Filler) get(
 	if err == nil {
 		if inc > 0 {
 			rc = f.inc(key, rc) // ++
+			f.incHard(key)
 		}
+		f.c.prioritize(f.r.Pub, key)
 		return
 	}
 
@@ -73,9 +84,24 @@ func (f *Filler) get(
 	f.c.Want(key, gc, inc)
 	defer f.c.Unwant(key, gc) // to be memory safe
 
-	// requset the object using the rq channel
-	if f.requset(key) == false {
-		return
+	// an object of partially filled Root the DB
+	// already has, otherwise requset the object
+	// using the rq channel
+
+	var pval []byte
+
+	switch pval, err = f.c.getPartial(key); err {
+	case nil:
+		if _, err = f.c.SetWanted(key, pval); err != nil {
+			fatal("DB failure:", err) // fatality
+		}
+	case data.ErrNotFound:
+		err = nil
+		if f.requset(key) == false {
+			return
+		}
+	default:
+		fatal("DB failure:", err) // fatality
 	}
 
 	select {
@@ -89,6 +115,8 @@ func (f *Filler) get(
 		} else {
 			rc = obj.RC
 		}
+		f.receive(len(val))
+		f.c.prioritize(f.r.Pub, key)
 	case <-f.closeq:
 		err = ErrTerminated
 	}
@@ -135,6 +163,51 @@ func (f *Filler) Get(key cipher.SHA256) (val []byte, rc int, err error) {
 	return
 }
 
+// Expect implements registry.Estimator interface
+func (f *Filler) Expect(n int) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	f.expect += n
+}
+
+// A FillProgress represents progress of a Filler
+type FillProgress struct {
+	Received  int // objects received from peers
+	Volume    int // volume of the received objects
+	Known     int // objects of the Root known so far
+	Estimated int // estimated total number of objects
+}
+
+// Progress of the Filler. The Estimated is Known plus
+// elements of Refs that are not reached yet. Thus, it
+// grows while the Filler walks the Root and can be
+// greater than real number of objects if some
+// subtrees are already stored
+func (f *Filler) Progress() (fp FillProgress) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	fp.Received = f.received
+	fp.Volume = f.volume
+	fp.Known = len(f.incs)
+	fp.Estimated = fp.Known
+
+	if f.expect > 0 {
+		fp.Estimated += f.expect
+	}
+
+	return
+}
+
+// Invalid implements registry.Checker interface. It
+// fails the Filler with *InvalidObjectError. Constraints
+// of objects are checked while filling, and an invalid
+// object breaks the filling before its subtree requested
+func (f *Filler) Invalid(hash cipher.SHA256, err error) {
+	f.Fail(&InvalidObjectError{hash, err})
+}
+
 // Fail used to terminate the Filler with
 // provided error
 func (f *Filler) Fail(err error) {
@@ -168,6 +241,24 @@ func (f *Filler) inc(key cipher.SHA256, drc int) (rc int) {
 	return
 }
 
+// the inc of the inc method is hard, e.g. the
+// object has been found in DB and incremented
+func (f *Filler) incHard(key cipher.SHA256) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	f.hard[key]++
+}
+
+// object received from peers
+func (f *Filler) receive(size int) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	f.received++
+	f.volume += size
+}
+
 func (f *Filler) requset(key cipher.SHA256) (ok bool) {
 
 	select {
@@ -191,7 +282,12 @@ func (f *Filler) Close() {
 // Root object. To request objects, the DB doesn't
 // have, given rq channel used. The Fill used by
 // the node package to fill Root objects. The filler
-// must be closed after using
+// must be closed after using.
+//
+// If the Filler breaks, then it stores the Root and
+// received objects to resume the filling, and the
+// objects are reused by next Filler of the Root
+// (see Filling)
 func (c *Container) Fill(
 	r *registry.Root, //        : the Root to fill
 	rq chan<- cipher.SHA256, // : request object from peers
@@ -207,6 +303,7 @@ func (c *Container) Fill(
 
 	f.rq = rq
 	f.incs = make(map[cipher.SHA256]int)
+	f.hard = make(map[cipher.SHA256]int)
 	f.pre = make(map[cipher.SHA256]struct{})
 
 	if maxParall > 0 {
@@ -227,6 +324,33 @@ func (f *Filler) apply() {
 	}
 }
 
+// objects received (or touched) by the Filler
+func (f *Filler) keys() (keys []cipher.SHA256) {
+
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	keys = make([]cipher.SHA256, 0, len(f.incs))
+	for key := range f.incs {
+		keys = append(keys, key)
+	}
+
+	return
+}
+
+// keep received objects of interrupted filling
+func (f *Filler) keep() (err error) {
+	return f.c.saveFilling(f.r, f.keys())
+}
+
+// is the Root partially filled
+func (f *Filler) isPartial() (ok bool) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	return f.partial
+}
+
 func (f *Filler) reject() {
 	for key, inc := range f.incs {
 		if err := f.c.Finc(key, -inc); err != nil {
@@ -235,6 +359,47 @@ func (f *Filler) reject() {
 	}
 }
 
+// apply incs of the Root, its Registry and envelope, and
+// reject incs of other objects, including hard; the other
+// objects are kept by the partially filled Root in the
+// partialBucket (see addPartialRoot); if the Container
+// already has the Root, then all incs are rejected
+func (f *Filler) applyPartial() {
+
+	var keep = make(map[cipher.SHA256]struct{})
+	if f.have == false {
+		for _, key := range partialRootKeys(f.r) {
+			keep[key] = struct{}{}
+		}
+	}
+
+	for key, inc := range f.incs {
+
+		if _, ok := keep[key]; ok == true {
+			if err := f.c.Finc(key, inc); err != nil {
+				panic("DB failure: " + err.Error()) // TODO: handle the error
+			}
+			continue
+		}
+
+		var hard = f.hard[key]
+
+		if inc > hard {
+			if err := f.c.Finc(key, hard-inc); err != nil {
+				panic("DB failure: " + err.Error()) // TODO: handle the error
+			}
+		}
+
+		if hard > 0 {
+			if _, err := f.c.Inc(key, -hard); err != nil {
+				panic("DB failure: " + err.Error()) // TODO: handle the error
+			}
+		}
+
+	}
+
+}
+
 func (f *Filler) acquire() (parall bool) {
 
 	if f.limit == nil { // no limit
@@ -287,11 +452,23 @@ func (f *Filler) Run() (err error) {
 	}
 
 	f.inc(f.r.Hash, 0) // increment
+	f.incHard(f.r.Hash)
+
+	var interrupted bool // break, but not invalid Root
+
+	// a filled Root removes its Filling (see AddRoot)
 
 	defer func() {
 		if err != nil {
 			f.r.IsFull = false // reset
+			if interrupted == true {
+				f.keep() // resume later (ignore error)
+			} else {
+				f.c.DelFilling(f.r.Pub, f.r.Nonce, f.r.Seq) // ignore error
+			}
 			f.reject()
+		} else if f.r.IsPartial == true {
+			f.applyPartial() // kept by the Root, not by rc
 		} else {
 			f.apply()
 		}
@@ -301,12 +478,36 @@ func (f *Filler) Run() (err error) {
 		return
 	}
 
-	for _, dr := range f.r.Refs {
+	if f.r.IsEncrypted() == true {
+
+		// envelope
+		if _, _, err = f.Get(f.r.Envelope); err != nil {
+			return
+		}
+
+		// walk links of encrypted objects
+		for _, dr := range f.r.Refs {
+			func(hash cipher.SHA256) {
+				f.Go(func() { splitSealedHash(f, hash) })
+			}(dr.Hash)
+		}
+
+	} else {
+
+		var s registry.Splitter = f
+
+		if f.filter != nil {
+			s = filterFiller{f} // skip filtered objects
+		}
+
+		for _, dr := range f.r.Refs {
 
-		// the closure is data-race protection
-		func(dr registry.Dynamic) {
-			f.Go(func() { dr.Split(f) })
-		}(dr)
+			// the closure is data-race protection
+			func(dr registry.Dynamic) {
+				f.Go(func() { dr.Split(s) })
+			}(dr)
+
+		}
 
 	}
 
@@ -319,9 +520,21 @@ func (f *Filler) Run() (err error) {
 
 	select {
 	case err = <-f.errq:
+		_, invalid := err.(*InvalidObjectError)
+		interrupted = !invalid // don't resume invalid Root
 	case <-done:
-		f.r.IsFull = true // full!
-		_, err = f.c.AddRoot(f.r)
+		select {
+		case <-f.closeq:
+			err, interrupted = ErrTerminated, true // closed, not filled
+		default:
+			f.r.IsFull = true // full!
+			if f.isPartial() == true {
+				f.r.IsPartial = true
+				err = f.addPartialRoot()
+			} else {
+				err = f.addRoot()
+			}
+		}
 	}
 
 	f.Close()
@@ -329,6 +542,114 @@ func (f *Filler) Run() (err error) {
 	return
 }
 
+// is the object received (or touched) by this Filler
+func (f *Filler) isFilled(key cipher.SHA256) (ok bool) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	_, ok = f.incs[key]
+	return
+}
+
+// add filled Root to Index updating field indexes;
+// constraints of objects are checked while filling,
+// but objects of encrypted Root are sealed, and they
+// are checked here if the Container has key; the
+// constraints and field indexes of encrypted Root
+// are skipped if the Container doesn't have the key
+func (f *Filler) addRoot() (err error) {
+
+	var pack *Pack
+	if pack, err = f.c.rootPack(f.c.getPack(f.reg), f.r); err != nil {
+		return
+	}
+
+	if pack == nil {
+		_, err = f.c.AddRoot(f.r) // can't decrypt
+		return
+	}
+
+	if f.r.IsEncrypted() == true {
+		if err = checkRoot(pack, f.r, f.isFilled); err != nil {
+			return
+		}
+	}
+
+	if err = f.c.indexRoot(pack, f.r); err != nil {
+		return
+	}
+
+	if _, err = f.c.AddRoot(f.r); err != nil {
+		f.c.unindexRoot(f.r.Hash) // ignore error
+		return
+	}
+
+	f.dropPartial() // ignore error (filled fully later)
+	return
+}
+
+// the Root has been partially filled before and now it's
+// full; drop the partially filled Root releasing the Root,
+// its Registry and envelope it holds, since the full Root
+// holds them and all its objects by rc
+func (f *Filler) dropPartial() (err error) {
+
+	var ok bool
+	if ok, err = f.c.dropPartial(f.r.Hash); err != nil || ok == false {
+		return
+	}
+
+	for _, key := range partialRootKeys(f.r) {
+		if _, err = f.c.Inc(key, -1); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// add partially filled Root to Index; field indexes
+// are not updated, since some objects are missing;
+// objects of the Root are copied to the partialBucket,
+// since they can't hold rc of the CXDS (see FillFilter)
+func (f *Filler) addPartialRoot() (err error) {
+
+	var (
+		objs = make(map[cipher.SHA256][]byte)
+		keep = make(map[cipher.SHA256]struct{})
+	)
+
+	for _, key := range partialRootKeys(f.r) {
+		keep[key] = struct{}{}
+	}
+
+	for _, key := range f.keys() {
+		if _, ok := keep[key]; ok == true {
+			continue
+		}
+		if objs[key], _, err = f.c.Get(key, 0); err != nil {
+			return
+		}
+	}
+
+	var was bool // already partial
+	if was, err = f.c.isPartial(f.r.Hash); err != nil {
+		return
+	}
+
+	if err = f.c.savePartial(f.r.Hash, objs); err != nil {
+		return
+	}
+
+	if f.have, err = f.c.AddRoot(f.r); err != nil || f.have == true {
+		if was == false {
+			f.c.dropPartial(f.r.Hash) // ignore error
+		}
+	}
+
+	return
+}
+
 func (f *Filler) getRegistry() (err error) {
 
 	if f.r.Reg == (registry.RegistryRef{}) {
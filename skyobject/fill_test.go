@@ -245,3 +245,85 @@ func testFillDBs(t *testing.T, sc, rc *Container) {
 		t.FailNow()
 	}
 }
+
+func TestFiller_Progress(t *testing.T) {
+
+	var (
+		sc, rc = getTestContainer(), getTestContainer()
+		pk, sk = cipher.GenerateKeyPair()
+	)
+
+	defer sc.Close()
+	defer rc.Close()
+
+	assertNil(t, sc.AddFeed(pk))
+	assertNil(t, rc.AddFeed(pk))
+
+	var up, err = sc.Unpack(sk, testRegistry)
+	assertNil(t, err)
+	defer up.Close()
+
+	var feed = Feed{Head: "feed"}
+
+	for i := 0; i < 50; i++ {
+		assertNil(t, feed.Posts.AppendValues(up, Post{
+			Head: fmt.Sprintf("Head #%d", i),
+		}))
+	}
+
+	var r = new(registry.Root)
+
+	r.Pub = pk
+	r.Nonce = 1
+	r.Refs = []registry.Dynamic{
+		createDynamic(up, testRegistry, "test.Feed", &feed),
+	}
+
+	assertNil(t, sc.Save(up, r))
+
+	var (
+		rq = make(chan cipher.SHA256, 10)
+		f  = rc.Fill(r, rq, 10)
+		wg sync.WaitGroup
+
+		received, volume int
+	)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for key := range rq {
+			var val, _, err = sc.Get(key, 0)
+			assertNil(t, err)
+
+			_, err = rc.SetWanted(key, val)
+			assertNil(t, err)
+
+			received++
+			volume += len(val)
+		}
+	}()
+
+	assertNil(t, f.Run())
+
+	close(rq)
+	wg.Wait()
+
+	var fp = f.Progress()
+
+	if fp.Received != received || fp.Volume != volume {
+		t.Errorf("wrong received %d (%d bytes), expected %d (%d bytes)",
+			fp.Received, fp.Volume, received, volume)
+	}
+
+	// all posts, the Refs, the Feed, the Registry and the Root
+	if fp.Known < 50+4 {
+		t.Error("wrong known", fp.Known)
+	}
+
+	if fp.Estimated != fp.Known {
+		t.Error("wrong estimation", fp.Estimated, fp.Known)
+	}
+
+}
@@ -0,0 +1,307 @@
+package skyobject
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+
+	"github.com/skycoin/cxo/data"
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+// interrupted fillings
+var fillingsBucket = []byte("skyobject.fillings")
+
+// A Filling represents interrupted filling of a Root.
+// A Filler stores the Root and received objects when
+// it breaks. Objects of a Filling are not removed by
+// cleaning (see cxoutils package), and a next Filler
+// of the Root reuses them instead of requesting again.
+// A Filling is removed when the Root or a newer Root
+// of its head is filled, when its head or feed is
+// deleted, or by DelFilling. A Filling expires if it's
+// not updated during the FillingTTL (see Config), and
+// expired Fillings are removed by DelExpiredFillings
+type Filling struct {
+	Root    *registry.Root // the Root to fill
+	Objects int            // objects received so far
+	Time    int64          // last update (unix nano)
+}
+
+// stored Filling
+type filling struct {
+	Root  []byte // encoded
+	Sig   cipher.Sig
+	Stamp uint64
+	Keys  []cipher.SHA256 // received objects
+	Time  int64
+}
+
+func (f *filling) decode() (fl *Filling, err error) {
+
+	var r *registry.Root
+	if r, err = registry.DecodeRoot(f.Root); err != nil {
+		return
+	}
+
+	r.Hash = cipher.SumSHA256(f.Root)
+	r.Sig = f.Sig
+	r.Stamp = f.Stamp
+
+	fl = &Filling{Root: r, Objects: len(f.Keys), Time: f.Time}
+	return
+}
+
+// is the filling expired at given time (unix nano)
+func (f *filling) isExpired(ttl time.Duration, now int64) bool {
+	return ttl > 0 && now-f.Time > int64(ttl)
+}
+
+func fillingKey(pk cipher.PubKey, nonce, seq uint64) (key []byte) {
+	key = make([]byte, len(pk)+8+8)
+	copy(key, pk[:])
+	binary.BigEndian.PutUint64(key[len(pk):], nonce)
+	binary.BigEndian.PutUint64(key[len(pk)+8:], seq)
+	return
+}
+
+// save or update Filling of given Root, the
+// keys are merged with stored ones
+func (c *Container) saveFilling(
+	r *registry.Root, //      : the Root
+	keys []cipher.SHA256, //  : received objects
+) (
+	err error, //             : an error
+) {
+
+	return c.db.IdxDB().BucketsTx(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(fillingsBucket); err != nil {
+			return
+		}
+
+		var (
+			key = fillingKey(r.Pub, r.Nonce, r.Seq)
+			f   filling
+			val []byte
+		)
+
+		switch val, err = bk.Get(key); err {
+		case nil:
+			if _, err = encoder.DeserializeRaw(val, &f); err != nil {
+				return
+			}
+		case data.ErrNotFound:
+			f.Root, f.Sig, f.Stamp = r.Encode(), r.Sig, r.Stamp
+		default:
+			return
+		}
+
+		if len(keys) > 0 {
+
+			var has = make(map[cipher.SHA256]struct{}, len(f.Keys))
+
+			for _, k := range f.Keys {
+				has[k] = struct{}{}
+			}
+
+			for _, k := range keys {
+				if _, ok := has[k]; ok == false {
+					f.Keys = append(f.Keys, k)
+				}
+			}
+
+		}
+
+		f.Time = time.Now().UnixNano()
+
+		return bk.Set(key, encoder.Serialize(&f))
+
+	})
+
+}
+
+// delete Fillings with keys of given prefix,
+// Fillings of a head with seq greater than
+// given are kept if the head is true
+func (c *Container) delFillings(
+	prefix []byte, // : prefix
+	head bool, //     : the prefix is feed + nonce
+	seq uint64, //    : max seq to delete
+) (
+	err error, //     : an error
+) {
+
+	return c.db.IdxDB().BucketsTx(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(fillingsBucket); err != nil {
+			return
+		}
+
+		return bk.Ascend(prefix, func(key, _ []byte) (err error) {
+			if head == true && binary.BigEndian.Uint64(key[len(key)-8:]) > seq {
+				return data.ErrStopIteration // ordered
+			}
+			return bk.Del(key)
+		})
+
+	})
+
+}
+
+// delete Fillings of given head up to given seq
+func (c *Container) delHeadFillings(
+	pk cipher.PubKey,
+	nonce uint64,
+	seq uint64,
+) error {
+	var prefix = fillingKey(pk, nonce, 0)
+	return c.delFillings(prefix[:len(pk)+8], true, seq)
+}
+
+// DelExpiredFillings removes expired Fillings (see
+// FillingTTL of Config). Cleaning code should call it
+// before removing objects
+func (c *Container) DelExpiredFillings() (err error) {
+
+	var ttl = c.conf.FillingTTL
+
+	if ttl <= 0 {
+		return // never expire
+	}
+
+	var now = time.Now().UnixNano()
+
+	return c.db.IdxDB().BucketsTx(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(fillingsBucket); err != nil {
+			return
+		}
+
+		return bk.Ascend(nil, func(key, val []byte) (err error) {
+
+			var f filling
+			if _, err = encoder.DeserializeRaw(val, &f); err != nil {
+				return
+			}
+
+			if f.isExpired(ttl, now) == true {
+				return bk.Del(key)
+			}
+
+			return
+
+		})
+
+	})
+
+}
+
+// DelFilling removes Filling of Root with given
+// feed, nonce and seq. Objects of the Filling are
+// not protected against cleaning anymore
+func (c *Container) DelFilling(pk cipher.PubKey, nonce, seq uint64) error {
+	return c.delFillings(fillingKey(pk, nonce, seq), false, 0)
+}
+
+// Fillings returns interrupted fillings of given feed,
+// expired are skipped. Use blank feed to get all Fillings
+func (c *Container) Fillings(pk cipher.PubKey) (fs []*Filling, err error) {
+
+	var (
+		prefix []byte
+
+		ttl = c.conf.FillingTTL
+		now = time.Now().UnixNano()
+	)
+
+	if pk != (cipher.PubKey{}) {
+		prefix = pk[:]
+	}
+
+	err = c.db.IdxDB().BucketsView(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(fillingsBucket); err != nil {
+			return
+		}
+
+		return bk.Ascend(prefix, func(_, val []byte) (err error) {
+
+			var f filling
+			if _, err = encoder.DeserializeRaw(val, &f); err != nil {
+				return
+			}
+
+			if f.isExpired(ttl, now) == true {
+				return
+			}
+
+			var fl *Filling
+			if fl, err = f.decode(); err != nil {
+				return
+			}
+
+			fs = append(fs, fl)
+			return
+
+		})
+
+	})
+
+	return
+}
+
+// FillingObjects returns set of objects received by
+// interrupted fillings, expired are skipped. Cleaning
+// code should keep the objects even if they are not
+// referenced
+func (c *Container) FillingObjects() (
+	keys map[cipher.SHA256]struct{},
+	err error,
+) {
+
+	var (
+		ttl = c.conf.FillingTTL
+		now = time.Now().UnixNano()
+	)
+
+	err = c.db.IdxDB().BucketsView(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(fillingsBucket); err != nil {
+			return
+		}
+
+		return bk.Ascend(nil, func(_, val []byte) (err error) {
+
+			var f filling
+			if _, err = encoder.DeserializeRaw(val, &f); err != nil {
+				return
+			}
+
+			if f.isExpired(ttl, now) == true {
+				return
+			}
+
+			if keys == nil && len(f.Keys) > 0 {
+				keys = make(map[cipher.SHA256]struct{}, len(f.Keys))
+			}
+
+			for _, k := range f.Keys {
+				keys[k] = struct{}{}
+			}
+
+			return
+
+		})
+
+	})
+
+	return
+}
@@ -0,0 +1,212 @@
+package skyobject
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+// fill Root serving max objects, if the max is
+// negative, then all objects are served
+func testFillMax(
+	sc, rc *Container,
+	r *registry.Root,
+	max int,
+) (
+	err error,
+) {
+	return testFillFilter(sc, rc, r, max, nil)
+}
+
+// fill Root serving max objects using given filter
+func testFillFilter(
+	sc, rc *Container,
+	r *registry.Root,
+	max int,
+	filter *FillFilter,
+) (
+	err error,
+) {
+
+	var (
+		rq = make(chan cipher.SHA256, 10)
+		f  = rc.FillPartial(r, rq, 10, filter)
+		wg sync.WaitGroup
+	)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for key := range rq {
+
+			if max == 0 {
+				f.Fail(errors.New("interrupted"))
+				continue
+			}
+
+			max--
+
+			var val, _, err = sc.Get(key, 0)
+			if err != nil {
+				panic(err)
+			}
+
+			if _, err = rc.SetWanted(key, val); err != nil {
+				panic(err)
+			}
+		}
+
+	}()
+
+	err = f.Run()
+
+	close(rq)
+	wg.Wait()
+
+	return
+}
+
+func TestContainer_Fillings(t *testing.T) {
+
+	var (
+		sc, rc = getTestContainer(), getTestContainer()
+		pk, sk = cipher.GenerateKeyPair()
+	)
+
+	defer sc.Close()
+	defer rc.Close()
+
+	assertNil(t, sc.AddFeed(pk))
+	assertNil(t, rc.AddFeed(pk))
+
+	var up, err = sc.Unpack(sk, testRegistry)
+	assertNil(t, err)
+	defer up.Close()
+
+	var r = new(registry.Root)
+
+	r.Pub = pk
+	r.Nonce = 1
+	r.Refs = []registry.Dynamic{
+		createDynamic(up, testRegistry, "test.User", &User{"Alice", 19}),
+	}
+
+	assertNil(t, sc.Save(up, r))
+
+	// only the Registry
+	if err = testFillMax(sc, rc, r, 1); err == nil {
+		t.Fatal("not interrupted")
+	}
+
+	var fs []*Filling
+	if fs, err = rc.Fillings(pk); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fs) != 1 {
+		t.Fatal("wrong number of fillings", len(fs))
+	}
+
+	if fs[0].Root.Hash != r.Hash || fs[0].Root.Sig != r.Sig {
+		t.Error("wrong Root")
+	}
+
+	if fs[0].Objects < 2 || fs[0].Time == 0 {
+		t.Error("wrong progress", fs[0].Objects)
+	}
+
+	var fos map[cipher.SHA256]struct{}
+	if fos, err = rc.FillingObjects(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := fos[cipher.SHA256(r.Reg)]; ok == false {
+		t.Error("missing object of the filling")
+	}
+
+	// resume
+	assertNil(t, testFillMax(sc, rc, fs[0].Root, -1))
+
+	if fs, err = rc.Fillings(cipher.PubKey{}); err != nil {
+		t.Fatal(err)
+	} else if len(fs) != 0 {
+		t.Error("filling is not removed")
+	}
+
+	t.Run("expired", func(t *testing.T) {
+
+		var r = new(registry.Root)
+
+		r.Pub = pk
+		r.Nonce = 2
+		r.Refs = []registry.Dynamic{
+			createDynamic(up, testRegistry, "test.User", &User{"Eva", 21}),
+		}
+
+		assertNil(t, sc.Save(up, r))
+
+		if err = testFillMax(sc, rc, r, 0); err == nil {
+			t.Fatal("not interrupted")
+		}
+
+		if fs, err = rc.Fillings(pk); err != nil {
+			t.Fatal(err)
+		} else if len(fs) != 1 {
+			t.Fatal("wrong number of fillings", len(fs))
+		}
+
+		defer func(ttl time.Duration) { rc.conf.FillingTTL = ttl }(
+			rc.conf.FillingTTL)
+
+		rc.conf.FillingTTL = time.Millisecond
+		time.Sleep(2 * time.Millisecond)
+
+		if fs, err = rc.Fillings(pk); err != nil {
+			t.Fatal(err)
+		} else if len(fs) != 0 {
+			t.Error("expired filling", len(fs))
+		}
+
+		if fos, err = rc.FillingObjects(); err != nil {
+			t.Fatal(err)
+		} else if len(fos) != 0 {
+			t.Error("objects of expired filling are kept", len(fos))
+		}
+
+		assertNil(t, rc.DelExpiredFillings())
+
+		rc.conf.FillingTTL = 0 // forever
+
+		if fs, err = rc.Fillings(pk); err != nil {
+			t.Fatal(err)
+		} else if len(fs) != 0 {
+			t.Error("expired filling is not removed", len(fs))
+		}
+
+	})
+
+	t.Run("delete", func(t *testing.T) {
+
+		assertNil(t, sc.Save(up, r)) // next seq
+
+		assertNil(t, rc.saveFilling(r, nil))
+		assertNil(t, rc.DelFilling(pk, r.Nonce, r.Seq))
+
+		assertNil(t, rc.saveFilling(r, nil))
+		assertNil(t, rc.DelFeed(pk))
+
+		if fs, err = rc.Fillings(pk); err != nil {
+			t.Fatal(err)
+		} else if len(fs) != 0 {
+			t.Error("filling is not removed")
+		}
+
+	})
+
+}
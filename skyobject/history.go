@@ -0,0 +1,313 @@
+package skyobject
+
+import (
+	"sync"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/data"
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+// A HistoryFunc used to iterate over Roots of a head
+// from newest to oldest. Use ErrStopIteration to
+// stop the iteration
+type HistoryFunc func(r *registry.Root) (err error)
+
+// History iterates over Roots of given head from
+// newest to oldest following Prev field of every
+// Root. The iteration stops if the Prev is blank
+// or if previous Root has been removed. Thus, if
+// some Roots of the head removed, then History
+// returns only part of them (newer then first
+// removed). Use ErrStopIteration to stop the
+// iteration. Roots can be removed during the
+// iteration, use HistoryPack to hold a Root
+func (c *Container) History(
+	pk cipher.PubKey, //          : feed
+	nonce uint64, //              : head
+	historyFunc HistoryFunc, //   : the function
+) (
+	err error, //                 : an error
+) {
+
+	var r *registry.Root
+	if r, err = c.LastRoot(pk, nonce); err != nil {
+		return
+	}
+
+	for {
+
+		if err = historyFunc(r); err != nil {
+			if err == data.ErrStopIteration {
+				err = nil
+			}
+			return
+		}
+
+		if r.Prev == (cipher.SHA256{}) {
+			return // the first Root
+		}
+
+		if r, err = c.prevRoot(r); err != nil {
+			if err == data.ErrNotFound {
+				err = nil // removed
+			}
+			return
+		}
+
+	}
+
+}
+
+// previous Root of given Root if it exists
+func (c *Container) prevRoot(
+	r *registry.Root,
+) (
+	prev *registry.Root,
+	err error,
+) {
+
+	return c.savedRoot(r.Prev)
+}
+
+// saved Root by hash; it returns data.ErrNotFound if
+// the Root has been removed (its object can be kept
+// in CXDS until the cxoutils.RemoveObjects)
+func (c *Container) savedRoot(
+	hash cipher.SHA256,
+) (
+	r *registry.Root,
+	err error,
+) {
+
+	if r, err = c.rootByHash(hash); err != nil {
+		return
+	}
+
+	var dr *data.Root
+	if dr, err = c.dataRootLock(r.Pub, r.Nonce, r.Seq); err != nil {
+		return
+	}
+
+	if dr.Hash != r.Hash {
+		return nil, data.ErrNotFound // replaced (can't be, but for sure)
+	}
+
+	r.Sig = dr.Sig
+	r.IsFull = true
+
+	return
+}
+
+// RootAt returns Root of given head that was
+// newest at given time. E.g. the Root with
+// greatest Time that is not after given. It
+// returns data.ErrNotFound if all Roots of the
+// head are newer or the head is empty
+func (c *Container) RootAt(
+	pk cipher.PubKey, //  : feed
+	nonce uint64, //      : head
+	t time.Time, //       : the time
+) (
+	r *registry.Root, //  : the Root
+	err error, //         : an error
+) {
+
+	var (
+		tn    = t.UnixNano()
+		found *data.Root
+	)
+
+	err = c.db.IdxDB().Tx(func(feeds data.Feeds) (err error) {
+
+		var hs data.Heads
+		if hs, err = feeds.Heads(pk); err != nil {
+			return
+		}
+
+		var rs data.Roots
+		if rs, err = hs.Roots(nonce); err != nil {
+			return
+		}
+
+		return rs.Descend(func(dr *data.Root) (err error) {
+			if dr.Time <= tn {
+				found = dr
+				return data.ErrStopIteration
+			}
+			return
+		})
+
+	})
+
+	if err != nil {
+		return
+	}
+
+	if found == nil {
+		return nil, data.ErrNotFound
+	}
+
+	if r, err = c.rootByHash(found.Hash); err != nil {
+		return
+	}
+
+	r.Sig = found.Sig
+	r.IsFull = true
+
+	return
+}
+
+// A HistoryPack is read-only Pack pinned to a Root.
+// Objects of the Root can't be removed while the
+// HistoryPack is not closed, even if the Root
+// removed. The Set and Add methods of the
+// HistoryPack returns ErrReadOnlyPack. The
+// HistoryPack must be closed after use
+type HistoryPack struct {
+	*Pack
+	r      *registry.Root
+	closeo sync.Once
+}
+
+// Root returns the Root of the HistoryPack
+func (h *HistoryPack) Root() (r *registry.Root) {
+	return h.r
+}
+
+// Set returns ErrReadOnlyPack
+func (h *HistoryPack) Set(cipher.SHA256, []byte) error {
+	return ErrReadOnlyPack
+}
+
+// Add returns ErrReadOnlyPack
+func (h *HistoryPack) Add([]byte) (cipher.SHA256, error) {
+	return cipher.SHA256{}, ErrReadOnlyPack
+}
+
+// Close the HistoryPack releasing the Root. If
+// the Root has been removed, then the Close
+// removes its objects. It's safe to call the
+// Close many times
+func (h *HistoryPack) Close() (err error) {
+	h.closeo.Do(func() {
+		err = h.c.releaseRoot(h.r)
+	})
+	return
+}
+
+// HistoryPack returns read-only Pack pinned to given
+// Root. The Root must exist in the Container. Use
+// Root, RootAt or History to get historical Root
+func (c *Container) HistoryPack(
+	r *registry.Root,
+) (
+	hp *HistoryPack,
+	err error,
+) {
+
+	var pack *Pack
+	if pack, err = c.Pack(r, nil); err != nil {
+		return // including ErrNoContentKey
+	}
+
+	if err = c.pinRoot(r); err != nil {
+		return
+	}
+
+	hp = &HistoryPack{
+		Pack: pack,
+		r:    r,
+	}
+
+	return
+}
+
+// pinRoot increments Root and all objects the Root
+// refers to directly, making them referenced twice;
+// thus if the Root will be removed, then the objects
+// will be kept; the releaseRoot used to release them
+func (i *Index) pinRoot(r *registry.Root) (err error) {
+
+	defer i.lockFeed(r.Pub)()
+
+	// the Root can't be removed under the lock
+
+	var dr *data.Root
+	if dr, err = i.dataRoot(r.Pub, r.Nonce, r.Seq); err != nil {
+		return
+	}
+
+	if dr.Hash != r.Hash {
+		return data.ErrNotFound
+	}
+
+	var hs = partialRootKeys(r)
+
+	if r.IsPartial == true {
+
+		// other objects of partially filled Root are
+		// kept by the Root (see FillFilter)
+
+		if err = i.c.incPartial(r.Hash, 1); err != nil {
+			return
+		}
+
+	} else {
+
+		for _, dr := range r.Refs {
+			if dr.Hash != (cipher.SHA256{}) {
+				hs = append(hs, dr.Hash)
+			}
+		}
+
+	}
+
+	for k, hash := range hs {
+		if _, err = i.c.Inc(hash, 1); err != nil {
+			for _, inced := range hs[:k] {
+				i.c.Inc(inced, -1) // rollback (ignore error)
+			}
+			if r.IsPartial == true {
+				i.c.delPartial(r.Hash) // rollback (ignore error)
+			}
+			return
+		}
+	}
+
+	return
+}
+
+// releaseRoot decrements objects incremented by the
+// pinRoot; if the Root has been removed, then objects
+// that are not used anymore are removed too
+func (i *Index) releaseRoot(r *registry.Root) (err error) {
+
+	defer i.lockFeed(r.Pub)()
+
+	if r.IsPartial == true {
+
+		// the partially filled Root can be filled
+		// fully meanwhile, then the delPartial does
+		// nothing, since the Root is full
+
+		return i.delPartialRoot(r)
+	}
+
+	// the deleting walk decrements the Root, the Registry
+	// and all objects the Root refers to directly, and goes
+	// deepper only if rc of an object turns to zero
+
+	var (
+		pack     registry.Pack
+		walkFunc registry.WalkFunc
+	)
+
+	if pack, walkFunc, err = i.delPackWalkFunc(r); err != nil {
+		return
+	}
+
+	return i.c.walkRoot(pack, r, walkFunc)
+}
@@ -0,0 +1,201 @@
+package skyobject
+
+import (
+	"testing"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/data"
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+func TestContainer_History(t *testing.T) {
+
+	var (
+		c      = getTestContainer()
+		pk, sk = cipher.GenerateKeyPair()
+	)
+
+	defer c.Close()
+
+	assertNil(t, c.AddFeed(pk))
+
+	var (
+		rs    []*registry.Root // saved
+		times []time.Time      // before every Save
+	)
+
+	for i, name := range []string{"Alice", "Eva", "Ammy"} {
+
+		var up, err = c.Unpack(sk, testRegistry)
+		assertNil(t, err)
+
+		var r = new(registry.Root)
+
+		r.Pub = pk
+		r.Nonce = 1
+		r.Refs = []registry.Dynamic{
+			createDynamic(up, testRegistry, "test.User",
+				&User{name, uint32(20 + i)}),
+		}
+
+		times = append(times, time.Now())
+		time.Sleep(10 * time.Millisecond)
+
+		assertNil(t, c.Save(up, r))
+		rs = append(rs, r)
+
+	}
+
+	t.Run("history", func(t *testing.T) {
+
+		var seqs []uint64
+
+		assertNil(t, c.History(pk, 1, func(r *registry.Root) error {
+			seqs = append(seqs, r.Seq)
+			return nil
+		}))
+
+		if len(seqs) != 3 || seqs[0] != 2 || seqs[1] != 1 || seqs[2] != 0 {
+			t.Error("wrong history:", seqs)
+		}
+
+		seqs = seqs[:0]
+
+		assertNil(t, c.History(pk, 1, func(r *registry.Root) error {
+			seqs = append(seqs, r.Seq)
+			return data.ErrStopIteration
+		}))
+
+		if len(seqs) != 1 {
+			t.Error("can't stop iteration:", seqs)
+		}
+
+	})
+
+	t.Run("root at", func(t *testing.T) {
+
+		if _, err := c.RootAt(pk, 1, times[0]); err != data.ErrNotFound {
+			t.Error("wrong error:", err)
+		}
+
+		for i := 1; i < len(times); i++ {
+
+			var r, err = c.RootAt(pk, 1, times[i])
+			assertNil(t, err)
+
+			if r.Hash != rs[i-1].Hash {
+				t.Errorf("wrong Root %d, want %d", r.Seq, rs[i-1].Seq)
+			}
+
+		}
+
+		var r, err = c.RootAt(pk, 1, time.Now())
+		assertNil(t, err)
+
+		if r.Hash != rs[2].Hash || r.Sig != rs[2].Sig {
+			t.Error("wrong Root", r.Seq)
+		}
+
+	})
+
+	t.Run("release", func(t *testing.T) {
+
+		var (
+			r  = rs[2]
+			hs = []cipher.SHA256{
+				r.Hash,
+				cipher.SHA256(r.Reg),
+				r.Refs[0].Hash,
+			}
+			rcs = make([]int, len(hs))
+			err error
+		)
+
+		for k, hash := range hs {
+			_, rcs[k], err = c.Get(hash, 0)
+			assertNil(t, err)
+		}
+
+		var hp *HistoryPack
+		if hp, err = c.HistoryPack(r); err != nil {
+			t.Fatal(err)
+		}
+
+		assertNil(t, hp.Close())
+
+		for k, hash := range hs {
+			var rc int
+			_, rc, err = c.Get(hash, 0)
+			assertNil(t, err)
+
+			if rc != rcs[k] {
+				t.Errorf("wrong rc of released object: %d, want %d", rc, rcs[k])
+			}
+		}
+
+	})
+
+	t.Run("history pack", func(t *testing.T) {
+
+		var r, err = c.Root(pk, 1, 0)
+		assertNil(t, err)
+
+		var hp *HistoryPack
+		if hp, err = c.HistoryPack(r); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = hp.Add([]byte("value")); err != ErrReadOnlyPack {
+			t.Error("wrong error:", err)
+		}
+
+		assertNil(t, c.DelRoot(pk, 1, 0))
+
+		// the history ends
+		var n int
+		assertNil(t, c.History(pk, 1, func(*registry.Root) error {
+			n++
+			return nil
+		}))
+
+		if n != 2 {
+			t.Error("wrong history length:", n)
+		}
+
+		// but the objects are still here
+
+		var usr User
+		assertNil(t, hp.Root().Refs[0].Value(hp, &usr))
+
+		if usr.Name != "Alice" {
+			t.Error("wrong user", usr)
+		}
+
+		var rc int
+		_, rc, err = c.Get(r.Refs[0].Hash, 0)
+		assertNil(t, err)
+
+		if rc != 1 {
+			t.Error("wrong rc of pinned object:", rc)
+		}
+
+		assertNil(t, hp.Close())
+		assertNil(t, hp.Close()) // twice
+
+		// zero rc, the object can be collected
+		_, rc, err = c.Get(r.Refs[0].Hash, 0)
+		assertNil(t, err)
+
+		if rc != 0 {
+			t.Error("wrong rc of released object:", rc)
+		}
+
+		if _, err = c.HistoryPack(r); err == nil {
+			t.Error("missing error")
+		}
+
+	})
+
+}
@@ -1,7 +1,10 @@
 package skyobject
 
 import (
+	"bytes"
 	"errors"
+	"math"
+	"sort"
 	"sync"
 	"time"
 
@@ -13,6 +16,8 @@ import (
 
 // keep latest and tracked Root objects only
 type indexHeads struct {
+	mx sync.Mutex // lock of the feed
+
 	h       map[uint64]*data.Root // last Root
 	activen uint64                // head with latest root (nonce)
 	activet int64                 // timestamp of the last root
@@ -34,6 +39,10 @@ func (i *indexHeads) setActive() {
 
 	for nonce, dr := range i.h {
 
+		if dr == nil {
+			continue // blank head
+		}
+
 		if i.activet < dr.Time {
 			i.activet = dr.Time
 			i.activen = nonce
@@ -46,9 +55,12 @@ func (i *indexHeads) setActive() {
 // Index is internal and used by Container.
 // The Index can't be creaed and used outside.
 // The Index keeps information about last Root
-// objects for fast access
+// objects for fast access. Every feed has its
+// own lock, thus operations on different feeds
+// are performed in parallel. The Index locked
+// for writing only to add or remove a feed
 type Index struct {
-	mx sync.Mutex
+	mx sync.RWMutex // lock of the feeds map
 
 	c *Container // back reference (for db.IdxDB and for the Cache)
 
@@ -121,6 +133,7 @@ func (i *Index) load(c *Container) (err error) {
 			}
 
 			i.feeds[pk] = feedMap
+			i.feedsl = append(i.feedsl, pk)
 
 			return
 		})
@@ -136,6 +149,62 @@ func (i *Index) load(c *Container) (err error) {
 	return
 }
 
+// lockFeed locks the Index for reading and given
+// feed for reading and writing its heads and Roots;
+// thus, the feed can't be added or removed meanwhile;
+// if the feed doesn't exist, then only the Index is
+// locked; call returned function to unlock
+func (i *Index) lockFeed(pk cipher.PubKey) (unlock func()) {
+
+	i.mx.RLock()
+
+	if hs, ok := i.feeds[pk]; ok == true {
+		hs.mx.Lock()
+		return func() {
+			hs.mx.Unlock()
+			i.mx.RUnlock()
+		}
+	}
+
+	return i.mx.RUnlock
+}
+
+// lockFeeds is like the lockFeed, but locks many
+// feeds; the feeds are locked in order to avoid
+// deadlocks
+func (i *Index) lockFeeds(pks []cipher.PubKey) (unlock func()) {
+
+	pks = append([]cipher.PubKey{}, pks...)
+
+	sort.Slice(pks, func(x, y int) bool {
+		return bytes.Compare(pks[x][:], pks[y][:]) < 0
+	})
+
+	i.mx.RLock()
+
+	var locked []*indexHeads
+
+	for k, pk := range pks {
+
+		if k > 0 && pk == pks[k-1] {
+			continue // already locked
+		}
+
+		if hs, ok := i.feeds[pk]; ok == true {
+			hs.mx.Lock()
+			locked = append(locked, hs)
+		}
+
+	}
+
+	return func() {
+		for _, hs := range locked {
+			hs.mx.Unlock()
+		}
+		i.mx.RUnlock()
+	}
+}
+
 // call under lock
 func (i *Index) lastRoot(
 	pk cipher.PubKey, // :
@@ -162,6 +231,30 @@ func (i *Index) lastRoot(
 	return
 }
 
+// seq and hash of last Root of given head, the hash
+// is blank if the head is blank or doesn't exist
+func (i *Index) lastSeqHash(
+	pk cipher.PubKey, //   : feed
+	nonce uint64, //       : head
+) (
+	seq uint64, //         : seq of the last Root
+	hash cipher.SHA256, // : hash of the last Root
+	err error, //          : an error
+) {
+
+	defer i.lockFeed(pk)()
+
+	var dr *data.Root
+	switch dr, err = i.lastRoot(pk, nonce); err {
+	case nil:
+		return dr.Seq, dr.Hash, nil
+	case data.ErrNoSuchHead, data.ErrNotFound:
+		return 0, cipher.SHA256{}, nil
+	}
+
+	return
+}
+
 // AddFeed adds feed
 func (i *Index) AddFeed(pk cipher.PubKey) (err error) {
 
@@ -180,16 +273,13 @@ func (i *Index) AddFeed(pk cipher.PubKey) (err error) {
 		return
 	}
 
-	// reset the change-on-write list;
-	// we can't use i.feedsl[:0], because
-	// next call rewrite list that can be
-	// used by end-user
-	i.feedsl = nil
-
-	if _, ok := i.feeds[pk]; ok == false {
-		i.feeds[pk] = newIndexHeads() // add to Index
-	}
+	// the list is change-on-write; we can't append
+	// to the list, because the list can be used by
+	// end-user, thus we have to copy it
+	i.feedsl = append(i.feedsl[:len(i.feedsl):len(i.feedsl)], pk)
+	i.feeds[pk] = newIndexHeads() // add to Index
 
+	i.c.Emit(Event{Kind: FeedAdded, Pub: pk})
 	return
 }
 
@@ -251,18 +341,14 @@ func (i *Index) receivedRoot(
 	err error,
 ) {
 
-	var hash = cipher.SumSHA256(val)
-	if err = cipher.VerifyPubKeySignedHash(pk, sig, hash); err != nil {
+	if r, err = verifyRoot(pk, sig, val); err != nil {
 		return
 	}
 
-	if r, err = registry.DecodeRoot(val); err != nil {
-		return
+	if err = i.c.checkRevoked(r); err != nil {
+		return nil, err
 	}
 
-	r.Hash = hash // set the hash
-	r.Sig = sig   // set the signature
-
 	return
 }
 
@@ -282,8 +368,7 @@ func (i *Index) PreviewRoot(
 	err error,
 ) {
 
-	i.mx.Lock()
-	defer i.mx.Unlock()
+	// the receivedRoot doesn't use the Index
 
 	return i.receivedRoot(pk, sig, val)
 }
@@ -295,7 +380,10 @@ func (i *Index) PreviewRoot(
 // root. The method changes nothing in DB, it
 // only checks the Root. The method set IsFull
 // field of the Root to true if DB already have
-// this Root
+// this Root. If the Root conflicts with stored
+// Roots of its head (the same seq or broken Prev
+// link), then the ReceivedRoot stores evidence
+// and returns *EquivocationError
 func (i *Index) ReceivedRoot(
 	pk cipher.PubKey,
 	sig cipher.Sig,
@@ -305,14 +393,24 @@ func (i *Index) ReceivedRoot(
 	err error,
 ) {
 
-	i.mx.Lock()
-	defer i.mx.Unlock()
+	// verify the Root without lock
 
 	if r, err = i.receivedRoot(pk, sig, val); err != nil {
 		r = nil // GC
 		return
 	}
 
+	if r.Pub != pk {
+		return nil, errors.New("the Root doesn't match its feed")
+	}
+
+	defer i.lockFeed(pk)()
+
+	// check seq and Prev against stored Roots
+	if err = i.equivocation(r, val); err != nil {
+		return nil, err
+	}
+
 	if _, err = i.findRoot(r.Pub, r.Nonce, r.Seq); err == nil {
 		r.IsFull = true
 		return
@@ -392,6 +490,14 @@ func (i *Index) addRoot(r *registry.Root) (alreadyHave bool, err error) {
 		return
 	}
 
+	if err = i.c.addRevocations(r); err != nil {
+		return
+	}
+
+	if err = i.c.setStamp(r.Hash, r.Stamp); err != nil {
+		return
+	}
+
 	if ir != nil && r.Seq < ir.Seq {
 		// don't add to the Index the fucking, old,
 		// outdated, never need, nobody need Root
@@ -448,8 +554,22 @@ func (i *Index) addSavedRoot(r *registry.Root, dr *data.Root) {
 // method adds the Root to index (that is necessary)
 func (i *Index) AddRoot(r *registry.Root) (alreadyHave bool, err error) {
 
-	i.mx.Lock()
-	defer i.mx.Unlock()
+	if alreadyHave, err = i.addRootLock(r); err != nil || alreadyHave == true {
+		return
+	}
+
+	// interrupted fillings of the Root and older
+	// Roots of the head are not needed anymore
+	i.c.delHeadFillings(r.Pub, r.Nonce, r.Seq) // ignore error
+
+	i.c.Emit(rootEvent(RootFilled, r.Pub, r.Nonce, r.Seq, r.Hash))
+	return
+}
+
+// addRoot with lock of the feed
+func (i *Index) addRootLock(r *registry.Root) (alreadyHave bool, err error) {
+
+	defer i.lockFeed(r.Pub)()
 
 	return i.addRoot(r)
 }
@@ -471,8 +591,7 @@ func (i *Index) AddRoot(r *registry.Root) (alreadyHave bool, err error) {
 // than inserting Root
 func (i *Index) ActiveHead(pk cipher.PubKey) (nonce uint64) {
 
-	i.mx.Lock()
-	defer i.mx.Unlock()
+	defer i.lockFeed(pk)()
 
 	var hs, ok = i.feeds[pk]
 
@@ -489,8 +608,7 @@ func (i *Index) ActiveHead(pk cipher.PubKey) (nonce uint64) {
 // error is data.ErrNoSuchFeed
 func (i *Index) Heads(pk cipher.PubKey) (heads []uint64, err error) {
 
-	i.mx.Lock()
-	defer i.mx.Unlock()
+	defer i.lockFeed(pk)()
 
 	var hs, ok = i.feeds[pk]
 
@@ -518,8 +636,7 @@ func (i *Index) LastRootSeq(
 	err error, //        : an error
 ) {
 
-	i.mx.Lock()
-	defer i.mx.Unlock()
+	defer i.lockFeed(pk)()
 
 	var lr *data.Root
 	if lr, err = i.lastRoot(pk, nonce); err != nil {
@@ -550,8 +667,7 @@ func (i *Index) LastRoot(
 	err error, //        : an error
 ) {
 
-	i.mx.Lock()
-	defer i.mx.Unlock()
+	defer i.lockFeed(pk)()
 
 	var lr *data.Root
 	if lr, err = i.lastRoot(pk, nonce); err != nil {
@@ -583,7 +699,16 @@ func (i *Index) delFeed(
 
 	// delete from IdxDB first
 
-	err = i.c.db.IdxDB().Tx(func(feeds data.Feeds) (err error) {
+	err = i.c.db.IdxDB().FeedsBucketsTx(func(
+		feeds data.Feeds,
+		bs data.Buckets,
+	) (
+		err error,
+	) {
+
+		if err = checkRootPins(feeds, bs, pinPrefix(pk, 0, nil)); err != nil {
+			return
+		}
 
 		var heads data.Heads
 		if heads, err = feeds.Heads(pk); err != nil {
@@ -618,12 +743,23 @@ func (i *Index) delFeed(
 	// delete from the Index
 
 	delete(i.feeds, pk)
-	i.feedsl = nil // clear the list
+
+	// the list is change-on-write
+
+	var feedsl = make([]cipher.PubKey, 0, len(i.feeds))
+
+	for _, fpk := range i.feedsl {
+		if fpk != pk {
+			feedsl = append(feedsl, fpk)
+		}
+	}
+
+	i.feedsl = feedsl
 
 	return
 }
 
-// with lock
+// with lock of the Index (write)
 func (i *Index) delFeedLock(
 	pk cipher.PubKey,
 ) (
@@ -637,7 +773,9 @@ func (i *Index) delFeedLock(
 	return i.delFeed(pk)
 }
 
-// DelFeed deletes feed with all heads and Root objects
+// DelFeed deletes feed with all heads and Root objects.
+// It returns ErrRootIsPinned if at least one Root of the
+// feed is pinned (see PinRoot)
 func (i *Index) DelFeed(pk cipher.PubKey) (err error) {
 
 	// with lock
@@ -648,11 +786,22 @@ func (i *Index) DelFeed(pk cipher.PubKey) (err error) {
 
 	// without lock
 	for _, hash := range rhs {
+		if err = i.c.unindexRoot(hash); err != nil {
+			return
+		}
+		if err = i.c.delStamp(hash); err != nil {
+			return
+		}
 		if err = i.delRootRelatedValues(hash); err != nil {
 			return
 		}
 	}
 
+	if err = i.c.delFillings(pk[:], false, 0); err != nil {
+		return
+	}
+
+	i.c.Emit(Event{Kind: FeedDeleted, Pub: pk, Amount: len(rhs)})
 	return
 }
 
@@ -677,7 +826,16 @@ func (i *Index) delHead(
 
 	// delete from IdxDB first
 
-	err = i.c.db.IdxDB().Tx(func(feed data.Feeds) (err error) {
+	err = i.c.db.IdxDB().FeedsBucketsTx(func(
+		feed data.Feeds,
+		bs data.Buckets,
+	) (
+		err error,
+	) {
+
+		if err = checkRootPins(feed, bs, pinPrefix(pk, nonce, nil)); err != nil {
+			return
+		}
 
 		var hs data.Heads
 		if hs, err = feed.Heads(pk); err != nil {
@@ -713,7 +871,7 @@ func (i *Index) delHead(
 
 }
 
-// with lock
+// with lock of the feed
 func (i *Index) delHeadLock(
 	pk cipher.PubKey,
 	nonce uint64,
@@ -722,14 +880,14 @@ func (i *Index) delHeadLock(
 	err error,
 ) {
 
-	i.mx.Lock()
-	defer i.mx.Unlock()
+	defer i.lockFeed(pk)()
 
 	return i.delHead(pk, nonce)
 }
 
 // DelHead deletes given head. It can't remove head if at least one
-// Root of the head is held, returning ErrRootIsHeld error
+// Root of the head is held, returning ErrRootIsHeld error, or if at
+// least one Root of the head is pinned, returning ErrRootIsPinned
 func (i *Index) DelHead(pk cipher.PubKey, nonce uint64) (err error) {
 
 	// with lock
@@ -742,11 +900,22 @@ func (i *Index) DelHead(pk cipher.PubKey, nonce uint64) (err error) {
 	// without lock
 
 	for _, hash := range rhs {
+		if err = i.c.unindexRoot(hash); err != nil {
+			return
+		}
+		if err = i.c.delStamp(hash); err != nil {
+			return
+		}
 		if err = i.delRootRelatedValues(hash); err != nil {
 			return
 		}
 	}
 
+	if err = i.c.delHeadFillings(pk, nonce, math.MaxUint64); err != nil {
+		return
+	}
+
+	i.c.Emit(Event{Kind: HeadDeleted, Pub: pk, Nonce: nonce, Amount: len(rhs)})
 	return
 }
 
@@ -786,7 +955,12 @@ func (i *Index) delRoot(
 		removed  bool // last Root removed and head is clean
 	)
 
-	err = i.c.db.IdxDB().Tx(func(feeds data.Feeds) (err error) {
+	err = i.c.db.IdxDB().FeedsBucketsTx(func(
+		feeds data.Feeds,
+		bs data.Buckets,
+	) (
+		err error,
+	) {
 
 		var hs data.Heads
 		if hs, err = feeds.Heads(pk); err != nil {
@@ -803,6 +977,10 @@ func (i *Index) delRoot(
 			return // DB failure or  'not found'
 		}
 
+		if err = checkRootPins(feeds, bs, pinPrefix(pk, nonce, &seq)); err != nil {
+			return
+		}
+
 		// keep hash of the Root to remove
 		// from CXDS with all related objects
 
@@ -847,7 +1025,7 @@ func (i *Index) delRoot(
 	return
 }
 
-// delRootLock is delRoot with lock
+// delRootLock is delRoot with lock of the feed
 func (i *Index) delRootLock(
 	pk cipher.PubKey, //       : feed
 	nonce uint64, //           : head
@@ -857,8 +1035,7 @@ func (i *Index) delRootLock(
 	err error, //              : an error
 ) {
 
-	i.mx.Lock()
-	defer i.mx.Unlock()
+	defer i.lockFeed(pk)()
 
 	return i.delRoot(pk, nonce, seq)
 }
@@ -918,11 +1095,19 @@ func (i *Index) delPackWalkFunc(
 // given Root, including the Root itself and its Registry
 func (i *Index) delRootRelatedValues(rootHash cipher.SHA256) (err error) {
 
+	if err = i.c.delMergeParents(rootHash); err != nil {
+		return
+	}
+
 	var r *registry.Root
 	if r, err = i.c.rootByHash(rootHash); err != nil {
 		return
 	}
 
+	if r.IsPartial == true {
+		return i.delPartialRoot(r)
+	}
+
 	var (
 		pack     registry.Pack
 		walkFunc registry.WalkFunc
@@ -935,8 +1120,22 @@ func (i *Index) delRootRelatedValues(rootHash cipher.SHA256) (err error) {
 	return i.c.walkRoot(pack, r, walkFunc)
 }
 
+// delPartialRoot decrements the Root, its Registry and
+// envelope, and releases other objects of the partially
+// filled Root kept in the partialBucket
+func (i *Index) delPartialRoot(r *registry.Root) (err error) {
+
+	for _, key := range partialRootKeys(r) {
+		if _, err = i.c.Inc(key, -1); err != nil {
+			return
+		}
+	}
+
+	return i.c.delPartial(r.Hash)
+}
+
 // DelRoot deletes Root. The method returns data.ErrNotFound if
-// Root doesn't exist
+// Root doesn't exist, and ErrRootIsPinned if the Root is pinned
 func (i *Index) DelRoot(pk cipher.PubKey, nonce, seq uint64) (err error) {
 
 	// with lock
@@ -946,7 +1145,20 @@ func (i *Index) DelRoot(pk cipher.PubKey, nonce, seq uint64) (err error) {
 	}
 
 	// without lock
-	return i.delRootRelatedValues(rootHash)
+	if err = i.c.unindexRoot(rootHash); err != nil {
+		return
+	}
+
+	if err = i.c.delStamp(rootHash); err != nil {
+		return
+	}
+
+	if err = i.delRootRelatedValues(rootHash); err != nil {
+		return
+	}
+
+	i.c.Emit(rootEvent(RootDeleted, pk, nonce, seq, rootHash))
+	return
 }
 
 // Feeds returns list of feeds. For performance
@@ -955,14 +1167,8 @@ func (i *Index) DelRoot(pk cipher.PubKey, nonce, seq uint64) (err error) {
 // Copy the list if you want to modify it
 func (i *Index) Feeds() (feeds []cipher.PubKey) {
 
-	i.mx.Lock()
-	defer i.mx.Unlock()
-
-	if len(i.feedsl) != len(i.feeds) {
-		for pk := range i.feeds {
-			i.feedsl = append(i.feedsl, pk)
-		}
-	}
+	i.mx.RLock()
+	defer i.mx.RUnlock()
 
 	return i.feedsl
 
@@ -971,8 +1177,8 @@ func (i *Index) Feeds() (feeds []cipher.PubKey) {
 // HasFeed returns true if feed exists
 func (i *Index) HasFeed(pk cipher.PubKey) (yep bool) {
 
-	i.mx.Lock()
-	defer i.mx.Unlock()
+	i.mx.RLock()
+	defer i.mx.RUnlock()
 
 	_, yep = i.feeds[pk]
 	return
@@ -982,8 +1188,7 @@ func (i *Index) HasFeed(pk cipher.PubKey) (yep bool) {
 // returns false if given feed doesn't exist
 func (i *Index) HasHead(pk cipher.PubKey, nonce uint64) (yep bool) {
 
-	i.mx.Lock()
-	defer i.mx.Unlock()
+	defer i.lockFeed(pk)()
 
 	var hs *indexHeads
 
@@ -1002,8 +1207,7 @@ func (i *Index) HasHead(pk cipher.PubKey, nonce uint64) (yep bool) {
 // and the Heads method will return it even if it empty
 func (i *Index) AddHead(pk cipher.PubKey, nonce uint64) (err error) {
 
-	i.mx.Lock()
-	defer i.mx.Unlock()
+	defer i.lockFeed(pk)()
 
 	// check out Index first
 
@@ -1035,7 +1239,9 @@ func (i *Index) AddHead(pk cipher.PubKey, nonce uint64) (err error) {
 
 	// add to the Index
 
-	i.feeds[pk] = newIndexHeads()
+	hs.h[nonce] = nil // blank head
+
+	i.c.Emit(Event{Kind: HeadAdded, Pub: pk, Nonce: nonce})
 	return
 
 }
@@ -1055,7 +1261,8 @@ func (i *Index) Close() (err error) {
 	return
 }
 
-func (i *Index) dataRoot(
+// dataRootLock is dataRoot with lock of the feed
+func (i *Index) dataRootLock(
 	pk cipher.PubKey,
 	nonce uint64,
 	seq uint64,
@@ -1064,8 +1271,21 @@ func (i *Index) dataRoot(
 	err error,
 ) {
 
-	i.mx.Lock()
-	defer i.mx.Unlock()
+	defer i.lockFeed(pk)()
+
+	return i.dataRoot(pk, nonce, seq)
+}
+
+// dataRoot returns data.Root from the Index or
+// IdxDB; the dataRoot must be called under lock
+func (i *Index) dataRoot(
+	pk cipher.PubKey,
+	nonce uint64,
+	seq uint64,
+) (
+	dr *data.Root,
+	err error,
+) {
 
 	// the root can be last
 
@@ -1118,7 +1338,7 @@ func (i *Index) Root(
 
 	var dr *data.Root
 
-	if dr, err = i.dataRoot(feed, nonce, seq); err != nil {
+	if dr, err = i.dataRootLock(feed, nonce, seq); err != nil {
 		return
 	}
 
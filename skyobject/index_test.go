@@ -0,0 +1,125 @@
+package skyobject
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/data"
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+func TestIndex_parallel(t *testing.T) {
+
+	var c = getTestContainer()
+	defer c.Close()
+
+	const feeds, roots = 8, 10
+
+	var (
+		pks = make([]cipher.PubKey, 0, feeds)
+		sks = make([]cipher.SecKey, 0, feeds)
+	)
+
+	for k := 0; k < feeds; k++ {
+		var pk, sk = cipher.GenerateKeyPair()
+		assertNil(t, c.AddFeed(pk))
+		pks, sks = append(pks, pk), append(sks, sk)
+	}
+
+	var wg sync.WaitGroup
+
+	// save and read Roots of different feeds in parallel
+
+	for k := range pks {
+
+		wg.Add(1)
+
+		go func(pk cipher.PubKey, sk cipher.SecKey) {
+			defer wg.Done()
+
+			var up, err = c.Unpack(sk, testRegistry)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer up.Close()
+
+			for n := 0; n < roots; n++ {
+
+				var r = new(registry.Root)
+				r.Pub = pk
+				r.Nonce = 1
+
+				if err = c.Save(up, r); err != nil {
+					t.Error(err)
+					return
+				}
+
+				var lr *registry.Root
+				if lr, err = c.LastRoot(pk, c.ActiveHead(pk)); err != nil {
+					t.Error(err)
+					return
+				} else if lr.Hash != r.Hash {
+					t.Error("wrong last Root")
+					return
+				}
+
+				c.Feeds() // the feeds are not changed
+
+			}
+
+		}(pks[k], sks[k])
+
+	}
+
+	// add and remove another feed meanwhile
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for n := 0; n < roots; n++ {
+			var pk, _ = cipher.GenerateKeyPair()
+			if err := c.AddFeed(pk); err != nil {
+				t.Error(err)
+				return
+			}
+			if err := c.DelFeed(pk); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	wg.Wait()
+
+	if len(c.Feeds()) != feeds {
+		t.Fatal("wrong number of feeds", len(c.Feeds()))
+	}
+
+	for _, pk := range pks {
+		if seq, err := c.LastRootSeq(pk, 1); err != nil {
+			t.Fatal(err)
+		} else if seq != roots-1 {
+			t.Error("wrong seq", seq)
+		}
+	}
+
+	t.Run("add head", func(t *testing.T) {
+
+		assertNil(t, c.AddHead(pks[0], 2))
+
+		if _, err := c.LastRoot(pks[0], 1); err != nil {
+			t.Error("head removed:", err)
+		}
+
+		if _, err := c.LastRoot(pks[0], 2); err != data.ErrNotFound {
+			t.Error("wrong error:", err)
+		}
+
+	})
+
+}
@@ -0,0 +1,816 @@
+package skyobject
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+
+	"github.com/skycoin/cxo/data"
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+// A MergeConflict represents a value changed by both
+// heads different ways, that can't be merged
+// automatically
+type MergeConflict struct {
+	// Path to the value, for example
+	// "Refs[1].Posts[3].Body"
+	Path string
+
+	// Schema of the value. It's nil
+	// for a Dynamic reference
+	Schema registry.Schema
+
+	// Base, Ours and Theirs are encoded values.
+	// Any of them can be nil if the value is
+	// absent (removed or not created yet)
+	Base, Ours, Theirs []byte
+}
+
+// A MergeResolver used to resolve a MergeConflict. It
+// returns encoded value of the Schema of the conflict
+// or an error. It can return nil to remove an element
+// of Root.Refs (other values can't be removed). The
+// pack can be used to get objects and to create new
+type MergeResolver func(
+	pack registry.Pack, //  : pack of the merging
+	mc *MergeConflict, //   : the conflict
+) (
+	val []byte, //          : resolved value
+	err error, //           : an error
+)
+
+// MergeOurs is MergeResolver that
+// always chooses our changes
+func MergeOurs(_ registry.Pack, mc *MergeConflict) ([]byte, error) {
+	return mc.Ours, nil
+}
+
+// MergeTheirs is MergeResolver that
+// always chooses their changes
+func MergeTheirs(_ registry.Pack, mc *MergeConflict) ([]byte, error) {
+	return mc.Theirs, nil
+}
+
+// MergeConflictError returned by Merge if a
+// MergeConflict has not been resolved
+type MergeConflictError struct {
+	Conflict *MergeConflict
+}
+
+// Error implements error interface
+func (m *MergeConflictError) Error() string {
+	return "merge conflict: " + m.Conflict.Path
+}
+
+// state of a Root (its content), since Roots of
+// different heads have different hashes anyway
+func rootState(r *registry.Root) cipher.SHA256 {
+	return cipher.SumSHA256(encoder.Serialize(struct {
+		Reg  registry.RegistryRef
+		Refs []registry.Dynamic
+	}{r.Reg, r.Refs}))
+}
+
+// merged Root hash -> hashes of ours and theirs Roots
+var mergesBucket = []byte("skyobject.merges")
+
+// MergeBase finds common ancestor of given heads. Since
+// hash of a Root depends on its head (nonce), Prev chains
+// of different heads never meet. But every Root saved by
+// Merge is linked with merged Roots. Thus, the MergeBase
+// walks through Prev of Roots of the heads and through
+// the links looking for newest common Root. It can be a
+// Root of any head. If there is no such Root (e.g. the
+// heads have never been merged on this node), then the
+// MergeBase falls back to content: it looks for newest
+// Roots of the heads with the same Refs and Registry and
+// returns the Root of head a. It returns data.ErrNotFound
+// if there is no common ancestor
+func (c *Container) MergeBase(
+	pk cipher.PubKey, //  : feed
+	a, b uint64, //       : heads
+) (
+	base *registry.Root, //
+	err error, //         :
+) {
+
+	var ra, rb *registry.Root
+
+	if ra, err = c.LastRoot(pk, a); err != nil {
+		return
+	}
+
+	if rb, err = c.LastRoot(pk, b); err != nil {
+		return
+	}
+
+	var ancestors = make(map[cipher.SHA256]struct{})
+
+	err = c.ancestors(ra, func(r *registry.Root) (_ error) {
+		ancestors[r.Hash] = struct{}{}
+		return
+	})
+
+	if err != nil {
+		return
+	}
+
+	err = c.ancestors(rb, func(r *registry.Root) (_ error) {
+		if _, ok := ancestors[r.Hash]; ok == true {
+			base = r
+			return data.ErrStopIteration
+		}
+		return
+	})
+
+	if err != nil || base != nil {
+		return
+	}
+
+	return c.mergeBaseByContent(pk, a, b)
+}
+
+// ancestors calls given function for given Root and
+// all its ancestors (following Prev and links of
+// merged Roots) from newest to oldest (breadth-first)
+func (c *Container) ancestors(
+	r *registry.Root,
+	ancestorFunc HistoryFunc,
+) (
+	err error,
+) {
+
+	var (
+		queue = []*registry.Root{r}
+		seen  = map[cipher.SHA256]struct{}{r.Hash: {}}
+
+		parents []cipher.SHA256
+	)
+
+	for len(queue) > 0 {
+
+		r, queue = queue[0], queue[1:]
+
+		if err = ancestorFunc(r); err != nil {
+			if err == data.ErrStopIteration {
+				err = nil
+			}
+			return
+		}
+
+		if parents, err = c.mergeParents(r.Hash); err != nil {
+			return
+		}
+
+		if r.Prev != (cipher.SHA256{}) {
+			parents = append([]cipher.SHA256{r.Prev}, parents...)
+		}
+
+		for _, hash := range parents {
+
+			if _, ok := seen[hash]; ok == true {
+				continue
+			}
+			seen[hash] = struct{}{}
+
+			var parent *registry.Root
+			switch parent, err = c.savedRoot(hash); err {
+			case nil:
+				queue = append(queue, parent)
+			case data.ErrNotFound:
+				err = nil // removed
+			default:
+				return
+			}
+
+		}
+
+	}
+
+	return
+}
+
+// mergeBaseByContent finds newest Roots of given heads
+// with the same content and returns the Root of head a
+func (c *Container) mergeBaseByContent(
+	pk cipher.PubKey, //  : feed
+	a, b uint64, //       : heads
+) (
+	base *registry.Root, //
+	err error, //         :
+) {
+
+	var states = make(map[cipher.SHA256]*registry.Root)
+
+	err = c.History(pk, a, func(r *registry.Root) (_ error) {
+		if st := rootState(r); states[st] == nil {
+			states[st] = r // keep newest
+		}
+		return
+	})
+
+	if err != nil {
+		return
+	}
+
+	err = c.History(pk, b, func(r *registry.Root) (_ error) {
+		if base = states[rootState(r)]; base != nil {
+			return data.ErrStopIteration
+		}
+		return
+	})
+
+	if err == nil && base == nil {
+		err = data.ErrNotFound
+	}
+
+	return
+}
+
+// link merged Root with Roots it merges
+func (c *Container) setMergeParents(
+	hash cipher.SHA256, //    : merged Root
+	ours cipher.SHA256, //    : our Root
+	theirs cipher.SHA256, //  : their Root
+) (
+	err error, //             : an error
+) {
+
+	return c.db.IdxDB().BucketsTx(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(mergesBucket); err != nil {
+			return
+		}
+
+		return bk.Set(hash[:], append(ours[:], theirs[:]...))
+
+	})
+
+}
+
+// Roots merged by Root with given hash, if any
+func (c *Container) mergeParents(
+	hash cipher.SHA256,
+) (
+	parents []cipher.SHA256,
+	err error,
+) {
+
+	err = c.db.IdxDB().BucketsView(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(mergesBucket); err != nil {
+			return
+		}
+
+		var val []byte
+		switch val, err = bk.Get(hash[:]); err {
+		case nil:
+		case data.ErrNotFound:
+			return nil
+		default:
+			return
+		}
+
+		if len(val) != 2*len(cipher.SHA256{}) {
+			return fmt.Errorf("invalid merge parents of Root %s",
+				hash.Hex()[:7])
+		}
+
+		parents = make([]cipher.SHA256, 2)
+		copy(parents[0][:], val)
+		copy(parents[1][:], val[len(cipher.SHA256{}):])
+		return
+
+	})
+
+	return
+}
+
+// remove links of a removed Root
+func (c *Container) delMergeParents(hash cipher.SHA256) (err error) {
+
+	return c.db.IdxDB().BucketsTx(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(mergesBucket); err != nil {
+			return
+		}
+
+		return bk.Del(hash[:])
+
+	})
+
+}
+
+// a merger performs three-way merge
+type merger struct {
+	up       *Unpack
+	resolver MergeResolver
+}
+
+// Merge performs three-way merge of last Roots of
+// ours and theirs heads. The common ancestor found by
+// MergeBase, if there is no common ancestor, then two-way
+// merge performed. The Merge merges elements of the
+// Root.Refs by index, fields of structures, elements
+// of Refs (as lists of hashes) and objects that Ref
+// and Dynamic references point to. Other values can't
+// be merged, and if they are changed by both heads,
+// then given resolver used to resolve the conflict. If
+// the resolver is nil, then *MergeConflictError returned
+// for first conflict. The Registry of the Unpack must
+// be the same as Registry of both heads. The merged
+// Root saved on the into head. The into can be any head
+// of the feed, including ours or theirs. See MergeOurs
+// and MergeTheirs. The merged Root is linked with ours
+// and theirs Roots for MergeBase. If the linking fails,
+// then the merged Root is saved and returned with the
+// error
+func (c *Container) Merge(
+	up *Unpack, //              : unpack to save
+	pk cipher.PubKey, //        : feed
+	ours, theirs uint64, //     : heads to merge
+	into uint64, //             : save to this head
+	resolver MergeResolver, //  : conflicts resolver
+) (
+	r *registry.Root, //        : merged Root
+	err error, //               : an error
+) {
+
+	var or, tr, br *registry.Root
+
+	if or, err = c.LastRoot(pk, ours); err != nil {
+		return
+	}
+
+	if tr, err = c.LastRoot(pk, theirs); err != nil {
+		return
+	}
+
+	var reg = up.Registry().Reference()
+
+	if or.Reg != reg || tr.Reg != reg {
+		return nil, ErrRegistryMismatch
+	}
+
+	if br, err = c.MergeBase(pk, ours, theirs); err != nil {
+		if err != data.ErrNotFound {
+			return
+		}
+		br, err = new(registry.Root), nil // empty base
+	}
+
+	var m = merger{up: up, resolver: resolver}
+
+	r = new(registry.Root)
+
+	r.Pub = pk
+	r.Nonce = into
+	r.Reg = reg
+
+	if r.Refs, err = m.mergeRootRefs(br, or, tr); err != nil {
+		return nil, err
+	}
+
+	r.Descriptor = or.Descriptor
+	if bytes.Equal(br.Descriptor, or.Descriptor) == true {
+		r.Descriptor = tr.Descriptor
+	}
+
+	if err = c.Save(up, r); err != nil {
+		return nil, err
+	}
+
+	err = c.setMergeParents(r.Hash, or.Hash, tr.Hash)
+	return
+}
+
+func (m *merger) resolve(
+	path string,
+	sch registry.Schema,
+	base, ours, theirs []byte,
+) (
+	val []byte,
+	err error,
+) {
+
+	var mc = &MergeConflict{path, sch, base, ours, theirs}
+
+	if m.resolver == nil {
+		return nil, &MergeConflictError{mc}
+	}
+
+	return m.resolver(m.up, mc)
+}
+
+// get encoded value, the blank hash means nil
+func (m *merger) get(hash cipher.SHA256) (val []byte, err error) {
+	if hash == (cipher.SHA256{}) {
+		return
+	}
+	return m.up.Get(hash)
+}
+
+func (m *merger) mergeRootRefs(
+	br, or, tr *registry.Root,
+) (
+	refs []registry.Dynamic,
+	err error,
+) {
+
+	var at = func(r *registry.Root, i int) []byte {
+		if i < len(r.Refs) {
+			return encoder.Serialize(r.Refs[i])
+		}
+		return nil
+	}
+
+	var ln = len(or.Refs)
+	if len(tr.Refs) > ln {
+		ln = len(tr.Refs)
+	}
+
+	var base = at
+
+	if br.Reg != or.Reg {
+		// can't use objects of another Registry
+		base = func(*registry.Root, int) []byte { return nil }
+	}
+
+	for i := 0; i < ln; i++ {
+
+		var val []byte
+
+		val, err = m.mergeDynamic("Refs["+strconv.Itoa(i)+"]",
+			base(br, i), at(or, i), at(tr, i))
+
+		if err != nil {
+			return
+		}
+
+		if val == nil {
+			continue // removed
+		}
+
+		var dr registry.Dynamic
+		if _, err = encoder.DeserializeRaw(val, &dr); err != nil {
+			return
+		}
+
+		refs = append(refs, dr)
+
+	}
+
+	return
+}
+
+// trivial cases of a merging
+func mergeTrivial(base, ours, theirs []byte) (val []byte, ok bool) {
+
+	switch {
+	case bytes.Equal(ours, theirs):
+		return ours, true
+	case base != nil && bytes.Equal(base, ours):
+		return theirs, true
+	case base != nil && bytes.Equal(base, theirs):
+		return ours, true
+	case base == nil && ours == nil:
+		return theirs, true // created by theirs
+	case base == nil && theirs == nil:
+		return ours, true // created by ours
+	}
+
+	return
+}
+
+func (m *merger) mergeValue(
+	path string,
+	sch registry.Schema,
+	base, ours, theirs []byte,
+) (
+	val []byte,
+	err error,
+) {
+
+	var ok bool
+	if val, ok = mergeTrivial(base, ours, theirs); ok == true {
+		return
+	}
+
+	if ours == nil || theirs == nil {
+		// removed and changed
+		return m.resolve(path, sch, base, ours, theirs)
+	}
+
+	if sch.IsReference() == true {
+
+		switch sch.ReferenceType() {
+		case registry.ReferenceTypeSingle:
+			return m.mergeRef(path, sch, base, ours, theirs)
+		case registry.ReferenceTypeSlice:
+			return m.mergeRefs(path, sch, base, ours, theirs)
+		case registry.ReferenceTypeDynamic:
+			return m.mergeDynamic(path, base, ours, theirs)
+		}
+
+	} else if sch.Kind() == reflect.Struct {
+		return m.mergeStruct(path, sch, base, ours, theirs)
+	}
+
+	return m.resolve(path, sch, base, ours, theirs)
+}
+
+// merge encoded structures field by field
+func (m *merger) mergeStruct(
+	path string,
+	sch registry.Schema,
+	base, ours, theirs []byte,
+) (
+	val []byte,
+	err error,
+) {
+
+	var bf, of, tf, mf []byte
+
+	for _, fl := range sch.Fields() {
+
+		if base != nil {
+			if bf, err = fieldValue(sch, base, fl.Name()); err != nil {
+				return
+			}
+		}
+
+		if of, err = fieldValue(sch, ours, fl.Name()); err != nil {
+			return
+		}
+
+		if tf, err = fieldValue(sch, theirs, fl.Name()); err != nil {
+			return
+		}
+
+		mf, err = m.mergeValue(path+"."+fl.Name(), fl.Schema(), bf, of, tf)
+
+		if err != nil {
+			return
+		}
+
+		if mf == nil {
+			return nil, fmt.Errorf("can't remove field %s.%s", path,
+				fl.Name())
+		}
+
+		val = append(val, mf...)
+
+	}
+
+	return
+}
+
+func (m *merger) mergeRef(
+	path string,
+	sch registry.Schema,
+	base, ours, theirs []byte,
+) (
+	val []byte,
+	err error,
+) {
+
+	var br, or, tr registry.Ref
+
+	if base != nil {
+		if _, err = encoder.DeserializeRaw(base, &br); err != nil {
+			return
+		}
+	}
+
+	if _, err = encoder.DeserializeRaw(ours, &or); err != nil {
+		return
+	}
+
+	if _, err = encoder.DeserializeRaw(theirs, &tr); err != nil {
+		return
+	}
+
+	if or.IsBlank() == true || tr.IsBlank() == true {
+		return m.resolve(path, sch, base, ours, theirs) // cleared and changed
+	}
+
+	var hash cipher.SHA256
+	if hash, err = m.mergeObject(path, sch.Elem(), br.Hash, or.Hash,
+		tr.Hash); err != nil {
+
+		return
+	}
+
+	return encoder.Serialize(registry.Ref{Hash: hash}), nil
+}
+
+func (m *merger) mergeDynamic(
+	path string,
+	base, ours, theirs []byte,
+) (
+	val []byte,
+	err error,
+) {
+
+	var ok bool
+	if val, ok = mergeTrivial(base, ours, theirs); ok == true {
+		return
+	}
+
+	if ours == nil || theirs == nil {
+		return m.resolve(path, nil, base, ours, theirs)
+	}
+
+	var bd, od, td registry.Dynamic
+
+	if base != nil {
+		if _, err = encoder.DeserializeRaw(base, &bd); err != nil {
+			return
+		}
+	}
+
+	if _, err = encoder.DeserializeRaw(ours, &od); err != nil {
+		return
+	}
+
+	if _, err = encoder.DeserializeRaw(theirs, &td); err != nil {
+		return
+	}
+
+	if od.IsBlank() == true || td.IsBlank() == true ||
+		od.Schema != td.Schema {
+
+		return m.resolve(path, nil, base, ours, theirs)
+	}
+
+	if bd.Schema != od.Schema {
+		bd = registry.Dynamic{} // base is another type
+	}
+
+	var sch registry.Schema
+	if sch, err = m.up.Registry().SchemaByReference(od.Schema); err != nil {
+		return
+	}
+
+	var hash cipher.SHA256
+	if hash, err = m.mergeObject(path, sch, bd.Hash, od.Hash,
+		td.Hash); err != nil {
+
+		return
+	}
+
+	return encoder.Serialize(registry.Dynamic{
+		Hash:   hash,
+		Schema: od.Schema,
+	}), nil
+}
+
+// merge objects by hashes and save merged
+func (m *merger) mergeObject(
+	path string,
+	sch registry.Schema,
+	base, ours, theirs cipher.SHA256,
+) (
+	hash cipher.SHA256,
+	err error,
+) {
+
+	var bv, ov, tv, val []byte
+
+	if bv, err = m.get(base); err != nil {
+		return
+	}
+
+	if ov, err = m.get(ours); err != nil {
+		return
+	}
+
+	if tv, err = m.get(theirs); err != nil {
+		return
+	}
+
+	if val, err = m.mergeValue(path, sch, bv, ov, tv); err != nil {
+		return
+	}
+
+	if val == nil {
+		return // blank
+	}
+
+	if bytes.Equal(val, ov) == true {
+		return ours, nil
+	}
+
+	if bytes.Equal(val, tv) == true {
+		return theirs, nil
+	}
+
+	return m.up.Add(val)
+}
+
+// hashes of elements of encoded Refs
+func (m *merger) refsHashes(val []byte) (hs []cipher.SHA256, err error) {
+
+	if val == nil {
+		return
+	}
+
+	var refs registry.Refs
+	if _, err = encoder.DeserializeRaw(val, &refs); err != nil {
+		return
+	}
+
+	err = refs.Ascend(m.up, func(_ int, hash cipher.SHA256) (_ error) {
+		hs = append(hs, hash)
+		return
+	})
+
+	return
+}
+
+// merge Refs as lists of hashes; the merged Refs contains
+// elements of ours, excluding removed by theirs, and then
+// elements added by theirs; changed element is removed
+// and added element for the merging
+func (m *merger) mergeRefs(
+	path string,
+	sch registry.Schema,
+	base, ours, theirs []byte,
+) (
+	val []byte,
+	err error,
+) {
+
+	var bh, oh, th []cipher.SHA256
+
+	if bh, err = m.refsHashes(base); err != nil {
+		return
+	}
+
+	if oh, err = m.refsHashes(ours); err != nil {
+		return
+	}
+
+	if th, err = m.refsHashes(theirs); err != nil {
+		return
+	}
+
+	var set = func(hs []cipher.SHA256) (s map[cipher.SHA256]struct{}) {
+		s = make(map[cipher.SHA256]struct{}, len(hs))
+		for _, h := range hs {
+			s[h] = struct{}{}
+		}
+		return
+	}
+
+	var (
+		bs, os, ts = set(bh), set(oh), set(th)
+		merged     []cipher.SHA256
+	)
+
+	for _, h := range oh {
+		var _, inBase = bs[h]
+		var _, inTheirs = ts[h]
+		if inBase == false || inTheirs == true {
+			merged = append(merged, h) // kept or added
+		}
+	}
+
+	for _, h := range th {
+		var _, inBase = bs[h]
+		var _, inOurs = os[h]
+		if inBase == false && inOurs == false {
+			merged = append(merged, h) // added by theirs
+		}
+	}
+
+	var or registry.Refs
+	if _, err = encoder.DeserializeRaw(ours, &or); err != nil {
+		return
+	}
+
+	var degree registry.Degree
+	if degree, err = or.Degree(m.up); err != nil {
+		return
+	}
+
+	var refs registry.Refs
+
+	if err = refs.SetDegree(m.up, degree); err != nil {
+		return
+	}
+
+	if err = refs.AppendHashes(m.up, merged...); err != nil {
+		return
+	}
+
+	return encoder.Serialize(refs), nil
+}
@@ -0,0 +1,246 @@
+package skyobject
+
+import (
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+
+	"github.com/skycoin/cxo/data"
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+// save Feed on given head
+func testSaveFeed(
+	t *testing.T,
+	c *Container,
+	pk cipher.PubKey,
+	sk cipher.SecKey,
+	nonce uint64,
+	feed *Feed,
+) *registry.Root {
+
+	t.Helper()
+
+	var up, err = c.Unpack(sk, testRegistry)
+	assertNil(t, err)
+
+	var r = new(registry.Root)
+
+	r.Pub = pk
+	r.Nonce = nonce
+	r.Refs = []registry.Dynamic{
+		createDynamic(up, testRegistry, "test.Feed", feed),
+	}
+
+	assertNil(t, c.Save(up, r))
+	return r
+}
+
+// load Feed and heads of its posts
+func testLoadFeed(
+	t *testing.T,
+	c *Container,
+	r *registry.Root,
+) (
+	feed Feed,
+	posts []string,
+) {
+
+	t.Helper()
+
+	var pack, err = c.Pack(r, testRegistry)
+	assertNil(t, err)
+
+	assertNil(t, r.Refs[0].Value(pack, &feed))
+
+	assertNil(t, feed.Posts.Ascend(pack,
+		func(_ int, hash cipher.SHA256) (err error) {
+			var val []byte
+			if val, err = pack.Get(hash); err != nil {
+				return
+			}
+			var post Post
+			if _, err = encoder.DeserializeRaw(val, &post); err != nil {
+				return
+			}
+			posts = append(posts, post.Head)
+			return
+		}))
+
+	return
+}
+
+func testAppendPost(t *testing.T, c *Container, sk cipher.SecKey,
+	feed *Feed, head string) {
+
+	t.Helper()
+
+	var up, err = c.Unpack(sk, testRegistry)
+	assertNil(t, err)
+
+	assertNil(t, feed.Posts.AppendValues(up, &Post{Head: head}))
+}
+
+func TestContainer_Merge(t *testing.T) {
+
+	var (
+		c      = getTestContainer()
+		pk, sk = cipher.GenerateKeyPair()
+	)
+
+	defer c.Close()
+
+	assertNil(t, c.AddFeed(pk))
+
+	// the same content on both heads
+	var base = Feed{Head: "feed", Info: "info"}
+	testAppendPost(t, c, sk, &base, "first")
+
+	testSaveFeed(t, c, pk, sk, 1, &base)
+	testSaveFeed(t, c, pk, sk, 2, &base)
+
+	var br, err = c.MergeBase(pk, 1, 2)
+	assertNil(t, err)
+
+	if br.Nonce != 1 {
+		t.Error("wrong nonce of the base:", br.Nonce)
+	}
+
+	// diverge
+	var ours, theirs = base, base
+
+	ours.Info = "our info"
+	testAppendPost(t, c, sk, &ours, "ours")
+	testSaveFeed(t, c, pk, sk, 1, &ours)
+
+	theirs.Head = "their feed"
+	testAppendPost(t, c, sk, &theirs, "theirs")
+	testSaveFeed(t, c, pk, sk, 2, &theirs)
+
+	t.Run("merge base", func(t *testing.T) {
+
+		var mb, err = c.MergeBase(pk, 1, 2)
+		assertNil(t, err)
+
+		if mb.Hash != br.Hash {
+			t.Error("wrong merge base")
+		}
+
+		var pk3, sk3 = cipher.GenerateKeyPair()
+		assertNil(t, c.AddFeed(pk3))
+
+		testSaveFeed(t, c, pk3, sk3, 1, &Feed{Head: "one"})
+		testSaveFeed(t, c, pk3, sk3, 2, &Feed{Head: "two"})
+
+		if _, err = c.MergeBase(pk3, 1, 2); err != data.ErrNotFound {
+			t.Error("wrong error:", err)
+		}
+
+	})
+
+	t.Run("merge", func(t *testing.T) {
+
+		var up, err = c.Unpack(sk, testRegistry)
+		assertNil(t, err)
+
+		var r *registry.Root
+		if r, err = c.Merge(up, pk, 1, 2, 1, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		if r.Nonce != 1 || r.Seq != 2 {
+			t.Error("wrong head or seq", r.Nonce, r.Seq)
+		}
+
+		var feed, posts = testLoadFeed(t, c, r)
+
+		if feed.Head != "their feed" || feed.Info != "our info" {
+			t.Error("wrong merged feed:", feed.Head, feed.Info)
+		}
+
+		if len(posts) != 3 || posts[0] != "first" || posts[1] != "ours" ||
+			posts[2] != "theirs" {
+
+			t.Error("wrong merged posts:", posts)
+		}
+
+		// the merged Root is linked with last Root of
+		// head 2, thus it's base of next merge
+
+		var tr, mb *registry.Root
+
+		tr, err = c.LastRoot(pk, 2)
+		assertNil(t, err)
+
+		for _, heads := range [][2]uint64{{1, 2}, {2, 1}} {
+			if mb, err = c.MergeBase(pk, heads[0], heads[1]); err != nil {
+				t.Error(err)
+			} else if mb.Hash != tr.Hash {
+				t.Error("wrong merge base after merge:", heads)
+			}
+		}
+
+		// the HistoryPack doesn't unlink merged Root
+
+		var hp *HistoryPack
+		if hp, err = c.HistoryPack(r); err != nil {
+			t.Fatal(err)
+		}
+		assertNil(t, hp.Close())
+
+		if mb, err = c.MergeBase(pk, 1, 2); err != nil {
+			t.Error(err)
+		} else if mb.Hash != tr.Hash {
+			t.Error("wrong merge base after HistoryPack closed")
+		}
+
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+
+		var ours, theirs = base, base
+
+		ours.Info = "ours"
+		theirs.Info = "theirs"
+
+		// the same base for heads 3 and 4
+		testSaveFeed(t, c, pk, sk, 3, &base)
+		testSaveFeed(t, c, pk, sk, 4, &base)
+
+		testSaveFeed(t, c, pk, sk, 3, &ours)
+		testSaveFeed(t, c, pk, sk, 4, &theirs)
+
+		var up, err = c.Unpack(sk, testRegistry)
+		assertNil(t, err)
+
+		_, err = c.Merge(up, pk, 3, 4, 3, nil)
+
+		if mce, ok := err.(*MergeConflictError); ok == false {
+			t.Fatal("wrong error:", err)
+		} else if mce.Conflict.Path != "Refs[0].Info" {
+			t.Error("wrong path:", mce.Conflict.Path)
+		}
+
+		for _, tt := range []struct {
+			resolver MergeResolver
+			info     string
+		}{
+			{MergeOurs, "ours"},
+			{MergeTheirs, "theirs"},
+		} {
+
+			var r *registry.Root
+			if r, err = c.Merge(up, pk, 3, 4, 5, tt.resolver); err != nil {
+				t.Fatal(err)
+			}
+
+			if feed, _ := testLoadFeed(t, c, r); feed.Info != tt.info {
+				t.Errorf("wrong info %q, want %q", feed.Info, tt.info)
+			}
+
+		}
+
+	})
+
+}
@@ -12,6 +12,12 @@ type Pack struct {
 	c     *Container
 	deg   registry.Degree
 	flags registry.Flags
+
+	pc *packCipher // encrypted feed
+
+	pk cipher.PubKey // feed (cache priority)
+
+	partial bool // the Root is partially filled
 }
 
 // Registry returns related registry
@@ -19,26 +25,46 @@ func (p *Pack) Registry() (reg *registry.Registry) {
 	return p.reg
 }
 
-// Get value by hash
+// Get value by hash. Value of encrypted
+// Pack is decrypted
 func (p *Pack) Get(key cipher.SHA256) (val []byte, err error) {
-	val, _, err = p.c.Get(key, 0)
-	return
+	if val, err = p.c.getObject(key, p.partial); err != nil {
+		return
+	}
+	p.c.prioritize(p.pk, key)
+	return p.open(val)
+}
+
+// decrypt value if the Pack is encrypted
+func (p *Pack) open(val []byte) ([]byte, error) {
+	if p.pc == nil {
+		return val, nil
+	}
+	return p.pc.open(val)
 }
 
-// Set key-value pair
+// Set key-value pair. The Set never
+// encrypts given value
 func (p *Pack) Set(key cipher.SHA256, val []byte) (err error) {
 
 	if len(val) > p.c.conf.MaxObjectSize {
 		return &ObjectIsTooLargeError{key}
 	}
 
-	_, err = p.c.Set(key, val, 1)
+	if _, err = p.c.Set(key, val, 1); err == nil {
+		p.c.prioritize(p.pk, key)
+	}
 	return
 }
 
-// Add is Set that calculates hash inside
+// Add is Set that calculates hash inside.
+// Value of encrypted Pack is encrypted
 func (p *Pack) Add(val []byte) (key cipher.SHA256, err error) {
-	key = cipher.SumSHA256(val)
+	if p.pc != nil {
+		key, val = p.pc.seal(val)
+	} else {
+		key = cipher.SumSHA256(val)
+	}
 	err = p.Set(key, val)
 	return
 }
@@ -85,7 +111,12 @@ func (p *Pack) ClearFlags(flags registry.Flags) {
 // that doesn't change anything in DB.
 //
 // If given Registry is nil, then the Pack method obtains
-// registry from DB
+// registry from DB.
+//
+// If the Root is encrypted, then the Pack decrypts
+// objects. The Pack returns ErrNoContentKey if the
+// Container doesn't have key of a reader of the Root
+// (see AddReader)
 func (c *Container) Pack(
 	r *registry.Root,
 	reg *registry.Registry,
@@ -100,6 +131,14 @@ func (c *Container) Pack(
 		}
 	}
 
-	p = c.getPack(reg)
+	if p, err = c.rootPack(c.getPack(reg), r); err == nil && p == nil {
+		err = ErrNoContentKey
+	}
+
+	if p != nil {
+		p.pk = r.Pub
+		p.partial = r.IsPartial
+	}
+
 	return
 }
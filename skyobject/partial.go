@@ -0,0 +1,642 @@
+package skyobject
+
+import (
+	"encoding/binary"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/data"
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+// partially filled Roots and their objects; a Root
+// holds its own rc (the Index and HistoryPacks), and
+// every object holds number of partially filled Roots
+// the object belongs to and its value. Thus, objects of
+// partially filled Roots don't use rc of the CXDS, that
+// means "the object with all its subtree"
+var partialBucket = []byte("skyobject.partial")
+
+// key prefixes of the partialBucket
+const (
+	partialRootPrefix   byte = 'r' // + Root -> rc; + Root + object -> mark
+	partialObjectPrefix byte = 'o' // + object -> rc + value
+)
+
+// value of keys of objects of a Root
+var partialMark = []byte{1}
+
+// key of the partialBucket: prefix + a + b
+func partialKey(prefix byte, a cipher.SHA256, b ...cipher.SHA256) []byte {
+
+	var pk = make([]byte, 0, 1+len(a)*(1+len(b)))
+
+	pk = append(pk, prefix)
+	pk = append(pk, a[:]...)
+
+	for _, x := range b {
+		pk = append(pk, x[:]...)
+	}
+
+	return pk
+}
+
+// encode rc and value of the partialBucket
+func encodePartialRC(rc uint32, val []byte) (pv []byte) {
+	pv = make([]byte, 4, 4+len(val))
+	binary.BigEndian.PutUint32(pv, rc)
+	return append(pv, val...)
+}
+
+// decode rc and value of the partialBucket, the
+// value is valid inside the transaction only
+func decodePartialRC(pv []byte) (rc uint32, val []byte, err error) {
+	if len(pv) < 4 {
+		return 0, nil, errInvalidPartialValue
+	}
+	return binary.BigEndian.Uint32(pv), pv[4:], nil
+}
+
+// A FillFilter used to fill a Root partially. Objects
+// skipped by the FillFilter are not requested, and the
+// Root is marked as partially filled (see IsPartial
+// field of registry.Root). Objects of partially filled
+// Root don't hold references (rc) of the CXDS, since
+// objects with rc are treated as objects with all their
+// subtrees. They are kept by the Root instead, and they
+// are removed with the Root. Constraints of received objects
+// are checked, but field indexes of partially filled Root
+// are not updated. Objects of encrypted Roots are not
+// filtered.
+// Use PartialPack to get skipped objects later
+type FillFilter struct {
+	// MaxDepth is max depth of objects to fill. Objects
+	// of Dynamic references of a Root have depth 1, and
+	// every Ref, Refs or Dynamic adds one. Zero is no
+	// limit
+	MaxDepth int
+
+	// LastRefs is number of last elements of every
+	// Refs to fill. Zero is all
+	LastRefs int
+
+	// Skip is optional callback. See registry.Filter
+	// for details
+	Skip func(sch registry.Schema, depth, index, length int) (skip bool)
+}
+
+func (f *FillFilter) skip(
+	sch registry.Schema,
+	depth, index, length int,
+) bool {
+
+	if f.MaxDepth > 0 && depth > f.MaxDepth {
+		return true
+	}
+
+	if f.LastRefs > 0 && index >= 0 && index < length-f.LastRefs {
+		return true
+	}
+
+	if f.Skip != nil {
+		return f.Skip(sch, depth, index, length)
+	}
+
+	return false
+}
+
+// LazyFilter returns FillFilter that skips all objects
+// of a Root. Such Root is filled with its Registry only,
+// and objects of the Root can be obtained on demand
+// using PartialPack
+func LazyFilter() *FillFilter {
+	return &FillFilter{
+		Skip: func(registry.Schema, int, int, int) bool { return true },
+	}
+}
+
+// Filler with FillFilter, implements registry.Filter
+type filterFiller struct {
+	*Filler
+}
+
+// Skip implements registry.Filter interface
+func (f filterFiller) Skip(
+	sch registry.Schema,
+	depth, index, length int,
+) (
+	skip bool,
+) {
+
+	if skip = f.filter.skip(sch, depth, index, length); skip == true {
+		f.mx.Lock()
+		f.partial = true
+		f.mx.Unlock()
+	}
+
+	return
+}
+
+// FillPartial is the Fill that uses given FillFilter.
+// If the filter is nil, then the FillPartial is the
+// same as the Fill. Filled Root is partial if at least
+// one object has been skipped
+func (c *Container) FillPartial(
+	r *registry.Root, //        : the Root to fill
+	rq chan<- cipher.SHA256, // : request object from peers
+	maxParall int, //           : max subtrees processing at the same time
+	filter *FillFilter, //      : the filter
+) (
+	f *Filler, //               : the Filler
+) {
+
+	f = c.Fill(r, rq, maxParall)
+	f.filter = filter
+
+	return
+}
+
+// is the Root with given hash partially filled
+func (c *Container) isPartial(hash cipher.SHA256) (ok bool, err error) {
+
+	err = c.db.IdxDB().BucketsView(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(partialBucket); err != nil {
+			return
+		}
+
+		switch _, err = bk.Get(partialKey(partialRootPrefix, hash)); err {
+		case nil:
+			ok = true
+		case data.ErrNotFound:
+			err = nil
+		}
+
+		return
+
+	})
+
+	return
+}
+
+// getPartial returns object of a partially filled
+// Root or data.ErrNotFound
+func (c *Container) getPartial(key cipher.SHA256) (val []byte, err error) {
+
+	err = c.db.IdxDB().BucketsView(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(partialBucket); err != nil {
+			return
+		}
+
+		var pv []byte
+		if pv, err = bk.Get(partialKey(partialObjectPrefix, key)); err != nil {
+			return
+		}
+
+		if _, pv, err = decodePartialRC(pv); err != nil {
+			return
+		}
+
+		val = make([]byte, len(pv))
+		copy(val, pv)
+		return
+
+	})
+
+	return
+}
+
+// savePartial creates partially filled Root with rc 1 and
+// adds given objects to it; if the Root already exists, then
+// the savePartial adds objects the Root doesn't have
+func (c *Container) savePartial(
+	hash cipher.SHA256, //            : hash of the Root
+	objs map[cipher.SHA256][]byte, // : objects
+) (
+	err error, //                     : an error
+) {
+	return c.addPartialObjects(hash, objs, true)
+}
+
+// keepPartial adds object to existing partially filled
+// Root; it does nothing if the Root doesn't exist (e.g.
+// it has been removed or filled fully)
+func (c *Container) keepPartial(
+	hash cipher.SHA256, // : hash of the Root
+	key cipher.SHA256, //  : the object
+	val []byte, //         : and its value
+) (
+	err error, //          : an error
+) {
+	return c.addPartialObjects(hash,
+		map[cipher.SHA256][]byte{key: val}, false)
+}
+
+func (c *Container) addPartialObjects(
+	hash cipher.SHA256, //            : hash of the Root
+	objs map[cipher.SHA256][]byte, // : objects
+	create bool, //                   : create the Root if not exists
+) (
+	err error, //                     : an error
+) {
+
+	return c.db.IdxDB().BucketsTx(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(partialBucket); err != nil {
+			return
+		}
+
+		var rk = partialKey(partialRootPrefix, hash)
+
+		switch _, err = bk.Get(rk); err {
+		case nil:
+		case data.ErrNotFound:
+			if create == false {
+				return nil // not partial
+			}
+			if err = bk.Set(rk, encodePartialRC(1, nil)); err != nil {
+				return
+			}
+		default:
+			return
+		}
+
+		for key, val := range objs {
+
+			var mk = partialKey(partialRootPrefix, hash, key)
+
+			switch _, err = bk.Get(mk); err {
+			case nil:
+				continue // already have
+			case data.ErrNotFound:
+			default:
+				return
+			}
+
+			if err = bk.Set(mk, partialMark); err != nil {
+				return
+			}
+
+			if err = incPartialObject(bk, key, val, 1); err != nil {
+				return
+			}
+
+		}
+
+		return
+
+	})
+
+}
+
+// change rc of object of partially filled Root, the
+// val is used if the object doesn't exist yet; the
+// object is deleted if its rc turns to zero
+func incPartialObject(
+	bk data.Bucket, //   : the partialBucket
+	key cipher.SHA256, // : the object
+	val []byte, //        : value of new object
+	inc int, //           : change
+) (
+	err error, //         : an error
+) {
+
+	var (
+		ok = partialKey(partialObjectPrefix, key)
+		pv []byte
+		rc uint32
+	)
+
+	switch pv, err = bk.Get(ok); err {
+	case nil:
+		if rc, val, err = decodePartialRC(pv); err != nil {
+			return
+		}
+	case data.ErrNotFound:
+		err = nil
+	default:
+		return
+	}
+
+	if nrc := int(rc) + inc; nrc > 0 {
+		return bk.Set(ok, encodePartialRC(uint32(nrc), val)) // copy
+	}
+
+	return bk.Del(ok)
+}
+
+// release objects of partially filled Root and delete the Root
+func releasePartial(bk data.Bucket, hash cipher.SHA256) (err error) {
+
+	var prefix = partialKey(partialRootPrefix, hash)
+
+	return bk.Ascend(prefix, func(pk, _ []byte) (err error) {
+
+		if len(pk) > len(prefix) {
+			var key cipher.SHA256
+			copy(key[:], pk[len(prefix):])
+			if err = incPartialObject(bk, key, nil, -1); err != nil {
+				return
+			}
+		}
+
+		return bk.Del(pk)
+
+	})
+
+}
+
+// incPartial changes rc of partially filled Root; if the
+// rc turns to zero, then the Root and its objects are
+// deleted; it does nothing if the Root doesn't exist
+func (c *Container) incPartial(hash cipher.SHA256, inc int) (err error) {
+
+	return c.db.IdxDB().BucketsTx(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(partialBucket); err != nil {
+			return
+		}
+
+		var (
+			rk = partialKey(partialRootPrefix, hash)
+			pv []byte
+			rc uint32
+		)
+
+		switch pv, err = bk.Get(rk); err {
+		case nil:
+		case data.ErrNotFound:
+			return nil // not partial
+		default:
+			return
+		}
+
+		if rc, _, err = decodePartialRC(pv); err != nil {
+			return
+		}
+
+		if nrc := int(rc) + inc; nrc > 0 {
+			return bk.Set(rk, encodePartialRC(uint32(nrc), nil))
+		}
+
+		return releasePartial(bk, hash)
+
+	})
+
+}
+
+// delPartial decrements rc of partially filled Root
+func (c *Container) delPartial(hash cipher.SHA256) (err error) {
+	return c.incPartial(hash, -1)
+}
+
+// dropPartial deletes partially filled Root regardless its
+// rc, since the Root has been filled fully; the ok is false
+// if the Root doesn't exist
+func (c *Container) dropPartial(hash cipher.SHA256) (ok bool, err error) {
+
+	err = c.db.IdxDB().BucketsTx(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(partialBucket); err != nil {
+			return
+		}
+
+		switch _, err = bk.Get(partialKey(partialRootPrefix, hash)); err {
+		case nil:
+			ok = true
+		case data.ErrNotFound:
+			return nil // not partial
+		default:
+			return
+		}
+
+		return releasePartial(bk, hash)
+
+	})
+
+	return
+}
+
+// keys of objects of a Root that hold rc of the CXDS even if
+// the Root is partially filled, since they don't refer to other
+// objects: the Root, its Registry and envelope of encrypted Root
+func partialRootKeys(r *registry.Root) (keys []cipher.SHA256) {
+
+	keys = []cipher.SHA256{r.Hash, cipher.SHA256(r.Reg)}
+
+	if r.IsEncrypted() == true {
+		keys = append(keys, r.Envelope)
+	}
+
+	return
+}
+
+// A PartialPack implements registry.Pack for partially
+// filled Root. The PartialPack gets objects from DB, and
+// objects the DB doesn't have using given Getter. Received
+// objects are saved and kept while the Root exists. The
+// PartialPack can be used with full Root too
+type PartialPack struct {
+	*Pack
+
+	g Getter         // get from remote peer
+	r *registry.Root // the Root
+}
+
+// Root of the PartialPack
+func (p *PartialPack) Root() (r *registry.Root) {
+	return p.r
+}
+
+// Get from DB or from remote peer. Objects
+// of encrypted Root are decrypted
+func (p *PartialPack) Get(key cipher.SHA256) (val []byte, err error) {
+
+	if val, err = p.c.getObject(key, true); err == data.ErrNotFound {
+		val, err = p.fetch(key)
+	}
+
+	if err != nil {
+		return
+	}
+
+	p.c.prioritize(p.pk, key)
+	return p.open(val)
+}
+
+// get skipped object from remote peer and save it
+func (p *PartialPack) fetch(key cipher.SHA256) (val []byte, err error) {
+	return p.c.fetchPartial(p.r.Hash, key, p.g)
+}
+
+// get object from the CXDS, or from the partialBucket if
+// the partial is true
+func (c *Container) getObject(
+	key cipher.SHA256, // : the object
+	partial bool, //      : look for objects of partially filled Roots
+) (
+	val []byte, //        : the object
+	err error, //         : an error
+) {
+
+	if val, _, err = c.Get(key, 0); err == data.ErrNotFound && partial {
+		val, err = c.getPartial(key)
+	}
+
+	return
+}
+
+// get object of partially filled Root from DB, or using
+// given Getter if the DB doesn't have it; the object
+// received is kept by the Root in the partialBucket,
+// since it doesn't have its subtree and can't hold
+// rc of the CXDS (see FillFilter)
+func (c *Container) fetchPartial(
+	hash cipher.SHA256, // : hash of the Root
+	key cipher.SHA256, //  : the object
+	g Getter, //           : remote
+) (
+	val []byte, //         : the object
+	err error, //          : an error
+) {
+
+	if val, err = c.getObject(key, true); err != data.ErrNotFound {
+		return
+	}
+
+	if val, err = g.Get(key); err != nil {
+		return
+	}
+
+	if cipher.SumSHA256(val) != key {
+		return nil, ErrWrongObject
+	}
+
+	err = c.keepPartial(hash, key, val)
+	return
+}
+
+// hold object with given key by the CXDS rc; if the
+// DB doesn't have the object, then it's received using
+// given Getter and saved
+func (c *Container) holdObject(key cipher.SHA256, g Getter) (err error) {
+
+	if _, _, err = c.Get(key, 1); err != data.ErrNotFound {
+		return
+	}
+
+	var val []byte
+	if val, err = g.Get(key); err != nil {
+		return
+	}
+
+	if cipher.SumSHA256(val) != key {
+		return ErrWrongObject
+	}
+
+	_, err = c.Set(key, val, 1)
+	return
+}
+
+// PartialPack returns PartialPack of given Root. Given
+// Getter used to get objects the DB doesn't have. The
+// node package uses a connection as the Getter. If the
+// Root is encrypted, then the PartialPack returns
+// ErrNoContentKey if the Container doesn't have key of
+// a reader (see AddReader)
+func (c *Container) PartialPack(
+	r *registry.Root, // : the Root
+	g Getter, //         : to get objects the DB doesn't have
+) (
+	p *PartialPack, //   : the PartialPack
+	err error, //        : an error
+) {
+
+	var pack *Pack
+	if pack, err = c.Pack(r, nil); err != nil {
+		return
+	}
+
+	p = &PartialPack{Pack: pack, g: g, r: r}
+	return
+}
+
+// LazyRoot adds given Root to the Container without
+// filling. The Root is added as partially filled and
+// has no objects, except the Registry (and envelope of
+// encrypted Root). Returned PartialPack gets objects
+// from DB or using given Getter. Objects received are
+// saved and kept while the Root exists. Thus, the Root
+// can be used right away and it's filled on demand.
+// The Root must be verified (see PreviewRoot). If the
+// Container already has the Root (full or partial),
+// then the LazyRoot returns PartialPack of the Root
+func (c *Container) LazyRoot(
+	r *registry.Root, // : the Root
+	g Getter, //         : to get objects the DB doesn't have
+) (
+	p *PartialPack, //   : the PartialPack
+	err error, //        : an error
+) {
+
+	var stored *registry.Root
+
+	switch stored, err = c.Root(r.Pub, r.Nonce, r.Seq); err {
+	case nil:
+		return c.PartialPack(stored, g) // already have
+	case data.ErrNotFound, data.ErrNoSuchHead:
+	default:
+		return
+	}
+
+	if r.Reg == (registry.RegistryRef{}) {
+		return nil, ErrBlankRegistryRef
+	}
+
+	// the Root, the Registry and the envelope hold rc of the
+	// CXDS, since they don't refer to other objects; objects
+	// received later are kept by the Root in the partialBucket
+
+	var held []cipher.SHA256
+
+	defer func() {
+		if err != nil {
+			for _, key := range held {
+				c.Inc(key, -1) // rollback (ignore error)
+			}
+			c.delPartial(r.Hash) // ignore error
+		}
+	}()
+
+	if _, err = c.Set(r.Hash, r.Encode(), 1); err != nil {
+		return
+	}
+
+	held = append(held, r.Hash)
+
+	for _, key := range partialRootKeys(r)[1:] {
+		if err = c.holdObject(key, g); err != nil {
+			return
+		}
+		held = append(held, key)
+	}
+
+	if err = c.savePartial(r.Hash, nil); err != nil {
+		return
+	}
+
+	r.IsFull, r.IsPartial = true, true
+
+	if _, err = c.AddRoot(r); err != nil {
+		r.IsFull, r.IsPartial = false, false // reset
+		return
+	}
+
+	return c.PartialPack(r, g)
+}
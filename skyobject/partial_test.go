@@ -0,0 +1,332 @@
+package skyobject
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/data"
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+// implements Getter using another Container
+type testGetter struct {
+	c *Container
+}
+
+func (t testGetter) Get(key cipher.SHA256) (val []byte, err error) {
+	val, _, err = t.c.Get(key, 0)
+	return
+}
+
+func TestContainer_FillPartial(t *testing.T) {
+
+	var (
+		sc, rc = getTestContainer(), getTestContainer()
+		pk, sk = cipher.GenerateKeyPair()
+	)
+
+	defer sc.Close()
+	defer rc.Close()
+
+	assertNil(t, sc.AddFeed(pk))
+	assertNil(t, rc.AddFeed(pk))
+
+	var up, err = sc.Unpack(sk, testRegistry)
+	assertNil(t, err)
+	defer up.Close()
+
+	var feed = Feed{Head: "Alices' feed"}
+
+	for i := 0; i < 50; i++ {
+		assertNil(t, feed.Posts.AppendValues(up, Post{
+			Head: fmt.Sprintf("Head #%d", i),
+			Body: fmt.Sprintf("Body #%d", i),
+		}))
+	}
+
+	var r = new(registry.Root)
+
+	r.Pub = pk
+	r.Nonce = 1
+	r.Refs = []registry.Dynamic{
+		createDynamic(up, testRegistry, "test.Feed", &feed),
+	}
+
+	assertNil(t, sc.Save(up, r))
+
+	var filter = &FillFilter{LastRefs: 5}
+
+	assertNil(t, testFillFilter(sc, rc, r, -1, filter))
+
+	var pr *registry.Root
+	if pr, err = rc.Root(pk, r.Nonce, r.Seq); err != nil {
+		t.Fatal(err)
+	}
+
+	if pr.IsPartial == false {
+		t.Fatal("not partial")
+	}
+
+	var first, last cipher.SHA256
+
+	if first, err = feed.Posts.HashByIndex(up, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if last, err = feed.Posts.HashByIndex(up, 49); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err = rc.Get(first, 0); err != data.ErrNotFound {
+		t.Error("skipped object received", err)
+	}
+
+	// objects of partial Root are kept by the Root
+	if _, err = rc.getPartial(last); err != nil {
+		t.Error("object of partial Root is not kept", err)
+	}
+
+	// and don't hold rc, since they don't have subtrees
+	var rcnt int
+	if _, rcnt, err = rc.Get(last, 0); err == nil && rcnt != 0 {
+		t.Error("object of partial Root holds rc", rcnt)
+	}
+
+	// but the Root and its Registry hold rc
+	for _, key := range []cipher.SHA256{pr.Hash, cipher.SHA256(pr.Reg)} {
+		if _, rcnt, err = rc.Get(key, 0); err != nil {
+			t.Fatal(err)
+		} else if rcnt == 0 {
+			t.Error("zero rc")
+		}
+	}
+
+	// on demand
+	var pp *PartialPack
+	if pp, err = rc.PartialPack(pr, testGetter{sc}); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		rf   Feed
+		post Post
+	)
+
+	assertNil(t, pr.Refs[0].Value(pp, &rf))
+	if _, err = rf.Posts.ValueByIndex(pp, 0, &post); err != nil {
+		t.Fatal(err)
+	}
+
+	if post.Head != "Head #0" {
+		t.Error("wrong post", post.Head)
+	}
+
+	if _, _, err = rc.Get(first, 0); err != data.ErrNotFound {
+		t.Error("fetched object is saved to CXDS", err)
+	}
+
+	if _, err = rc.getPartial(first); err != nil {
+		t.Error("fetched object is not kept", err)
+	}
+
+	t.Run("depth", func(t *testing.T) {
+
+		assertNil(t, sc.Save(up, r)) // next seq
+
+		assertNil(t, testFillFilter(sc, rc, r, -1, &FillFilter{MaxDepth: 1}))
+
+		var dr *registry.Root
+		if dr, err = rc.Root(pk, r.Nonce, r.Seq); err != nil {
+			t.Fatal(err)
+		}
+
+		if dr.IsPartial == false {
+			t.Error("not partial")
+		}
+
+	})
+
+	t.Run("delete", func(t *testing.T) {
+
+		assertNil(t, rc.DelRoot(pk, pr.Nonce, pr.Seq))
+
+		if ok, err := rc.isPartial(pr.Hash); err != nil {
+			t.Fatal(err)
+		} else if ok == true {
+			t.Error("partial Root is not removed")
+		}
+
+		for _, key := range []cipher.SHA256{first, last} {
+			if _, err := rc.getPartial(key); err != data.ErrNotFound {
+				t.Error("object of deleted Root is kept", err)
+			}
+		}
+
+		// kept by the Root of the "depth"
+		if _, err := rc.getPartial(pr.Refs[0].Hash); err != nil {
+			t.Error("object of other partial Root is not kept", err)
+		}
+
+		var rcnt int
+		if _, rcnt, err = rc.Get(pr.Hash, 0); err == nil && rcnt != 0 {
+			t.Error("deleted Root holds rc", rcnt)
+		}
+
+	})
+
+}
+
+func TestContainer_LazyRoot(t *testing.T) {
+
+	var (
+		sc, rc = getTestContainer(), getTestContainer()
+		pk, sk = cipher.GenerateKeyPair()
+	)
+
+	defer sc.Close()
+	defer rc.Close()
+
+	assertNil(t, sc.AddFeed(pk))
+	assertNil(t, rc.AddFeed(pk))
+
+	var up, err = sc.Unpack(sk, testRegistry)
+	assertNil(t, err)
+	defer up.Close()
+
+	var r = new(registry.Root)
+
+	r.Pub = pk
+	r.Nonce = 1
+	r.Refs = []registry.Dynamic{
+		createDynamic(up, testRegistry, "test.User", &User{"Alice", 19}),
+	}
+
+	assertNil(t, sc.Save(up, r))
+
+	var lr = *r // copy
+	lr.IsFull = false
+
+	var pp *PartialPack
+	if pp, err = rc.LazyRoot(&lr, testGetter{sc}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err = rc.Get(r.Refs[0].Hash, 0); err != data.ErrNotFound {
+		t.Error("object received before use", err)
+	}
+
+	var usr User
+	assertNil(t, lr.Refs[0].Value(pp, &usr))
+
+	if usr.Name != "Alice" {
+		t.Error("wrong user", usr.Name)
+	}
+
+	// the object doesn't hold rc, but it's kept by the Root
+
+	if _, _, err = rc.Get(r.Refs[0].Hash, 0); err != data.ErrNotFound {
+		t.Error("fetched object is saved to CXDS", err)
+	}
+
+	if _, err = rc.getPartial(r.Refs[0].Hash); err != nil {
+		t.Error("fetched object is not kept", err)
+	}
+
+	var rcnt int
+	for _, key := range []cipher.SHA256{r.Hash, cipher.SHA256(r.Reg)} {
+		if _, rcnt, err = rc.Get(key, 0); err != nil {
+			t.Fatal(err)
+		} else if rcnt != 1 {
+			t.Error("wrong rc", rcnt)
+		}
+	}
+
+	var pr *registry.Root
+	if pr, err = rc.Root(pk, r.Nonce, r.Seq); err != nil {
+		t.Fatal(err)
+	} else if pr.IsPartial == false {
+		t.Error("not partial")
+	}
+
+	// already have
+	if pp, err = rc.LazyRoot(&lr, testGetter{sc}); err != nil {
+		t.Fatal(err)
+	} else if pp.Root().Hash != r.Hash {
+		t.Error("wrong Root")
+	}
+
+	// pinned
+	var hp *HistoryPack
+	if hp, err = rc.HistoryPack(pr); err != nil {
+		t.Fatal(err)
+	}
+
+	assertNil(t, rc.DelRoot(pk, r.Nonce, r.Seq))
+
+	if _, err = rc.getPartial(r.Refs[0].Hash); err != nil {
+		t.Error("object of pinned Root is not kept", err)
+	}
+
+	assertNil(t, hp.Close())
+
+	if _, err = rc.getPartial(r.Refs[0].Hash); err != data.ErrNotFound {
+		t.Error("object of deleted Root is kept", err)
+	}
+
+	for _, key := range []cipher.SHA256{r.Hash, cipher.SHA256(r.Reg)} {
+		if _, rcnt, err = rc.Get(key, 0); err == nil && rcnt != 0 {
+			t.Error("object of deleted Root holds rc", rcnt)
+		}
+	}
+
+	t.Run("fill fully", func(t *testing.T) {
+
+		var lr = *r // copy
+		lr.IsFull = false
+
+		if _, err = rc.LazyRoot(&lr, testGetter{sc}); err != nil {
+			t.Fatal(err)
+		}
+
+		var pp *PartialPack
+		if pp, err = rc.PartialPack(&lr, testGetter{sc}); err != nil {
+			t.Fatal(err)
+		}
+
+		var usr User
+		assertNil(t, lr.Refs[0].Value(pp, &usr))
+
+		var fr = *r // copy
+		fr.IsFull = false
+
+		assertNil(t, testFillFilter(sc, rc, &fr, 0, nil)) // from partial
+
+		if ok, err := rc.isPartial(r.Hash); err != nil {
+			t.Fatal(err)
+		} else if ok == true {
+			t.Error("full Root is partial")
+		}
+
+		if _, err = rc.getPartial(r.Refs[0].Hash); err != data.ErrNotFound {
+			t.Error("object of full Root is kept as partial", err)
+		}
+
+		var keys = []cipher.SHA256{
+			r.Hash,
+			cipher.SHA256(r.Reg),
+			r.Refs[0].Hash,
+		}
+
+		for _, key := range keys {
+			if _, rcnt, err = rc.Get(key, 0); err != nil {
+				t.Fatal(err)
+			} else if rcnt != 1 {
+				t.Error("wrong rc", rcnt)
+			}
+		}
+
+	})
+
+}
@@ -0,0 +1,427 @@
+package skyobject
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+
+	"github.com/skycoin/cxo/data"
+)
+
+// pins of Roots and objects
+var pinsBucket = []byte("skyobject.pins")
+
+// key prefixes of the pinsBucket
+const (
+	rootPinPrefix   byte = 'r' // + feed + nonce + seq
+	objectPinPrefix byte = 'o' // + hash
+)
+
+// A Pin protects a Root or an object against removing.
+// A pinned Root can't be removed (DelRoot, DelHead and
+// DelFeed return ErrRootIsPinned), thus objects of the
+// Root are kept too. A pinned object is not removed by
+// cleaning (see cxoutils package and IsPinnedObject). The
+// Pin doesn't change rc of the object, thus objects the
+// pinned object refers to are kept while the object is
+// used by a Root. A Pin can expire. Expired Pins don't protect anything, and
+// they are removed by DelExpiredPins
+type Pin struct {
+	Hash cipher.SHA256 // Root or object
+
+	Pub   cipher.PubKey // feed of pinned Root, blank for object
+	Nonce uint64        // head of the Root
+	Seq   uint64        // seq of the Root
+
+	Label  string // optional label
+	Create int64  // pinned at (unix nano)
+	Expire int64  // unix nano, zero is never
+}
+
+// IsRoot returns true if the Pin
+// pins a Root, and false for object
+func (p *Pin) IsRoot() bool {
+	return p.Pub != (cipher.PubKey{})
+}
+
+// IsExpired returns true if the Pin
+// is expired at given time (unix nano)
+func (p *Pin) IsExpired(t int64) bool {
+	return p.Expire != 0 && t > p.Expire
+}
+
+// String implements fmt.Stringer interface
+func (p *Pin) String() (s string) {
+
+	if p.IsRoot() == true {
+		s = fmt.Sprintf("root %s/%d/%d:%s", p.Pub.Hex()[:7], p.Nonce, p.Seq,
+			p.Hash.Hex()[:7])
+	} else {
+		s = "object " + p.Hash.Hex()[:7]
+	}
+
+	if p.Label != "" {
+		s += fmt.Sprintf(" %q", p.Label)
+	}
+
+	if p.Expire != 0 {
+		s += " until " + time.Unix(0, p.Expire).Format(time.RFC3339)
+	}
+
+	return
+}
+
+// key of the Pin in the pinsBucket
+func (p *Pin) key() []byte {
+	if p.IsRoot() == true {
+		return rootPinKey(p.Pub, p.Nonce, p.Seq)
+	}
+	return objectPinKey(p.Hash)
+}
+
+func rootPinKey(pk cipher.PubKey, nonce, seq uint64) (key []byte) {
+	key = make([]byte, 1+len(pk)+8+8)
+	key[0] = rootPinPrefix
+	copy(key[1:], pk[:])
+	binary.BigEndian.PutUint64(key[1+len(pk):], nonce)
+	binary.BigEndian.PutUint64(key[1+len(pk)+8:], seq)
+	return
+}
+
+func objectPinKey(hash cipher.SHA256) []byte {
+	return append([]byte{objectPinPrefix}, hash[:]...)
+}
+
+func newPin(label string, expire time.Time) (p *Pin) {
+
+	p = new(Pin)
+
+	p.Label = label
+	p.Create = time.Now().UnixNano()
+
+	if expire.IsZero() == false {
+		p.Expire = expire.UnixNano()
+	}
+
+	return
+}
+
+// PinRoot pins Root with given feed, nonce and seq. The
+// label is optional. Use zero time for never expiring
+// Pin. If the Root is already pinned, then the PinRoot
+// replaces label and expiration time of the Pin
+func (c *Container) PinRoot(
+	pk cipher.PubKey, //      : feed
+	nonce uint64, //          : head
+	seq uint64, //            : seq
+	label string, //          : label (optional)
+	expire time.Time, //      : expiration time
+) (
+	p *Pin, //                : the Pin
+	err error, //             : an error
+) {
+
+	p = newPin(label, expire)
+	p.Pub, p.Nonce, p.Seq = pk, nonce, seq
+
+	// the Root can't be removed meanwhile
+
+	err = c.db.IdxDB().FeedsBucketsTx(func(
+		feeds data.Feeds,
+		bs data.Buckets,
+	) (
+		err error,
+	) {
+
+		var dr *data.Root
+		if dr, err = rootTx(feeds, pk, nonce, seq); err != nil {
+			return
+		}
+
+		p.Hash = dr.Hash
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(pinsBucket); err != nil {
+			return
+		}
+
+		return bk.Set(p.key(), encoder.Serialize(p))
+
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+// PinObject pins object with given hash. The object
+// must exist, otherwise data.ErrNotFound returned. The
+// label is optional. Use zero time for never expiring
+// Pin. If the object is already pinned, then the
+// PinObject replaces label and expiration time of
+// the Pin
+func (c *Container) PinObject(
+	hash cipher.SHA256, //    : object
+	label string, //          : label (optional)
+	expire time.Time, //      : expiration time
+) (
+	p *Pin, //                : the Pin
+	err error, //             : an error
+) {
+
+	p = newPin(label, expire)
+	p.Hash = hash
+
+	err = c.db.IdxDB().BucketsTx(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(pinsBucket); err != nil {
+			return
+		}
+
+		// the RemoveObjects keeps pinned objects, and the
+		// object can't be pinned meanwhile
+
+		if _, _, err = c.Get(hash, 0); err != nil {
+			return // including data.ErrNotFound
+		}
+
+		return bk.Set(p.key(), encoder.Serialize(p))
+
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+// IsPinnedObject returns true if object with given
+// hash is pinned by a Pin that is not expired
+func (c *Container) IsPinnedObject(hash cipher.SHA256) (yep bool, err error) {
+
+	err = c.db.IdxDB().BucketsView(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(pinsBucket); err != nil {
+			return
+		}
+
+		var val []byte
+		switch val, err = bk.Get(objectPinKey(hash)); err {
+		case nil:
+		case data.ErrNotFound:
+			return nil // not pinned
+		default:
+			return
+		}
+
+		var p Pin
+		if _, err = encoder.DeserializeRaw(val, &p); err != nil {
+			return
+		}
+
+		yep = p.IsExpired(time.Now().UnixNano()) == false
+		return
+
+	})
+
+	return
+}
+
+// UnpinRoot removes Pin of Root with given feed, nonce
+// and seq. It returns data.ErrNotFound if the Root is
+// not pinned
+func (c *Container) UnpinRoot(pk cipher.PubKey, nonce, seq uint64) (err error) {
+	return c.unpin(&Pin{Pub: pk, Nonce: nonce, Seq: seq}, 0)
+}
+
+// UnpinObject removes Pin of object with given hash.
+// It returns data.ErrNotFound if the object is not
+// pinned
+func (c *Container) UnpinObject(hash cipher.SHA256) (err error) {
+	return c.unpin(&Pin{Hash: hash}, 0)
+}
+
+// remove Pin; if the now is not zero, then
+// the Pin is removed only if it's expired
+func (c *Container) unpin(p *Pin, now int64) (err error) {
+
+	return c.db.IdxDB().BucketsTx(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(pinsBucket); err != nil {
+			return
+		}
+
+		var (
+			key = p.key()
+			val []byte
+		)
+
+		if val, err = bk.Get(key); err != nil {
+			return // including data.ErrNotFound
+		}
+
+		if now != 0 {
+			var stored Pin
+			if _, err = encoder.DeserializeRaw(val, &stored); err != nil {
+				return
+			}
+			if stored.IsExpired(now) == false {
+				return // has been pinned again
+			}
+		}
+
+		return bk.Del(key)
+
+	})
+
+}
+
+// Pins returns all Pins, including expired
+func (c *Container) Pins() (ps []*Pin, err error) {
+
+	err = c.db.IdxDB().BucketsView(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(pinsBucket); err != nil {
+			return
+		}
+
+		return bk.Ascend(nil, func(_, val []byte) (err error) {
+			var p = new(Pin)
+			if _, err = encoder.DeserializeRaw(val, p); err != nil {
+				return
+			}
+			ps = append(ps, p)
+			return
+		})
+
+	})
+
+	return
+}
+
+// DelExpiredPins removes expired Pins. Cleaning
+// code should call it before removing anything
+func (c *Container) DelExpiredPins() (err error) {
+
+	var ps []*Pin
+	if ps, err = c.Pins(); err != nil {
+		return
+	}
+
+	var now = time.Now().UnixNano()
+
+	for _, p := range ps {
+
+		if p.IsExpired(now) == false {
+			continue
+		}
+
+		switch err = c.unpin(p, now); err {
+		case nil, data.ErrNotFound: // removed by another goroutine
+		default:
+			return
+		}
+
+	}
+
+	return nil
+}
+
+// checkRootPins returns ErrRootIsPinned if a Pin that is
+// not expired and has key with given prefix pins existing
+// Root. The checkRootPins used inside IdxDB transaction
+// that removes Roots, thus the Roots can't be pinned
+// meanwhile. See also pinPrefix
+func checkRootPins(
+	feeds data.Feeds, //   : feeds of the transaction
+	bs data.Buckets, //    : buckets of the transaction
+	prefix []byte, //      : prefix of keys of the Pins
+) (
+	err error, //          : ErrRootIsPinned or other error
+) {
+
+	var bk data.Bucket
+	if bk, err = bs.Bucket(pinsBucket); err != nil {
+		return
+	}
+
+	var now = time.Now().UnixNano()
+
+	return bk.Ascend(prefix, func(_, val []byte) (err error) {
+
+		var p Pin
+		if _, err = encoder.DeserializeRaw(val, &p); err != nil {
+			return
+		}
+
+		if p.IsExpired(now) == true {
+			return
+		}
+
+		var dr *data.Root
+		switch dr, err = rootTx(feeds, p.Pub, p.Nonce, p.Seq); err {
+		case nil:
+		case data.ErrNoSuchFeed, data.ErrNoSuchHead, data.ErrNotFound:
+			return nil // the Root has been removed
+		default:
+			return
+		}
+
+		if dr.Hash == p.Hash {
+			return ErrRootIsPinned
+		}
+
+		return
+
+	})
+
+}
+
+// prefix of keys of Pins of Roots of a feed, or a head
+// if the nonce is not zero, or a Root if the seq is not
+// nil (see checkRootPins)
+func pinPrefix(pk cipher.PubKey, nonce uint64, seq *uint64) (prefix []byte) {
+
+	switch {
+	case seq != nil:
+		return rootPinKey(pk, nonce, *seq)
+	case nonce != 0:
+		return rootPinKey(pk, nonce, 0)[:1+len(pk)+8]
+	}
+
+	return rootPinKey(pk, 0, 0)[:1+len(pk)]
+}
+
+// get Root inside IdxDB transaction
+func rootTx(
+	feeds data.Feeds, // : feeds of the transaction
+	pk cipher.PubKey, // : feed
+	nonce uint64, //     : head
+	seq uint64, //       : seq
+) (
+	dr *data.Root, //    : the Root
+	err error, //        : an error
+) {
+
+	var hs data.Heads
+	if hs, err = feeds.Heads(pk); err != nil {
+		return
+	}
+
+	var rs data.Roots
+	if rs, err = hs.Roots(nonce); err != nil {
+		return
+	}
+
+	return rs.Get(seq)
+}
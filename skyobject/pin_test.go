@@ -0,0 +1,155 @@
+package skyobject
+
+import (
+	"testing"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/data"
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+func TestContainer_PinRoot(t *testing.T) {
+
+	var (
+		c      = getTestContainer()
+		pk, sk = cipher.GenerateKeyPair()
+	)
+
+	defer c.Close()
+
+	assertNil(t, c.AddFeed(pk))
+
+	var up, err = c.Unpack(sk, testRegistry)
+	assertNil(t, err)
+	defer up.Close()
+
+	var roots []*registry.Root
+
+	for i := 0; i < 3; i++ {
+		var r = new(registry.Root)
+		r.Pub = pk
+		r.Nonce = 1
+		assertNil(t, c.Save(up, r))
+		roots = append(roots, r)
+	}
+
+	var p *Pin
+	p, err = c.PinRoot(pk, 1, 0, "release", time.Time{})
+	assertNil(t, err)
+
+	if p.IsRoot() == false || p.Hash != roots[0].Hash {
+		t.Fatal("wrong Pin")
+	}
+
+	if err = c.DelRoot(pk, 1, 0); err != ErrRootIsPinned {
+		t.Error("wrong error:", err)
+	}
+
+	if err = c.DelHead(pk, 1); err != ErrRootIsPinned {
+		t.Error("wrong error:", err)
+	}
+
+	if err = c.DelFeed(pk); err != ErrRootIsPinned {
+		t.Error("wrong error:", err)
+	}
+
+	assertNil(t, c.DelRoot(pk, 1, 1)) // not pinned
+
+	var ps []*Pin
+	ps, err = c.Pins()
+	assertNil(t, err)
+
+	if len(ps) != 1 || ps[0].Label != "release" {
+		t.Fatal("wrong Pins")
+	}
+
+	t.Run("expired", func(t *testing.T) {
+
+		_, err = c.PinRoot(pk, 1, 2, "", time.Now().Add(-time.Second))
+		assertNil(t, err)
+
+		assertNil(t, c.DelExpiredPins())
+
+		if ps, err = c.Pins(); err != nil {
+			t.Fatal(err)
+		} else if len(ps) != 1 {
+			t.Error("expired Pin is not removed")
+		}
+
+	})
+
+	t.Run("unpin", func(t *testing.T) {
+
+		assertNil(t, c.UnpinRoot(pk, 1, 0))
+		assertNil(t, c.DelFeed(pk))
+
+	})
+
+}
+
+func TestContainer_PinObject(t *testing.T) {
+
+	var c = getTestContainer()
+	defer c.Close()
+
+	var (
+		val  = []byte("value")
+		hash = cipher.SumSHA256(val)
+	)
+
+	if _, err := c.PinObject(hash, "", time.Time{}); err != data.ErrNotFound {
+		t.Error("wrong error:", err)
+	}
+
+	var _, err = c.Set(hash, val, 1)
+	assertNil(t, err)
+
+	_, err = c.PinObject(hash, "first", time.Time{})
+	assertNil(t, err)
+
+	_, err = c.PinObject(hash, "second", time.Time{}) // replace
+	assertNil(t, err)
+
+	// the Pin doesn't change rc
+	var rc int
+	if _, rc, err = c.Get(hash, -1); err != nil {
+		t.Fatal(err)
+	} else if rc != 0 {
+		t.Fatal("wrong rc of pinned object", rc)
+	}
+
+	var pinned bool
+	if pinned, err = c.IsPinnedObject(hash); err != nil {
+		t.Fatal(err)
+	} else if pinned == false {
+		t.Error("not pinned")
+	}
+
+	assertNil(t, c.UnpinObject(hash))
+
+	if pinned, err = c.IsPinnedObject(hash); err != nil {
+		t.Fatal(err)
+	} else if pinned == true {
+		t.Error("pinned after UnpinObject")
+	}
+
+	if err = c.UnpinObject(hash); err == nil {
+		t.Error("missing error")
+	}
+
+	t.Run("expired", func(t *testing.T) {
+
+		_, err = c.PinObject(hash, "", time.Now().Add(-time.Second))
+		assertNil(t, err)
+
+		if pinned, err = c.IsPinnedObject(hash); err != nil {
+			t.Fatal(err)
+		} else if pinned == true {
+			t.Error("pinned by expired Pin")
+		}
+
+	})
+
+}
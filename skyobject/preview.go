@@ -38,8 +38,17 @@ func (p *Preview) Root() (r *registry.Root) {
 	return p.r
 }
 
-// Get from DB or from remote peer
+// Get from DB or from remote peer. Objects
+// of encrypted Root are decrypted
 func (p *Preview) Get(key cipher.SHA256) (val []byte, err error) {
+	if val, err = p.get(key); err != nil {
+		return
+	}
+	return p.Pack.open(val)
+}
+
+// get (encrypted) value from DB or from remote peer
+func (p *Preview) get(key cipher.SHA256) (val []byte, err error) {
 
 	// check out map first
 	var ok bool
@@ -47,7 +56,9 @@ func (p *Preview) Get(key cipher.SHA256) (val []byte, err error) {
 		return // alrady received
 	}
 
-	if val, err = p.Pack.Get(key); err != nil && err != data.ErrNotFound {
+	if val, _, err = p.c.Get(key, 0); err == nil {
+		return
+	} else if err != data.ErrNotFound {
 		return // db failure
 	}
 
@@ -67,7 +78,10 @@ func (p *Preview) Get(key cipher.SHA256) (val []byte, err error) {
 // or Getter. The Preview method can blocks
 // calling Get from given Getter. The Preview
 // method used by node package for feeds
-// preview
+// preview. If the Root is encrypted, then the
+// Preview returns ErrNoContentKey if the
+// Container doesn't have key of a reader
+// (see AddReader)
 func (c *Container) Preview(
 	r *registry.Root, // : root to preview
 	g Getter, //         : getter to get objects from remote peer
@@ -80,6 +94,7 @@ func (c *Container) Preview(
 
 	pack.r = r
 	pack.g = g
+	pack.c = c
 	pack.m = make(map[cipher.SHA256][]byte)
 
 	var reg *registry.Registry
@@ -106,6 +121,31 @@ func (c *Container) Preview(
 
 	pack.Pack = c.getPack(reg)
 
+	if r.IsEncrypted() == false {
+		return
+	}
+
+	// envelope of encrypted Root
+
+	var (
+		val []byte
+		e   *Envelope
+		ck  ContentKey
+	)
+
+	if val, err = pack.get(r.Envelope); err != nil {
+		return
+	}
+
+	if e, err = decodeEnvelope(val); err != nil {
+		return
+	}
+
+	if ck, err = c.openEnvelope(e); err != nil {
+		return
+	}
+
+	pack.Pack.pc, err = newPackCipher(ck)
 	return
 
 }
@@ -0,0 +1,72 @@
+package skyobject
+
+import (
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+// SetFeedPriority sets cache priority of given feed.
+// Cleaning the Cache removes objects of feeds with
+// lower priority first. Default priority is zero.
+// Use negative priority for big archive feeds that
+// are rarely read, and positive for feeds that must
+// be fast. Objects shared by many feeds have the
+// highest priority of the feeds. The priority is
+// applied to objects accessed after the call. See
+// also FeedPriorities configuration
+func (c *Cache) SetFeedPriority(pk cipher.PubKey, prio int) {
+
+	c.pmx.Lock()
+	defer c.pmx.Unlock()
+
+	if prio == 0 {
+		delete(c.prios, pk) // default
+		return
+	}
+
+	c.prios[pk] = prio
+}
+
+// FeedPriority returns cache priority of given feed
+func (c *Cache) FeedPriority(pk cipher.PubKey) (prio int) {
+
+	c.pmx.RLock()
+	defer c.pmx.RUnlock()
+
+	return c.prios[pk]
+}
+
+// set priority of the feed to cached item
+// with given key, if the item exists
+func (c *Cache) prioritize(pk cipher.PubKey, key cipher.SHA256) {
+
+	if pk == (cipher.PubKey{}) {
+		return // not a feed
+	}
+
+	c.pmx.RLock()
+
+	if len(c.prios) == 0 {
+		c.pmx.RUnlock()
+		return // all feeds are equal
+	}
+
+	var prio = c.prios[pk]
+
+	c.pmx.RUnlock()
+
+	var s = c.shard(key)
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	var it, ok = s.is[key]
+
+	if ok == false {
+		return
+	}
+
+	if it.hasPrio == false || it.prio < prio {
+		it.prio, it.hasPrio = prio, true
+	}
+
+}
@@ -0,0 +1,64 @@
+package skyobject
+
+import (
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+func TestCache_SetFeedPriority(t *testing.T) {
+
+	var (
+		c, _     = getTestCacheContainer(LRU, 100)
+		hpk, hsk = cipher.GenerateKeyPair() // fast feed
+		apk, ask = cipher.GenerateKeyPair() // archive feed
+	)
+
+	defer c.Close()
+
+	c.SetFeedPriority(hpk, 1)
+	c.SetFeedPriority(apk, -1)
+
+	if c.FeedPriority(hpk) != 1 || c.FeedPriority(apk) != -1 {
+		t.Fatal("wrong priority")
+	}
+
+	var hup, err = c.Unpack(hsk, testRegistry)
+	assertNil(t, err)
+	defer hup.Close()
+
+	var aup *Unpack
+	aup, err = c.Unpack(ask, testRegistry)
+	assertNil(t, err)
+	defer aup.Close()
+
+	var (
+		hot, hotv   = testCacheObjects("fast", 10)
+		arch, archv = testCacheObjects("archive", 1000)
+	)
+
+	for k, key := range hot {
+		assertNil(t, hup.Set(key, hotv[k]))
+	}
+
+	for k, key := range arch {
+		assertNil(t, aup.Set(key, archv[k]))
+	}
+
+	for _, key := range hot {
+		if c.IsCached(key) == false {
+			t.Fatal("object of fast feed evicted")
+		}
+	}
+
+	t.Run("default", func(t *testing.T) {
+
+		c.SetFeedPriority(apk, 0)
+
+		if c.FeedPriority(apk) != 0 {
+			t.Error("priority is not reset")
+		}
+
+	})
+
+}
@@ -0,0 +1,455 @@
+package registry
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+// A Constraints represents validation constraints of a
+// field. The constraints are declared using the skyobject
+// tag. For example
+//
+//     type Post struct {
+//         Head string     `skyobject:"required,maxlen=140"`
+//         Tags []string   `skyobject:"maxlen=16"`
+//         Rate int32      `skyobject:"min=0,max=10"`
+//         Reply Dynamic   `skyobject:"schemas=app.Post|app.Comment"`
+//         Likes Refs      `skyobject:"schema=app.Like,maxlen=1000"`
+//     }
+//
+// The 'required' means that a value can't be zero: an empty
+// string or slice, blank reference or zero number. The 'maxlen'
+// limits length of a string, slice or Refs. The 'min' and 'max'
+// limits a number. And the 'schemas' is list of names of Schemas
+// a Dynamic reference allowed to point to.
+//
+// Since tags of fields are part of Schema, the constraints
+// are recorded in Registry and travel with it
+type Constraints struct {
+	Required bool     // value can't be zero
+	MaxLen   int      // max length (zero is no limit)
+	Min, Max float64  // limits of a number
+	HasMin   bool     // Min is set
+	HasMax   bool     // Max is set
+	Schemas  []string // allowed schemas of a Dynamic
+}
+
+// IsEmpty returns true if the Constraints
+// constraints nothing
+func (c *Constraints) IsEmpty() bool {
+	return c.Required == false && c.MaxLen == 0 && c.HasMin == false &&
+		c.HasMax == false && len(c.Schemas) == 0
+}
+
+// hasConstraints returns true if given Schema or a
+// nested Schema has fields with constraints; it
+// returns true for malformed constraints too
+func hasConstraints(sch Schema) bool {
+
+	if sch == nil || sch.IsReference() == true {
+		return false // referenced Schemas are registered
+	}
+
+	switch sch.Kind() {
+	case reflect.Array, reflect.Slice:
+		return hasConstraints(sch.Elem())
+	case reflect.Struct:
+	default:
+		return false
+	}
+
+	for _, fl := range sch.Fields() {
+		if c, err := FieldConstraints(fl); err != nil || c != nil {
+			return true
+		}
+		if hasConstraints(fl.Schema()) == true {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	return kind != reflect.Bool && fixedSize(kind) > 0
+}
+
+// FieldConstraints parses constraints of given field. It
+// returns nil if the field has not constraints. And it
+// returns an error if the constraints are malformed or
+// can't be applied to the field
+func FieldConstraints(f Field) (c *Constraints, err error) {
+
+	var skytag = f.Tag().Get(Tag)
+
+	if skytag == "" {
+		return
+	}
+
+	var (
+		sch  = f.Schema()
+		kind = sch.Kind()
+		rt   = sch.ReferenceType()
+		x    Constraints
+	)
+
+	for _, part := range strings.Split(skytag, ",") {
+
+		var key, val = part, ""
+
+		if i := strings.IndexByte(part, '='); i >= 0 {
+			key, val = part[:i], part[i+1:]
+		}
+
+		switch key {
+
+		case "schema", "degree":
+			continue // not a constraint
+
+		case "required":
+
+			if val != "" {
+				return nil, fmt.Errorf("unexpected value of %q", part)
+			}
+
+			if sch.IsReference() == false && kind != reflect.String &&
+				kind != reflect.Slice && isNumberKind(kind) == false {
+
+				return nil, fmt.Errorf("'required' can't be applied to %s",
+					sch)
+			}
+
+			x.Required = true
+
+		case "maxlen":
+
+			if rt != ReferenceTypeSlice && kind != reflect.String &&
+				kind != reflect.Slice {
+
+				return nil, fmt.Errorf("'maxlen' can't be applied to %s", sch)
+			}
+
+			if x.MaxLen, err = strconv.Atoi(val); err != nil {
+				return nil, fmt.Errorf("invalid 'maxlen': %v", err)
+			}
+
+			if x.MaxLen <= 0 {
+				return nil, fmt.Errorf("invalid 'maxlen': %d", x.MaxLen)
+			}
+
+		case "min", "max":
+
+			if sch.IsReference() == true || isNumberKind(kind) == false {
+				return nil, fmt.Errorf("'%s' can't be applied to %s", key, sch)
+			}
+
+			var n float64
+			if n, err = strconv.ParseFloat(val, 64); err != nil {
+				return nil, fmt.Errorf("invalid '%s': %v", key, err)
+			}
+
+			if key == "min" {
+				x.Min, x.HasMin = n, true
+			} else {
+				x.Max, x.HasMax = n, true
+			}
+
+		case "schemas":
+
+			if rt != ReferenceTypeDynamic {
+				return nil, fmt.Errorf("'schemas' can't be applied to %s", sch)
+			}
+
+			if val == "" {
+				return nil, fmt.Errorf("empty 'schemas': %q", part)
+			}
+
+			x.Schemas = strings.Split(val, "|")
+
+		default:
+			return nil, fmt.Errorf("unknown skyobject tag: %q", part)
+
+		}
+
+	}
+
+	if x.HasMin == true && x.HasMax == true && x.Min > x.Max {
+		return nil, fmt.Errorf("'min' is greater then 'max': %q", skytag)
+	}
+
+	if x.IsEmpty() == true {
+		return
+	}
+
+	return &x, nil
+}
+
+// A ConstraintError represents violation of
+// a constraint of a field
+type ConstraintError struct {
+	Schema string // name of Schema of the struct
+	Field  string // name of the field
+	Reason string // violated constraint
+}
+
+// Error implements error interface
+func (c *ConstraintError) Error() string {
+	return fmt.Sprintf("constraint violation: %s.%s: %s",
+		c.Schema, c.Field, c.Reason)
+}
+
+// CheckConstraints checks constraints of fields of given
+// encoded struct. The CheckConstraints returns first
+// *ConstraintError or another error if the Schema or the
+// encoded struct is invalid. The pack used to get length
+// of Refs and Schemas of Dynamic references. Fields of
+// nested structs (not referenced) checked too
+func CheckConstraints(pack Pack, sch Schema, val []byte) (err error) {
+
+	if sch.Kind() != reflect.Struct || sch.IsReference() == true {
+		return // only structs have fields
+	}
+
+	var shift, s int
+
+	for _, fl := range sch.Fields() {
+
+		if shift > len(val) {
+			return fmt.Errorf("unexpected end of encoded struct <%s>", sch)
+		}
+
+		if s, err = fl.Schema().Size(val[shift:]); err != nil {
+			return
+		}
+
+		var fv = val[shift : shift+s]
+
+		shift += s
+
+		var c *Constraints
+		if c, err = FieldConstraints(fl); err != nil {
+			return
+		}
+
+		if c != nil {
+			if err = c.check(pack, fl.Schema(), fv); err != nil {
+				if ce, ok := err.(*ConstraintError); ok {
+					ce.Schema, ce.Field = sch.Name(), fl.Name()
+				}
+				return
+			}
+		}
+
+		if fl.Kind() == reflect.Struct && fl.Schema().IsReference() == false {
+			if err = CheckConstraints(pack, fl.Schema(), fv); err != nil {
+				return
+			}
+		}
+
+	}
+
+	return
+}
+
+func violation(format string, args ...interface{}) error {
+	return &ConstraintError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// check encoded value of a field
+func (c *Constraints) check(pack Pack, sch Schema, fv []byte) (err error) {
+
+	switch rt := sch.ReferenceType(); {
+
+	case rt == ReferenceTypeSingle:
+		return c.checkRef(fv)
+
+	case rt == ReferenceTypeSlice:
+		return c.checkRefs(pack, fv)
+
+	case rt == ReferenceTypeDynamic:
+		return c.checkDynamic(pack, fv)
+
+	case sch.Kind() == reflect.String, sch.Kind() == reflect.Slice:
+		return c.checkLength(fv)
+
+	}
+
+	return c.checkNumber(sch.Kind(), fv)
+}
+
+func (c *Constraints) checkRef(fv []byte) (err error) {
+
+	var ref Ref
+	if _, err = encoder.DeserializeRaw(fv, &ref); err != nil {
+		return
+	}
+
+	if c.Required == true && ref.IsBlank() == true {
+		return violation("required")
+	}
+
+	return
+}
+
+func (c *Constraints) checkRefs(pack Pack, fv []byte) (err error) {
+
+	var refs Refs
+	if _, err = encoder.DeserializeRaw(fv, &refs); err != nil {
+		return
+	}
+
+	if refs.Hash == (cipher.SHA256{}) {
+		if c.Required == true {
+			return violation("required")
+		}
+		return
+	}
+
+	if c.MaxLen == 0 {
+		return
+	}
+
+	var ln int
+	switch ln, err = refs.Len(pack); err {
+	case nil:
+	case errNotLoaded:
+		return nil // checked when the Refs is loaded (Split)
+	default:
+		return
+	}
+
+	if ln > c.MaxLen {
+		return violation("length %d exceeds maxlen %d", ln, c.MaxLen)
+	}
+
+	return
+}
+
+func (c *Constraints) checkDynamic(pack Pack, fv []byte) (err error) {
+
+	var dr Dynamic
+	if _, err = encoder.DeserializeRaw(fv, &dr); err != nil {
+		return
+	}
+
+	if dr.IsBlank() == true {
+		if c.Required == true {
+			return violation("required")
+		}
+		return
+	}
+
+	if len(c.Schemas) == 0 {
+		return
+	}
+
+	var reg *Registry
+	if reg = pack.Registry(); reg == nil {
+		return ErrMissingRegistry
+	}
+
+	var sch Schema
+	if sch, err = reg.SchemaByReference(dr.Schema); err != nil {
+		return
+	}
+
+	for _, name := range c.Schemas {
+		if name == sch.Name() {
+			return
+		}
+	}
+
+	return violation("schema %q is not allowed", sch.Name())
+}
+
+func (c *Constraints) checkLength(fv []byte) (err error) {
+
+	var ln int
+	if ln, err = getLength(fv); err != nil {
+		return
+	}
+
+	if c.Required == true && ln == 0 {
+		return violation("required")
+	}
+
+	if c.MaxLen > 0 && ln > c.MaxLen {
+		return violation("length %d exceeds maxlen %d", ln, c.MaxLen)
+	}
+
+	return
+}
+
+func (c *Constraints) checkNumber(kind reflect.Kind, fv []byte) (err error) {
+
+	var n float64
+	if n, err = decodeNumber(kind, fv); err != nil {
+		return
+	}
+
+	if c.Required == true && n == 0 {
+		return violation("required")
+	}
+
+	if c.HasMin == true && n < c.Min {
+		return violation("%v is less then min %v", n, c.Min)
+	}
+
+	if c.HasMax == true && n > c.Max {
+		return violation("%v is greater then max %v", n, c.Max)
+	}
+
+	return
+}
+
+func decodeNumber(kind reflect.Kind, fv []byte) (n float64, err error) {
+
+	var typ reflect.Type
+
+	switch kind {
+	case reflect.Int8:
+		typ = reflect.TypeOf(int8(0))
+	case reflect.Uint8:
+		typ = reflect.TypeOf(uint8(0))
+	case reflect.Int16:
+		typ = reflect.TypeOf(int16(0))
+	case reflect.Uint16:
+		typ = reflect.TypeOf(uint16(0))
+	case reflect.Int32:
+		typ = reflect.TypeOf(int32(0))
+	case reflect.Uint32:
+		typ = reflect.TypeOf(uint32(0))
+	case reflect.Int64:
+		typ = reflect.TypeOf(int64(0))
+	case reflect.Uint64:
+		typ = reflect.TypeOf(uint64(0))
+	case reflect.Float32:
+		typ = reflect.TypeOf(float32(0))
+	case reflect.Float64:
+		typ = reflect.TypeOf(float64(0))
+	default:
+		return 0, fmt.Errorf("unexpected kind of number: %s", kind)
+	}
+
+	var val = reflect.New(typ)
+
+	if _, err = encoder.DeserializeRawToValue(fv, val); err != nil {
+		return
+	}
+
+	switch val = val.Elem(); kind {
+	case reflect.Float32, reflect.Float64:
+		n = val.Float()
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = float64(val.Uint())
+	default:
+		n = float64(val.Int())
+	}
+
+	return
+}
@@ -0,0 +1,227 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+type constrainedPost struct {
+	Head  string   `skyobject:"required,maxlen=5"`
+	Tags  []string `skyobject:"maxlen=2"`
+	Rate  int32    `skyobject:"min=-1,max=10"`
+	Reply Dynamic  `skyobject:"schemas=test.constrainedPost"`
+	Likes Refs     `skyobject:"schema=test.constrainedPost,maxlen=2"`
+}
+
+type constrainedNested struct {
+	Post constrainedPost
+}
+
+func constrainedRegistry() *Registry {
+	return NewRegistry(func(r *Reg) {
+		r.Register("test.constrainedPost", constrainedPost{})
+		r.Register("test.constrainedNested", constrainedNested{})
+		r.Register("test.User", TestUser{})
+	})
+}
+
+func TestFieldConstraints(t *testing.T) {
+
+	var (
+		reg      = constrainedRegistry()
+		sch, err = reg.SchemaByName("test.constrainedPost")
+	)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reg.HasConstraints() == false {
+		t.Error("HasConstraints returns false")
+	}
+
+	if testRegistry().HasConstraints() == true {
+		t.Error("HasConstraints returns true")
+	}
+
+	var c *Constraints
+
+	for _, fl := range sch.Fields() {
+
+		if c, err = FieldConstraints(fl); err != nil {
+			t.Fatal(err)
+		}
+
+		if c == nil {
+			t.Fatal("missing constraints of", fl.Name())
+		}
+
+		switch fl.Name() {
+		case "Head":
+			if c.Required == false || c.MaxLen != 5 {
+				t.Error("wrong constraints", c)
+			}
+		case "Tags", "Likes":
+			if c.Required == true || c.MaxLen != 2 {
+				t.Error("wrong constraints", c)
+			}
+		case "Rate":
+			if c.HasMin == false || c.Min != -1 || c.HasMax == false ||
+				c.Max != 10 {
+
+				t.Error("wrong constraints", c)
+			}
+		case "Reply":
+			if len(c.Schemas) != 1 || c.Schemas[0] != "test.constrainedPost" {
+				t.Error("wrong constraints", c)
+			}
+		}
+
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+
+		for _, val := range []interface{}{
+			struct {
+				A bool `skyobject:"required"`
+			}{},
+			struct {
+				A int32 `skyobject:"maxlen=1"`
+			}{},
+			struct {
+				A string `skyobject:"min=1"`
+			}{},
+			struct {
+				A int32 `skyobject:"min=2,max=1"`
+			}{},
+			struct {
+				A string `skyobject:"unknown"`
+			}{},
+			struct {
+				A Refs `skyobject:"schema=test.User,schemas=test.User"`
+			}{},
+		} {
+			func() {
+				defer shouldPanic(t)
+				NewRegistry(func(r *Reg) {
+					r.Register("test.User", TestUser{})
+					r.Register("test.Invalid", val)
+				})
+			}()
+		}
+
+	})
+
+}
+
+func TestCheckConstraints(t *testing.T) {
+
+	var (
+		reg  = constrainedRegistry()
+		pack = testPackReg(reg)
+	)
+
+	var check = func(
+		t *testing.T,
+		name string,
+		obj interface{},
+		field string,
+	) {
+		t.Helper()
+
+		var sch, err = reg.SchemaByName(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = CheckConstraints(pack, sch, encoder.Serialize(obj))
+
+		if field == "" {
+			if err != nil {
+				t.Error(err)
+			}
+			return
+		}
+
+		if err == nil {
+			t.Error("missing error, expected violation of", field)
+			return
+		}
+
+		var ce, ok = err.(*ConstraintError)
+		if ok == false {
+			t.Error("unexpected error:", err)
+			return
+		}
+
+		if ce.Field != field {
+			t.Errorf("wrong field %q, want %q: %v", ce.Field, field, err)
+		}
+	}
+
+	var valid = constrainedPost{
+		Head: "head",
+		Tags: []string{"one", "two"},
+		Rate: 10,
+	}
+
+	if err := valid.Likes.AppendValues(pack, constrainedPost{Head: "like"},
+		constrainedPost{Head: "like"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var postSch, _ = reg.SchemaByName("test.constrainedPost")
+	if err := valid.Reply.SetValue(pack, &constrainedPost{Head: "re"}); err != nil {
+		t.Fatal(err)
+	}
+	valid.Reply.Schema = postSch.Reference()
+
+	t.Run("valid", func(t *testing.T) {
+		check(t, "test.constrainedPost", valid, "")
+		check(t, "test.constrainedNested", constrainedNested{valid}, "")
+	})
+
+	t.Run("required", func(t *testing.T) {
+		var x = valid
+		x.Head = ""
+		check(t, "test.constrainedPost", x, "Head")
+		check(t, "test.constrainedNested", constrainedNested{x}, "Head")
+	})
+
+	t.Run("maxlen", func(t *testing.T) {
+		var x = valid
+		x.Head = "too long head"
+		check(t, "test.constrainedPost", x, "Head")
+
+		x = valid
+		x.Tags = []string{"one", "two", "three"}
+		check(t, "test.constrainedPost", x, "Tags")
+
+		x = valid
+		x.Likes = Refs{}
+		if err := x.Likes.AppendValues(pack, valid, valid, valid); err != nil {
+			t.Fatal(err)
+		}
+		check(t, "test.constrainedPost", x, "Likes")
+	})
+
+	t.Run("min max", func(t *testing.T) {
+		var x = valid
+		x.Rate = 11
+		check(t, "test.constrainedPost", x, "Rate")
+		x.Rate = -2
+		check(t, "test.constrainedPost", x, "Rate")
+	})
+
+	t.Run("schemas", func(t *testing.T) {
+		var x = valid
+		var usrSch, _ = reg.SchemaByName("test.User")
+		if err := x.Reply.SetValue(pack, &TestUser{Name: "Alice"}); err != nil {
+			t.Fatal(err)
+		}
+		x.Reply.Schema = usrSch.Reference()
+		check(t, "test.constrainedPost", x, "Reply")
+	})
+
+}
@@ -0,0 +1,201 @@
+package registry
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+// TagDegree returns degree of Refs declared by given
+// tag. For example
+//
+//     type Feed struct {
+//         Posts Refs `skyobject:"schema=app.Post,degree=256"`
+//     }
+//
+// The TagDegree returns zero if the tag doesn't declare
+// a degree. Since tags of fields are part of Schema, the
+// degree is recorded in Registry. A new or blank Refs of
+// such field uses the degree instead of default degree
+// of a Pack. The Refs gets the degree when it decoded or
+// encoded using methods of Ref, Refs and Dynamic (e.g.
+// Value, SetValue, AppendValues, etc)
+func TagDegree(tag reflect.StructTag) (degree Degree, err error) {
+
+	var skytag = tag.Get(Tag)
+
+	if skytag == "" {
+		return
+	}
+
+	for _, part := range strings.Split(skytag, ",") {
+
+		if strings.HasPrefix(part, "degree=") == false {
+			continue
+		}
+
+		var d int
+		if d, err = strconv.Atoi(strings.TrimPrefix(part, "degree=")); err != nil {
+			return 0, fmt.Errorf("invalid 'degree': %v", err)
+		}
+
+		if degree = Degree(d); degree.Validate() != nil {
+			return 0, fmt.Errorf("invalid 'degree': %d", d)
+		}
+
+		return
+	}
+
+	return
+}
+
+// FieldDegree returns degree of Refs declared for given
+// field or zero (see TagDegree). It returns an error if
+// the degree is invalid or the field is not Refs
+func FieldDegree(f Field) (degree Degree, err error) {
+
+	if degree, err = TagDegree(f.Tag()); err != nil || degree == 0 {
+		return
+	}
+
+	if f.Schema().ReferenceType() != ReferenceTypeSlice {
+		return 0, fmt.Errorf("'degree' can't be applied to %s", f.Schema())
+	}
+
+	return
+}
+
+// a degreeField is a Refs field
+// of a struct with declared degree
+type degreeField struct {
+	index  []int  // index of the field (for FieldByIndex)
+	degree Degree // declared degree
+}
+
+// cache of degreeFields by types (reflect.Type -> []degreeField);
+// the sync.Map is used, since the cache is read on every
+// decoding and written once per type
+var degreeFields sync.Map
+
+// typeDegreeFields returns Refs fields with declared
+// degree of given type, including fields of nested
+// structs (not referenced)
+func typeDegreeFields(typ reflect.Type) (dfs []degreeField) {
+
+	if typ.Kind() != reflect.Struct {
+		return
+	}
+
+	if cached, ok := degreeFields.Load(typ); ok == true {
+		return cached.([]degreeField)
+	}
+
+	var cached, _ = degreeFields.LoadOrStore(typ,
+		collectDegreeFields(typ, nil, nil))
+
+	return cached.([]degreeField)
+}
+
+func collectDegreeFields(
+	typ reflect.Type, //      : type of struct
+	index []int, //           : index of the struct
+	dfs []degreeField, //     : fields to append to
+) []degreeField {
+
+	for i := 0; i < typ.NumField(); i++ {
+
+		var sf = typ.Field(i)
+
+		if sf.PkgPath != "" || sf.Tag.Get("enc") == "-" {
+			continue // unexported or skipped field
+		}
+
+		var fi = append(append([]int{}, index...), i)
+
+		switch {
+		case sf.Type == typeOfRefs:
+			if degree, err := TagDegree(sf.Tag); err == nil && degree != 0 {
+				dfs = append(dfs, degreeField{fi, degree})
+			}
+		case sf.Type == typeOfRef, sf.Type == typeOfDynamic:
+		case sf.Type.Kind() == reflect.Struct:
+			dfs = collectDegreeFields(sf.Type, fi, dfs)
+		}
+
+	}
+
+	return dfs
+}
+
+// setDegrees sets declared degrees of Refs fields of
+// given decoded object; the obj is a pointer
+func setDegrees(obj interface{}) {
+
+	var val = reflect.ValueOf(obj)
+
+	if val.Kind() != reflect.Ptr || val.IsNil() == true {
+		return
+	}
+
+	val = val.Elem()
+
+	for _, df := range typeDegreeFields(val.Type()) {
+		var refs = val.FieldByIndex(df.index).Addr().Interface().(*Refs)
+		refs.fieldDegree = df.degree
+	}
+
+}
+
+// encodeValue encodes given object setting declared
+// degrees of its Refs fields; if a Refs has been created
+// with another degree before, then it will be rebuilt
+func encodeValue(pack Pack, obj interface{}) (b []byte, err error) {
+
+	var (
+		val = reflect.ValueOf(obj)
+		dfs = typeDegreeFields(reflect.Indirect(val).Type())
+	)
+
+	if len(dfs) == 0 {
+		return encoder.Serialize(obj), nil
+	}
+
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	} else {
+		var cp = reflect.New(val.Type()).Elem() // addressable copy
+		cp.Set(val)
+		val = cp
+	}
+
+	for _, df := range dfs {
+		var refs = val.FieldByIndex(df.index).Addr().Interface().(*Refs)
+		if err = refs.setFieldDegree(pack, df.degree); err != nil {
+			return
+		}
+	}
+
+	return encoder.Serialize(val.Interface()), nil
+}
+
+// set declared degree of the Refs
+func (r *Refs) setFieldDegree(pack Pack, degree Degree) (err error) {
+
+	if r.fieldDegree == degree {
+		return // already set
+	}
+
+	r.fieldDegree = degree
+
+	if r.refsNode == nil || r.degree == degree {
+		return // the initialize uses the declared degree
+	}
+
+	// the Refs has been created with another degree
+	// and the declared degree is unknown for it
+	return r.SetDegree(pack, degree)
+}
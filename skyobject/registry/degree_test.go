@@ -0,0 +1,232 @@
+package registry
+
+import (
+	"reflect"
+	"testing"
+)
+
+type TestWideGroup struct {
+	Name    string
+	Members Refs `skyobject:"schema=test.User,degree=16"`
+	Nested  struct {
+		Members Refs `skyobject:"schema=test.User,degree=8"`
+	}
+}
+
+func testWideRegistry() *Registry {
+	return NewRegistry(func(r *Reg) {
+		r.Register("test.User", TestUser{})
+		r.Register("test.WideGroup", TestWideGroup{})
+	})
+}
+
+func TestTagDegree(t *testing.T) {
+
+	for _, tt := range []struct {
+		tag    reflect.StructTag
+		degree Degree
+		err    bool
+	}{
+		{``, 0, false},
+		{`skyobject:"schema=test.User"`, 0, false},
+		{`skyobject:"schema=test.User,degree=256"`, 256, false},
+		{`skyobject:"degree=2,schema=test.User"`, 2, false},
+		{`skyobject:"schema=test.User,degree=1"`, 0, true},
+		{`skyobject:"schema=test.User,degree=x"`, 0, true},
+		{`skyobject:"schema=test.User,degree=2048"`, 0, true},
+	} {
+
+		var degree, err = TagDegree(tt.tag)
+
+		if tt.err == true {
+			if err == nil {
+				t.Errorf("missing error for %q", tt.tag)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Error(err)
+		} else if degree != tt.degree {
+			t.Errorf("wrong degree %d for %q, want %d", degree, tt.tag,
+				tt.degree)
+		}
+
+	}
+
+	t.Run("register", func(t *testing.T) {
+
+		var reg = testWideRegistry()
+
+		var sch, err = reg.SchemaByName("test.WideGroup")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var degree Degree
+		if degree, err = FieldDegree(sch.Fields()[1]); err != nil {
+			t.Fatal(err)
+		} else if degree != 16 {
+			t.Error("wrong degree recorded:", degree)
+		}
+
+		// decoded registry keeps the degree
+		if reg, err = DecodeRegistry(reg.Encode()); err != nil {
+			t.Fatal(err)
+		}
+
+		if sch, err = reg.SchemaByName("test.WideGroup"); err != nil {
+			t.Fatal(err)
+		}
+
+		if degree, err = FieldDegree(sch.Fields()[1]); err != nil {
+			t.Fatal(err)
+		} else if degree != 16 {
+			t.Error("wrong degree recorded:", degree)
+		}
+
+		for _, val := range []interface{}{
+			struct {
+				A string `skyobject:"degree=16"`
+			}{},
+			struct {
+				A Refs `skyobject:"schema=test.User,degree=1"`
+			}{},
+		} {
+			func() {
+				defer shouldPanic(t)
+				NewRegistry(func(r *Reg) {
+					r.Register("test.User", TestUser{})
+					r.Register("test.Invalid", val)
+				})
+			}()
+		}
+
+	})
+
+}
+
+func testRefsDegree(t *testing.T, pack Pack, refs *Refs, want Degree) {
+	t.Helper()
+
+	var degree, err = refs.Degree(pack)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if degree != want {
+		t.Errorf("wrong degree %d, want %d", degree, want)
+	}
+}
+
+func TestRefs_fieldDegree(t *testing.T) {
+
+	var pack = testPackReg(testWideRegistry()) // default degree is 3
+
+	t.Run("blank", func(t *testing.T) {
+
+		var (
+			ref Ref
+			grp TestWideGroup
+		)
+
+		if err := ref.SetValue(pack, &grp); err != nil {
+			t.Fatal(err)
+		}
+
+		var dec TestWideGroup
+		if err := ref.Value(pack, &dec); err != nil {
+			t.Fatal(err)
+		}
+
+		testRefsDegree(t, pack, &dec.Members, 16)
+		testRefsDegree(t, pack, &dec.Nested.Members, 8)
+
+		dec.Members.Clear()
+
+		testRefsDegree(t, pack, &dec.Members, 16)
+
+	})
+
+	t.Run("new", func(t *testing.T) {
+
+		var grp TestWideGroup
+
+		for i := 0; i < 20; i++ {
+			var err = grp.Members.AppendValues(pack, TestUser{Age: uint32(i)})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		testRefsDegree(t, pack, &grp.Members, 3) // not known yet
+
+		var (
+			dr  Dynamic
+			dec TestWideGroup
+		)
+
+		// by value
+		if err := dr.SetValue(pack, grp); err != nil {
+			t.Fatal(err)
+		}
+
+		testRefsDegree(t, pack, &grp.Members, 3) // not changed
+
+		dr.Schema = SchemaRef{1} // any, not used
+
+		if err := dr.Value(pack, &dec); err != nil {
+			t.Fatal(err)
+		}
+
+		testRefsDegree(t, pack, &dec.Members, 16)
+
+		if ln, err := dec.Members.Len(pack); err != nil {
+			t.Fatal(err)
+		} else if ln != 20 {
+			t.Error("wrong length", ln)
+		}
+
+		// by pointer
+		if err := dr.SetValue(pack, &grp); err != nil {
+			t.Fatal(err)
+		}
+
+		testRefsDegree(t, pack, &grp.Members, 16) // rebuilt
+
+	})
+
+	t.Run("explicit", func(t *testing.T) {
+
+		var (
+			ref Ref
+			grp TestWideGroup
+		)
+
+		if err := ref.SetValue(pack, &grp); err != nil {
+			t.Fatal(err)
+		}
+
+		var dec TestWideGroup
+		if err := ref.Value(pack, &dec); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := dec.Members.SetDegree(pack, 4); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := dec.Members.AppendValues(pack, TestUser{}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ref.SetValue(pack, &dec); err != nil {
+			t.Fatal(err)
+		}
+
+		testRefsDegree(t, pack, &dec.Members, 4) // kept
+
+	})
+
+}
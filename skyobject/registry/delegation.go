@@ -0,0 +1,226 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+// A Certificate authorizes a delegate key to sign Root
+// objects on behalf of issuer. The issuer is a feed or
+// another delegate (sub-delegation). The Certificate
+// can expire. Zero Expire means the Certificate never
+// expires. See also Revocation and Delegation
+type Certificate struct {
+	Issuer   cipher.PubKey // feed or delegate
+	Delegate cipher.PubKey // authorized key
+	Expire   int64         // unix nano, zero is never
+	Sig      cipher.Sig    // signature of the issuer
+}
+
+// hash of signed fields of the Certificate
+func (c *Certificate) hash() cipher.SHA256 {
+	return cipher.SumSHA256(encoder.Serialize(struct {
+		Issuer   cipher.PubKey
+		Delegate cipher.PubKey
+		Expire   int64
+	}{c.Issuer, c.Delegate, c.Expire}))
+}
+
+// NewCertificate creates Certificate signed by given
+// secret key of the issuer. Use zero time for never
+// expiring Certificate
+func NewCertificate(
+	sk cipher.SecKey, //          : issuer
+	delegate cipher.PubKey, //    : authorized key
+	expire time.Time, //          : expiration time
+) (
+	c *Certificate, //            : the Certificate
+	err error, //                 : an error
+) {
+
+	c = new(Certificate)
+
+	if c.Issuer, err = cipher.PubKeyFromSecKey(sk); err != nil {
+		return nil, err
+	}
+
+	c.Delegate = delegate
+
+	if expire.IsZero() == false {
+		c.Expire = expire.UnixNano()
+	}
+
+	if c.Sig, err = cipher.SignHash(c.hash(), sk); err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+// Verify signature of the Certificate
+func (c *Certificate) Verify() (err error) {
+	return cipher.VerifyPubKeySignedHash(c.Issuer, c.Sig, c.hash())
+}
+
+// IsExpired returns true if the Certificate
+// is expired at given time (unix nano)
+func (c *Certificate) IsExpired(t int64) bool {
+	return c.Expire != 0 && t > c.Expire
+}
+
+// A Revocation revokes all Certificates issued by
+// the Issuer for the Delegate. All Root objects signed
+// using such Certificate are not valid, regardless of
+// their Time. The Time of a Root is chosen by signer,
+// thus a stolen delegate key could be used to sign
+// Roots with past time and no time can be trusted.
+// Roots signed by the Delegate before the Revocation
+// should be re-signed by the issuer if they have to
+// be kept by other nodes
+type Revocation struct {
+	Issuer   cipher.PubKey // feed or delegate
+	Delegate cipher.PubKey // revoked key
+	Sig      cipher.Sig    // signature of the issuer
+}
+
+// hash of signed fields of the Revocation
+func (r *Revocation) hash() cipher.SHA256 {
+	return cipher.SumSHA256(encoder.Serialize(struct {
+		Issuer   cipher.PubKey
+		Delegate cipher.PubKey
+	}{r.Issuer, r.Delegate}))
+}
+
+// NewRevocation creates Revocation signed by given
+// secret key of issuer of revoked Certificate
+func NewRevocation(
+	sk cipher.SecKey, //          : issuer
+	delegate cipher.PubKey, //    : revoked key
+) (
+	r *Revocation, //             : the Revocation
+	err error, //                 : an error
+) {
+
+	r = new(Revocation)
+
+	if r.Issuer, err = cipher.PubKeyFromSecKey(sk); err != nil {
+		return nil, err
+	}
+
+	r.Delegate = delegate
+
+	if r.Sig, err = cipher.SignHash(r.hash(), sk); err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+// Verify signature of the Revocation
+func (r *Revocation) Verify() (err error) {
+	return cipher.VerifyPubKeySignedHash(r.Issuer, r.Sig, r.hash())
+}
+
+// A Delegation travels with a Root and contains chain of
+// Certificates from feed of the Root to key that signs
+// the Root, and Revocations issued by the feed. The
+// Delegation is appended to encoded Root (if it's not
+// blank, see Encode method of the Root)
+type Delegation struct {
+	// Certs is chain of Certificates. The first is
+	// issued by the feed, and every next is issued
+	// by delegate of previous one. The last delegate
+	// signs the Root. A Root signed by the feed has
+	// no Certificates
+	Certs []Certificate
+	// Revocations issued by the feed or by delegates.
+	// Nodes that receive the Root remember them. A
+	// Revocation can be published once, and it's
+	// not necessary to keep it in next Roots
+	Revocations []Revocation
+}
+
+// IsBlank returns true if the Delegation is blank
+func (d *Delegation) IsBlank() bool {
+	return d == nil || (len(d.Certs) == 0 && len(d.Revocations) == 0)
+}
+
+// Signer returns key that must sign the Root. It's the
+// feed or last delegate in the chain of Certificates
+func (r *Root) Signer() (pk cipher.PubKey) {
+	if d := r.Delegation; d != nil && len(d.Certs) > 0 {
+		return d.Certs[len(d.Certs)-1].Delegate
+	}
+	return r.Pub
+}
+
+// VerifyDelegation verifies chain of Certificates and
+// Revocations of the Root. The chain must start from
+// the feed of the Root, and Certificates must not be
+// expired at Time of the Root. The VerifyDelegation
+// doesn't verify signature of the Root (see Signer)
+// and doesn't check Revocations known by a node
+func (r *Root) VerifyDelegation() (err error) {
+
+	if r.Delegation.IsBlank() == true {
+		return
+	}
+
+	var issuer = r.Pub
+
+	for i := range r.Delegation.Certs {
+
+		var c = &r.Delegation.Certs[i]
+
+		if c.Issuer != issuer {
+			return fmt.Errorf("certificate %d: unexpected issuer %s", i,
+				c.Issuer.Hex()[:7])
+		}
+
+		if err = c.Verify(); err != nil {
+			return fmt.Errorf("certificate %d: %v", i, err)
+		}
+
+		if c.IsExpired(r.Time) == true {
+			return fmt.Errorf("certificate %d: expired", i)
+		}
+
+		issuer = c.Delegate
+
+	}
+
+	for i := range r.Delegation.Revocations {
+		if err = r.Delegation.Revocations[i].Verify(); err != nil {
+			return fmt.Errorf("revocation %d: %v", i, err)
+		}
+	}
+
+	return
+}
+
+// IsRevokedBy returns true if given Revocation revokes
+// one of Certificates of the Root. The Time of the Root
+// is not taken into account (see Revocation)
+func (r *Root) IsRevokedBy(rev *Revocation) bool {
+
+	if r.Delegation == nil {
+		return false
+	}
+
+	for _, c := range r.Delegation.Certs {
+		if c.Issuer == rev.Issuer && c.Delegate == rev.Delegate {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ErrInvalidDelegation returned by DecodeRoot if
+// encoded Root has invalid trailing Delegation (or
+// Envelope, since they are encoded together)
+var ErrInvalidDelegation = errors.New("invalid encoded Delegation")
@@ -0,0 +1,141 @@
+package registry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+func TestRoot_Delegation(t *testing.T) {
+
+	var (
+		fpk, fsk = cipher.GenerateKeyPair() // feed
+		dpk, dsk = cipher.GenerateKeyPair() // delegate
+		spk, _   = cipher.GenerateKeyPair() // sub-delegate
+	)
+
+	var first, err = NewCertificate(fsk, dpk, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var second *Certificate
+	if second, err = NewCertificate(dsk, spk, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var r = new(Root)
+	r.Pub = fpk
+	r.Nonce = 1
+	r.Time = time.Now().UnixNano()
+
+	var blank = r.Encode()
+
+	r.Delegation = &Delegation{Certs: []Certificate{*first, *second}}
+
+	if r.Signer() != spk {
+		t.Error("wrong signer")
+	}
+
+	if err = r.VerifyDelegation(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("encode", func(t *testing.T) {
+
+		var val = r.Encode()
+
+		if len(val) <= len(blank) {
+			t.Fatal("the Delegation is not encoded")
+		}
+
+		var x *Root
+		if x, err = DecodeRoot(val); err != nil {
+			t.Fatal(err)
+		}
+
+		if x.Signer() != spk || len(x.Delegation.Certs) != 2 {
+			t.Error("wrong decoded Delegation")
+		}
+
+		if x, err = DecodeRoot(blank); err != nil {
+			t.Fatal(err)
+		} else if x.Delegation != nil {
+			t.Error("unexpected Delegation")
+		}
+
+	})
+
+	t.Run("broken chain", func(t *testing.T) {
+
+		var x = *r
+		x.Delegation = &Delegation{Certs: []Certificate{*second}}
+
+		if err = x.VerifyDelegation(); err == nil {
+			t.Error("missing error")
+		}
+
+	})
+
+	t.Run("expired", func(t *testing.T) {
+
+		var x = *r
+		x.Time = time.Now().Add(2 * time.Hour).UnixNano()
+
+		if err = x.VerifyDelegation(); err == nil {
+			t.Error("missing error")
+		}
+
+	})
+
+	t.Run("forged", func(t *testing.T) {
+
+		var x = *r
+		var forged = *first
+		forged.Delegate = spk
+		x.Delegation = &Delegation{Certs: []Certificate{forged}}
+
+		if err = x.VerifyDelegation(); err == nil {
+			t.Error("missing error")
+		}
+
+	})
+
+	t.Run("revoked", func(t *testing.T) {
+
+		var rev *Revocation
+		if rev, err = NewRevocation(fsk, dpk); err != nil {
+			t.Fatal(err)
+		}
+
+		if err = rev.Verify(); err != nil {
+			t.Fatal(err)
+		}
+
+		var x = *r
+		x.Time = time.Now().Add(time.Second).UnixNano()
+
+		if x.IsRevokedBy(rev) == false {
+			t.Error("not revoked")
+		}
+
+		// the Time is chosen by signer
+		x.Time = 0
+
+		if x.IsRevokedBy(rev) == false {
+			t.Error("past is not revoked")
+		}
+
+		// another delegate
+		if rev, err = NewRevocation(fsk, spk); err != nil {
+			t.Fatal(err)
+		}
+
+		if x.IsRevokedBy(rev) == true {
+			t.Error("revoked by revocation of another delegate")
+		}
+
+	})
+
+}
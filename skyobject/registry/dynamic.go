@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/skycoin/skycoin/src/cipher"
-	"github.com/skycoin/skycoin/src/cipher/encoder"
 )
 
 // A Dynamic represents reference to object
@@ -78,8 +77,13 @@ func (d *Dynamic) SetValue(
 		return
 	}
 
+	var val []byte
+	if val, err = encodeValue(pack, obj); err != nil {
+		return
+	}
+
 	var hash cipher.SHA256
-	if hash, err = pack.Add(encoder.Serialize(obj)); err != nil {
+	if hash, err = pack.Add(val); err != nil {
 		return
 	}
 
@@ -151,6 +155,10 @@ func (d *Dynamic) Walk(
 
 // Split used by the node package to fill the Dynamic.
 func (d *Dynamic) Split(s Splitter) {
+	d.split(s, 1)
+}
+
+func (d *Dynamic) split(s Splitter, depth int) {
 
 	if d.IsValid() == false {
 		s.Fail(ErrInvalidDynamicReference)
@@ -168,6 +176,10 @@ func (d *Dynamic) Split(s Splitter) {
 		return
 	}
 
-	splitSchemaHash(s, sch, d.Hash)
+	if skip(s, sch, depth, -1, 0) == true {
+		return
+	}
+
+	splitSchemaHash(s, sch, d.Hash, depth)
 
 }
@@ -33,3 +33,6 @@ var (
 	ErrStopIteration   = errors.New("stop iteration")
 	ErrMissingRegistry = errors.New("missing registry")
 )
+
+// object is not loaded yet (internal, see checkPack)
+var errNotLoaded = errors.New("not loaded")
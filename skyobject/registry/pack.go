@@ -129,7 +129,11 @@ func get(
 		return
 	}
 
-	_, err = encoder.DeserializeRaw(val, obj)
+	if _, err = encoder.DeserializeRaw(val, obj); err != nil {
+		return
+	}
+
+	setDegrees(obj) // declared degrees of Refs
 
 	return
 }
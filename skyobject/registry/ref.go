@@ -4,7 +4,6 @@ import (
 	"reflect"
 
 	"github.com/skycoin/skycoin/src/cipher"
-	"github.com/skycoin/skycoin/src/cipher/encoder"
 )
 
 //
@@ -39,15 +38,7 @@ func (r *Ref) Value(pack Pack, obj interface{}) (err error) {
 		return ErrReferenceRepresentsNil
 	}
 
-	// obtain encoded object
-	var val []byte
-	if val, err = pack.Get(r.Hash); err != nil {
-		return
-	}
-
-	_, err = encoder.DeserializeRaw(val, obj)
-
-	return
+	return get(pack, r.Hash, obj)
 }
 
 // SetValue replacing the Ref with new. Use nil-interface{} to clear
@@ -63,8 +54,13 @@ func (r *Ref) SetValue(
 		return
 	}
 
+	var val []byte
+	if val, err = encodeValue(pack, obj); err != nil {
+		return
+	}
+
 	var hash cipher.SHA256
-	if hash, err = pack.Add(encoder.Serialize(obj)); err != nil {
+	if hash, err = pack.Add(val); err != nil {
 		return
 	}
 
@@ -125,5 +121,14 @@ func (r *Ref) Walk(
 
 // Split used by the node package to fill the Ref
 func (r *Ref) Split(s Splitter, el Schema) {
-	splitSchemaHashAsync(s, el, r.Hash)
+	r.split(s, el, 1)
+}
+
+func (r *Ref) split(s Splitter, el Schema, depth int) {
+
+	if r.Hash == (cipher.SHA256{}) || skip(s, el, depth, -1, 0) == true {
+		return
+	}
+
+	splitSchemaHashAsync(s, el, r.Hash, depth)
 }
@@ -34,13 +34,14 @@ import (
 //
 // There is a note about the degree. Since all
 // blank Refs are equal, then the degree can't
-// be kept if the Refs is blank. E.g. if you are
-// using non-default degree and want to keep the
-// degree for a while, then you have to handle
-// it yourself. It's because, all blank Refs are
-// not stored in DB and have blank hash. Since the
-// hash is blank, then the Refs can't store anything
-// in DB
+// be kept if the Refs is blank. It's because, all
+// blank Refs are not stored in DB and have blank
+// hash. Since the hash is blank, then the Refs
+// can't store anything in DB. But it's possible
+// to declare degree of a Refs field using tag
+// (see TagDegree). The declared degree is kept
+// by Registry and new and blank Refs of the field
+// use it
 //
 // The Refs is not thread safe
 type Refs struct {
@@ -51,6 +52,8 @@ type Refs struct {
 	depth  int    `enc:"-"` // depth - 1
 	degree Degree `enc:"-"` // degree
 
+	fieldDegree Degree `enc:"-"` // declared degree (see TagDegree)
+
 	refsIndex `enc:"-"` // hash-table index
 	*refsNode `enc:"-"` // leafs, branches, mods and length (pointer)
 
@@ -88,7 +91,9 @@ func (r *Refs) initialize(pack Pack) (err error) {
 	r.mods = loadedMod     // mark as loaded
 	r.flags = pack.Flags() // keep current flags
 
-	r.degree = pack.Degree() // use default degree
+	if r.degree = r.fieldDegree; r.degree == 0 {
+		r.degree = pack.Degree() // use default degree
+	}
 
 	if err = r.degree.Validate(); err != nil {
 		panic("invalid Degree of the Pack") // test the Pack
@@ -103,7 +108,7 @@ func (r *Refs) initialize(pack Pack) (err error) {
 	}
 
 	var er encodedRefs
-	if err = get(pack, r.Hash, &er); err != nil {
+	if r.refsNode.plain, err = getNode(pack, r.Hash, &er); err != nil {
 		return // get or decoding error
 	}
 
@@ -186,7 +191,7 @@ func (r *Refs) SetDegree(pack Pack, degree Degree) (err error) {
 
 	nr.iterators = r.iterators // copy iterators
 
-	*r = *nr
+	r.replace(nr)
 
 	return
 }
@@ -233,9 +238,9 @@ func (r *Refs) Reset() (err error) {
 		return ErrRefsIterating // can't reset during iterating
 	}
 
-	var hash = r.Hash // }
-	*r = Refs{}       // }reset
-	r.Hash = hash     // }
+	var hash = r.Hash  // }
+	r.replace(&Refs{}) // }reset
+	r.Hash = hash      // }
 
 	return
 }
@@ -591,13 +596,18 @@ func (r *Refs) updateHash(pack Pack) (err error) {
 	val := r.encode()
 	hash := cipher.SumSHA256(val)
 
-	if r.Hash != hash {
+	// the Pack can change value and its hash, thus
+	// hash of the encoded value is kept by the root
+	// node (its own hash is always blank)
 
-		if err = pack.Set(hash, val); err != nil {
+	if r.Hash != hash && r.refsNode.plain != hash {
+
+		var key cipher.SHA256
+		if key, err = pack.Add(val); err != nil {
 			return // saving error
 		}
 
-		r.Hash = hash
+		r.Hash, r.refsNode.plain = key, hash
 
 	}
 
@@ -652,7 +662,11 @@ func (r *Refs) SetValueByIndex(
 	var hash cipher.SHA256
 
 	if isNil(obj) == false {
-		if hash, err = pack.Add(encoder.Serialize(obj)); err != nil {
+		var b []byte
+		if b, err = encodeValue(pack, obj); err != nil {
+			return
+		}
+		if hash, err = pack.Add(b); err != nil {
 			return
 		}
 	}
@@ -1455,7 +1469,7 @@ func (r *Refs) Append(
 			return // error
 		}
 
-		*r = *nr // replace this Refs with new extended
+		r.replace(nr) // replace this Refs with new extended
 
 		return // done
 
@@ -1504,7 +1518,12 @@ func (r *Refs) AppendValues(
 
 		} else {
 
-			if hash, err = pack.Add(encoder.Serialize(val)); err != nil {
+			var b []byte
+			if b, err = encodeValue(pack, val); err != nil {
+				return
+			}
+
+			if hash, err = pack.Add(b); err != nil {
 				return
 			}
 
@@ -1592,7 +1611,7 @@ func (r *Refs) AppendHashes(
 		}
 
 		nr.iterators = r.iterators // copy iterators
-		*r = *nr                   // replace this Refs with new extended
+		r.replace(nr)              // replace this Refs with new extended
 
 		return // done
 
@@ -1618,9 +1637,18 @@ func (r *Refs) AppendHashes(
 
 }
 
-// Clear the Refs making it blank
+// Clear the Refs making it blank. The Clear
+// keeps declared degree (see TagDegree)
 func (r *Refs) Clear() {
-	*r = Refs{}
+	r.replace(&Refs{})
+}
+
+// replace the Refs with given one
+// keeping the declared degree
+func (r *Refs) replace(nr *Refs) {
+	var fd = r.fieldDegree
+	*r = *nr
+	r.fieldDegree = fd
 }
 
 // Rebuild the Refs if need. The Refs can contain
@@ -1664,7 +1692,7 @@ func (r *Refs) Rebuild(
 		if slice, err = r.Slice(pack, 0, r.length); err != nil {
 			return
 		}
-		*r = *slice // replace
+		r.replace(slice) // replace
 	} else {
 		err = r.walkUpdating(pack)
 	}
@@ -14,6 +14,7 @@ type refsElement struct {
 // a branch of the Refs
 type refsNode struct {
 	hash   cipher.SHA256 // hash of this node
+	plain  cipher.SHA256 // hash of encoded node (see Pack.Add)
 	length int           // length of this subtree
 	mods   refsMod       // unsaved modifications
 
@@ -169,7 +170,7 @@ func (r *Refs) loadNode(
 ) {
 
 	var ern encodedRefsNode // encoded branch
-	if err = get(pack, rn.hash, &ern); err != nil {
+	if rn.plain, err = getNode(pack, rn.hash, &ern); err != nil {
 		return // get or decoding error
 	}
 
@@ -178,6 +179,31 @@ func (r *Refs) loadNode(
 	return r.loadSubtree(pack, rn, ern.Elements, depth) // depth is the same
 }
 
+// getNode gets and decodes encoded Refs or node, it
+// returns hash of the encoded value, since a Pack can
+// change values and their hashes (encrypted feeds)
+func getNode(
+	pack Pack, //            : pack to get from
+	key cipher.SHA256, //    : key of the value
+	obj interface{}, //      : pointer to object
+) (
+	plain cipher.SHA256, //  : hash of the encoded value
+	err error, //            : getting or decoding error
+) {
+
+	var val []byte
+
+	if val, err = pack.Get(key); err != nil {
+		return
+	}
+
+	if _, err = encoder.DeserializeRaw(val, obj); err != nil {
+		return
+	}
+
+	return cipher.SumSHA256(val), nil
+}
+
 // isLoaded returns true if the node is loaded
 func (r *refsNode) isLoaded() bool {
 	return r.mods&loadedMod != 0
@@ -354,7 +380,7 @@ func (r *refsNode) updateHash(
 	// get hash
 	var hash = cipher.SumSHA256(val)
 
-	if hash == r.hash {
+	if hash == r.hash || hash == r.plain {
 		return // the hash is the same
 	}
 
@@ -363,8 +389,11 @@ func (r *refsNode) updateHash(
 	// with depth and degree
 	if r.upper != nil {
 
-		// save the node
-		if err = pack.Set(hash, val); err != nil {
+		// save the node; the Add used instead of the
+		// Set, because a Pack can change the value
+		// (encrypted feeds) and its hash
+		var key cipher.SHA256
+		if key, err = pack.Add(val); err != nil {
 			return
 		}
 
@@ -372,7 +401,7 @@ func (r *refsNode) updateHash(
 		// part of the Refs and set it in
 		// other cases
 
-		r.hash = hash // set the  hash
+		r.hash, r.plain = key, hash // set the  hash
 
 	}
 
@@ -2013,6 +2013,68 @@ func TestRefs_SetHashByIndex(t *testing.T) {
 
 }
 
+// a sealingPack changes keys of values (like
+// encrypted feeds) and counts calls of the Add
+type sealingPack struct {
+	*dummyPack
+	adds int
+}
+
+func (s *sealingPack) Add(val []byte) (key cipher.SHA256, err error) {
+	s.adds++
+	key = cipher.SumSHA256(append([]byte("sealed"), val...))
+	err = s.Set(key, val)
+	return
+}
+
+func TestRefs_updateHash_sealed(t *testing.T) {
+
+	var (
+		pack  = &sealingPack{dummyPack: getTestPack()}
+		users = getHashList(getTestUsers(10))
+
+		r   Refs
+		err error
+	)
+
+	pack.AddFlags(EntireRefs)
+
+	if err = r.AppendHashes(pack, users...); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, reset := range []bool{false, true} {
+
+		if reset == true {
+			r.Reset()
+			if err = r.Init(pack); err != nil { // load
+				t.Fatal(err)
+			}
+		}
+
+		pack.adds = 0
+
+		// update hashes of all nodes, that are not changed
+		if err = r.walkUpdatingSlice(pack); err != nil {
+			t.Fatal(err)
+		}
+
+		if pack.adds != 0 {
+			t.Error("unchanged nodes saved again:", pack.adds, reset)
+		}
+
+	}
+
+	if err = r.SetHashByIndex(pack, 5, hashByNumber(100)); err != nil {
+		t.Fatal(err)
+	}
+
+	if pack.adds == 0 {
+		t.Error("changed nodes are not saved")
+	}
+
+}
+
 func TestRefs_SetValueByIndex(t *testing.T) {
 	// SetValueByIndex(pack Pack, i int, obj interface{}) (err error)
 
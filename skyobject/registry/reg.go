@@ -1,6 +1,7 @@
 package registry
 
 import (
+	"fmt"
 	"reflect"
 )
 
@@ -127,7 +128,20 @@ func (r *Reg) getSchema(typ reflect.Type) Schema {
 			if sf.Tag.Get("enc") == "-" || sf.PkgPath != "" || sf.Name == "_" {
 				continue
 			}
-			ss.fields = append(ss.fields, r.getField(sf))
+
+			fl := r.getField(sf)
+
+			// check out constraints of the field
+			if _, err := FieldConstraints(fl); err != nil {
+				panic(fmt.Sprintf("field %s of %s: %v", sf.Name, typ, err))
+			}
+
+			// and declared degree
+			if _, err := FieldDegree(fl); err != nil {
+				panic(fmt.Sprintf("field %s of %s: %v", sf.Name, typ, err))
+			}
+
+			ss.fields = append(ss.fields, fl)
 
 		}
 
@@ -20,6 +20,8 @@ type Registry struct {
 
 	ref RegistryRef // reference to the registry
 
+	constrained bool // has constraints (see Constraints)
+
 	reg map[string]Schema    // by name
 	srf map[SchemaRef]Schema // by reference (for Dynamic references)
 
@@ -225,10 +227,24 @@ func (r *Registry) finialize() {
 		r.srf[sch.Reference()] = sch
 	}
 
+	// check out constraints
+	for _, sch := range r.reg {
+		if r.constrained = hasConstraints(sch); r.constrained == true {
+			break
+		}
+	}
+
 	encoded := r.Encode()
 	r.ref = RegistryRef(cipher.SumSHA256(encoded))
 }
 
+// HasConstraints returns true if at least one field
+// of at least one Schema of the Registry has validation
+// constraints (see Constraints)
+func (r *Registry) HasConstraints() bool {
+	return r.constrained
+}
+
 // TagSchemaName returns schema name from given reflect.StructTag.
 // E.g. it returns "User" if tag is `skyobject:"schema=User" json:"blah"`.
 // It returns error if given tag doesn't contain the `skyobject:"schema=XXX"`
@@ -28,6 +28,11 @@ type Root struct {
 	Sig  cipher.Sig    `enc:"-"` // signature
 	Hash cipher.SHA256 `enc:"-"` // hash of this encoded Root
 
+	// Stamp is optional proof-of-work stamp
+	// of the Hash (see StampBits). Like the
+	// Sig, it's not a part of the Root
+	Stamp uint64 `enc:"-"`
+
 	// Prev is hash of previous Root, the Prev can
 	// be blank is Seq of the Root is zero, that
 	// means the Root is first in chain
@@ -39,11 +44,58 @@ type Root struct {
 	// of a Root, and this field is machine
 	// local
 	IsFull bool `enc:"-"`
+
+	// IsPartial means that this Root object
+	// has been filled partially by this machine
+	// using a filter. Some subtrees of the Root
+	// are missing. The field is machine local
+	// too
+	IsPartial bool `enc:"-"`
+
+	// Delegation is chain of Certificates (and
+	// Revocations) if the Root is signed by a
+	// delegate. It's encoded after the Root
+	Delegation *Delegation `enc:"-"`
+
+	// Envelope is hash of key-envelope of encrypted
+	// feed. If the Envelope is not blank, then all
+	// objects of the Root are encrypted. It's
+	// encoded after the Root
+	Envelope cipher.SHA256 `enc:"-"`
+}
+
+// trailer of encoded Root, the trailer appended
+// to encoded Root only if it's not blank, thus
+// Roots without delegation and encryption are
+// encoded as they were encoded before
+type rootTrailer struct {
+	Delegation Delegation
+	Envelope   cipher.SHA256
+}
+
+// IsEncrypted returns true if objects
+// of the Root are encrypted
+func (r *Root) IsEncrypted() bool {
+	return r.Envelope != (cipher.SHA256{})
 }
 
 // Encode the Root
-func (r *Root) Encode() []byte {
-	return encoder.Serialize(r)
+func (r *Root) Encode() (val []byte) {
+
+	val = encoder.Serialize(r)
+
+	if r.Delegation.IsBlank() == true && r.IsEncrypted() == false {
+		return
+	}
+
+	var rt rootTrailer
+
+	if r.Delegation != nil {
+		rt.Delegation = *r.Delegation
+	}
+	rt.Envelope = r.Envelope
+
+	return append(val, encoder.Serialize(&rt)...)
 }
 
 // Short return string like "1a2ef33/1234/2" (pub_key/nonce/seq),
@@ -81,9 +133,26 @@ func (r *Root) String() string {
 // DecodeRoot decodes and encoded Root object
 func DecodeRoot(val []byte) (r *Root, err error) {
 	r = new(Root)
-	if _, err = encoder.DeserializeRaw(val, r); err != nil {
-		r = nil
+
+	var n, derr = encoder.DeserializeRaw(val, r)
+	if derr != nil {
+		return nil, derr
 	}
+
+	if int(n) < len(val) {
+
+		var rt rootTrailer
+		if _, err = encoder.DeserializeRaw(val[n:], &rt); err != nil {
+			return nil, ErrInvalidDelegation
+		}
+
+		if rt.Delegation.IsBlank() == false {
+			r.Delegation = &rt.Delegation
+		}
+		r.Envelope = rt.Envelope
+
+	}
+
 	return
 }
 
@@ -58,18 +58,139 @@ type Splitter interface {
 	Go(func())
 }
 
+// An Estimator is optional interface of a Splitter.
+// The Split of Refs reports number of elements it is
+// going to split (positive n) and number of elements
+// it has reached (negative n). The Estimator used
+// to estimate total number of objects of a Root
+type Estimator interface {
+	Expect(n int)
+}
+
+// report to Estimator if the s implements it
+func expect(s Splitter, n int) {
+	if e, ok := s.(Estimator); ok == true && n != 0 {
+		e.Expect(n)
+	}
+}
+
+// A Filter is optional interface of a Splitter used
+// to fill a Root partially. The Skip called before
+// splitting an object referenced by Ref, Refs or
+// Dynamic. The depth is number of references from
+// the Root to the object (objects of Dynamic references
+// of the Root have depth 1). For an element of Refs the
+// index is index of the element and the length is
+// length of the Refs. For Ref, Dynamic and Refs itself
+// the index is -1 and the length is zero. If the Skip
+// returns true, then the object with its subtree is
+// not split
+type Filter interface {
+	Skip(sch Schema, depth, index, length int) (skip bool)
+}
+
+// ask Filter if the s implements it
+func skip(s Splitter, sch Schema, depth, index, length int) bool {
+	if f, ok := s.(Filter); ok == true {
+		return f.Skip(sch, depth, index, length)
+	}
+	return false
+}
+
+// A Checker is optional interface of a Splitter. If
+// a Splitter implements it, then constraints of objects
+// (see Constraints) are checked while splitting, before
+// references of an object are split. Thus an invalid
+// object breaks the splitting before its subtree is
+// requested. The Invalid called with hash of the invalid
+// object and *ConstraintError (or decoding error), and it
+// should fail the Splitter. Length of a Refs is checked
+// when the Refs is loaded, and the hash is hash of the
+// Refs in this case
+type Checker interface {
+	Invalid(hash cipher.SHA256, err error)
+}
+
+// pack used to check constraints while splitting,
+// it has no objects, since length of Refs checked
+// when the Refs is loaded by the Split
+type checkPack struct {
+	fakePack
+}
+
+func (*checkPack) Get(cipher.SHA256) ([]byte, error) {
+	return nil, errNotLoaded
+}
+
+// check constraints of an object if the s implements
+// Checker, the check returns false if the object is
+// invalid
+func check(
+	s Splitter, //         : splitter
+	sch Schema, //         : schema of the object
+	hash cipher.SHA256, // : hash of the object
+	val []byte, //         : the object
+) (
+	ok bool, //            : valid
+) {
+
+	var c, is = s.(Checker)
+
+	if is == false || s.Registry().HasConstraints() == false {
+		return true
+	}
+
+	var err = CheckConstraints(&checkPack{fakePack{s: s}}, sch, val)
+
+	if err != nil {
+		c.Invalid(hash, err)
+		return false
+	}
+
+	return true
+}
+
+// max length of a Refs field to check while splitting
+type refsLimit struct {
+	max    int    // maxlen
+	schema string // name of Schema of the struct
+	field  string // name of the field
+}
+
+// limit of given Refs field or nil
+func fieldRefsLimit(s Splitter, sch Schema, fl Field) (lim *refsLimit) {
+
+	if _, ok := s.(Checker); ok == false {
+		return
+	}
+
+	if fl.Schema().ReferenceType() != ReferenceTypeSlice {
+		return
+	}
+
+	var c, err = FieldConstraints(fl)
+
+	if err != nil || c == nil || c.MaxLen == 0 {
+		return // malformed constraints checked by the check
+	}
+
+	return &refsLimit{max: c.MaxLen, schema: sch.Name(), field: fl.Name()}
+}
+
 func splitSchemaHashAsync(
 	s Splitter, //         : splitter
 	sch Schema, //         : schema of the object
 	hash cipher.SHA256, // : hash of the object
+	depth int, //          : depth of the object
 ) {
-	s.Go(func() { splitSchemaHash(s, sch, hash) })
+	s.Go(func() { splitSchemaHash(s, sch, hash, depth) })
 }
 
 func splitSchemaHash(
 	s Splitter, //         : splitter
 	sch Schema, //         : schema of the object
 	hash cipher.SHA256, // : hash of the object
+	depth int, //          : depth of the object
 ) {
 
 	if hash == (cipher.SHA256{}) {
@@ -91,9 +212,13 @@ func splitSchemaHash(
 		return
 	}
 
+	if check(s, sch, hash, val) == false {
+		return // invalid
+	}
+
 	// go deepper
 
-	splitSchemaData(s, sch, val)
+	splitSchemaData(s, sch, val, depth)
 
 }
 
@@ -101,14 +226,16 @@ func splitSchemaDataAsync(
 	s Splitter, // :
 	sch Schema, // :
 	val []byte, // :
+	depth int, //  :
 ) {
-	s.Go(func() { splitSchemaData(s, sch, val) })
+	s.Go(func() { splitSchemaData(s, sch, val, depth) })
 }
 
 func splitSchemaData(
 	s Splitter, // :
 	sch Schema, // : schema of the object
 	val []byte, // : encoded object
+	depth int, //  : depth of the object
 ) {
 
 	if sch.HasReferences() == false {
@@ -117,17 +244,17 @@ func splitSchemaData(
 
 	// the object represents Ref, Refs or Dynamic
 	if sch.IsReference() == true {
-		splitSchemaReference(s, sch, val)
+		splitSchemaReference(s, sch, val, depth)
 		return
 	}
 
 	switch sch.Kind() {
 	case reflect.Array:
-		splitArray(s, sch, val)
+		splitArray(s, sch, val, depth)
 	case reflect.Slice:
-		splitSlice(s, sch, val)
+		splitSlice(s, sch, val, depth)
 	case reflect.Struct:
-		splitStruct(s, sch, val)
+		splitStruct(s, sch, val, depth)
 	default:
 		s.Fail(fmt.Errorf("invalid Schema to walk through: %s", sch))
 	}
@@ -138,6 +265,7 @@ func splitSchemaReference(
 	s Splitter, // :
 	sch Schema, // : schema of the object
 	val []byte, // : encoded reference
+	depth int, //  : depth of object that contains the reference
 ) {
 
 	var err error
@@ -158,7 +286,7 @@ func splitSchemaReference(
 			return
 		}
 
-		ref.Split(s, el)
+		ref.split(s, el, depth+1)
 
 	case ReferenceTypeSlice: // Refs
 
@@ -174,7 +302,7 @@ func splitSchemaReference(
 			return
 		}
 
-		refs.Split(s, el)
+		refs.split(s, el, depth+1, nil)
 
 	case ReferenceTypeDynamic: // Dynamic
 
@@ -184,7 +312,7 @@ func splitSchemaReference(
 			return
 		}
 
-		dr.Split(s)
+		dr.split(s, depth+1)
 
 	default:
 
@@ -198,6 +326,7 @@ func splitArray(
 	s Splitter, // : pack to get
 	sch Schema, // : schema of the array
 	val []byte, // : encoded array
+	depth int, //  : depth of the object
 ) {
 
 	var el Schema // Schema of the element
@@ -206,7 +335,7 @@ func splitArray(
 		return
 	}
 
-	splitArraySlice(s, el, sch.Len(), val)
+	splitArraySlice(s, el, sch.Len(), val, depth)
 
 }
 
@@ -214,6 +343,7 @@ func splitSlice(
 	s Splitter, // : pack to get
 	sch Schema, // : schema of the slice
 	val []byte, // : encoded slice
+	depth int, //  : depth of the object
 ) {
 
 	var (
@@ -232,7 +362,7 @@ func splitSlice(
 		return
 	}
 
-	splitArraySlice(s, el, ln, val[4:])
+	splitArraySlice(s, el, ln, val[4:], depth)
 }
 
 func splitArraySlice(
@@ -240,6 +370,7 @@ func splitArraySlice(
 	el Schema, //  : shcema of an element
 	ln int, //     : length of the array or slice (> 0)
 	val []byte, // : encoded array or slice starting from first element
+	depth int, //  : depth of the object
 ) {
 
 	// doesn't need to walk through the zero-length
@@ -269,7 +400,7 @@ func splitArraySlice(
 		}
 
 		// split
-		splitSchemaDataAsync(s, el, val[shift:shift+m])
+		splitSchemaDataAsync(s, el, val[shift:shift+m], depth)
 
 		shift += m
 
@@ -283,6 +414,7 @@ func splitStruct(
 	s Splitter, // : pack to get
 	sch Schema, // : schema of the struct
 	val []byte, // : encoded struct
+	depth int, //  : depth of the object
 ) {
 
 	var (
@@ -308,10 +440,48 @@ func splitStruct(
 			return
 		}
 
-		splitSchemaDataAsync(s, fl.Schema(), val[shift:shift+z])
+		if lim := fieldRefsLimit(s, sch, fl); lim != nil {
+			splitRefsLimitAsync(s, fl.Schema(), val[shift:shift+z], depth, lim)
+		} else {
+			splitSchemaDataAsync(s, fl.Schema(), val[shift:shift+z], depth)
+		}
 
 		shift += z
 
 	}
 
 }
+
+func splitRefsLimitAsync(
+	s Splitter, //       :
+	sch Schema, //       : schema of the Refs
+	val []byte, //       : encoded Refs
+	depth int, //        : depth of object that contains the Refs
+	lim *refsLimit, //   : max length
+) {
+	s.Go(func() { splitRefsLimit(s, sch, val, depth, lim) })
+}
+
+// split Refs field checking its length
+func splitRefsLimit(
+	s Splitter, //       :
+	sch Schema, //       : schema of the Refs
+	val []byte, //       : encoded Refs
+	depth int, //        : depth of object that contains the Refs
+	lim *refsLimit, //   : max length
+) {
+
+	var el Schema
+	if el = sch.Elem(); el == nil {
+		s.Fail(fmt.Errorf("Schema of Ref with nil element: %s", sch))
+		return
+	}
+
+	var refs Refs
+	if _, err := encoder.DeserializeRaw(val, &refs); err != nil {
+		s.Fail(err)
+		return
+	}
+
+	refs.split(s, el, depth+1, lim)
+}
@@ -1,13 +1,16 @@
 package registry
 
 import (
+	"fmt"
+
 	"github.com/skycoin/skycoin/src/cipher"
 )
 
 // fake pack to load Refs for the
 // Split method
 type fakePack struct {
-	s Splitter
+	s     Splitter
+	depth int // depth of elements of the Refs (Filter)
 }
 
 func (f *fakePack) Registry() (r *Registry) {
@@ -58,13 +61,21 @@ func (r *Refs) splitHash(
 // Refs if it loads it and never updates hashes of
 // the Refs if they are not actual
 func (r *Refs) Split(s Splitter, el Schema) {
+	r.split(s, el, 1, nil)
+}
 
-	var fp = fakePack{s} // fake Pack
+func (r *Refs) split(s Splitter, el Schema, depth int, lim *refsLimit) {
+
+	var fp = fakePack{s, depth} // fake Pack
 
 	if r.Hash == (cipher.SHA256{}) {
 		return // done
 	}
 
+	if skip(s, el, depth, -1, 0) == true {
+		return // filtered
+	}
+
 	// first of all, check the Refs.Hash
 
 	if r.splitHash(&fp, r.Hash) == false {
@@ -77,7 +88,19 @@ func (r *Refs) Split(s Splitter, el Schema) {
 		return
 	}
 
-	r.splitNode(&fp, el, r.refsNode, r.depth)
+	if lim != nil && r.length > lim.max {
+		s.(Checker).Invalid(r.Hash, &ConstraintError{
+			Schema: lim.schema,
+			Field:  lim.field,
+			Reason: fmt.Sprintf("length %d exceeds maxlen %d", r.length,
+				lim.max),
+		})
+		return
+	}
+
+	expect(s, r.length) // elements to split
+
+	r.splitNode(&fp, el, r.refsNode, r.depth, 0)
 
 }
 
@@ -86,8 +109,30 @@ func (r *Refs) splitNodeAsync(
 	sch Schema, //   : schema of elements
 	rn *refsNode, // : the node
 	depth int, //    : depth of the node
+	offset int, //   : index of first element of the node
 ) {
-	fp.s.Go(func() { r.splitNode(fp, sch, rn, depth) })
+	fp.s.Go(func() { r.splitNode(fp, sch, rn, depth, offset) })
+}
+
+// are all elements of given range skipped by Filter
+func (r *Refs) skipRange(
+	fp *fakePack, // : fake pack
+	sch Schema, //   : schema of elements
+	offset int, //   : first element
+	ln int, //       : number of elements
+) bool {
+
+	if _, ok := fp.s.(Filter); ok == false {
+		return false
+	}
+
+	for i := offset; i < offset+ln; i++ {
+		if skip(fp.s, sch, fp.depth, i, r.length) == false {
+			return false
+		}
+	}
+
+	return true
 }
 
 func (r *Refs) splitNode(
@@ -95,12 +140,18 @@ func (r *Refs) splitNode(
 	sch Schema, //   : schema of elements
 	rn *refsNode, // : the node
 	depth int, //    : depth of the node
+	offset int, //   : index of first element of the node
 ) {
 
 	if depth == 0 {
 
-		for _, leaf := range rn.leafs {
-			splitSchemaHashAsync(fp.s, sch, leaf.Hash)
+		expect(fp.s, -len(rn.leafs)) // reached
+
+		for i, leaf := range rn.leafs {
+			if skip(fp.s, sch, fp.depth, offset+i, r.length) == true {
+				continue // filtered
+			}
+			splitSchemaHashAsync(fp.s, sch, leaf.Hash, fp.depth)
 		}
 
 		return
@@ -108,10 +159,32 @@ func (r *Refs) splitNode(
 
 	// else if depth > 0 -> { branches }
 
-	var toSplit []*refsNode // data-race protection
+	type branch struct {
+		rn     *refsNode
+		offset int
+	}
+
+	var (
+		toSplit  []branch // data-race protection
+		_, index = fp.s.(Filter)
+	)
 
 	for _, br := range rn.branches {
+
 		if r.splitHash(fp, br.hash) == false {
+
+			// a Filter needs indices of elements, thus
+			// we have to load the node to get its length
+
+			if index == true {
+				if err := r.loadNodeIfNeed(fp, br, depth-1); err != nil {
+					fp.s.Fail(err)
+					return
+				}
+				expect(fp.s, -br.length) // already have
+				offset += br.length
+			}
+
 			continue
 		}
 
@@ -120,13 +193,19 @@ func (r *Refs) splitNode(
 			return
 		}
 
-		toSplit = append(toSplit, br)
+		if r.skipRange(fp, sch, offset, br.length) == true {
+			expect(fp.s, -br.length) // reached
+		} else {
+			toSplit = append(toSplit, branch{br, offset})
+		}
+
+		offset += br.length
 	}
 
 	// data-race protection: load first, then split
 
 	for _, br := range toSplit {
-		r.splitNodeAsync(fp, sch, br, depth-1)
+		r.splitNodeAsync(fp, sch, br.rn, depth-1, br.offset)
 	}
 
 }
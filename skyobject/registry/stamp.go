@@ -0,0 +1,57 @@
+package registry
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+// MaxStampDifficulty is max difficulty of a stamp.
+// Every next bit doubles time required to find a
+// stamp, and even 40 bits is too much for a desktop
+const MaxStampDifficulty = 64
+
+// StampBits returns difficulty of given proof-of-work
+// stamp of a Root. The difficulty is number of leading
+// zero bits of SHA256(hash + stamp), where the hash is
+// hash of the Root and the stamp is encoded in
+// little-endian order
+func StampBits(hash cipher.SHA256, stamp uint64) (n int) {
+
+	var buf [len(hash) + 8]byte
+
+	copy(buf[:], hash[:])
+	binary.LittleEndian.PutUint64(buf[len(hash):], stamp)
+
+	for _, b := range cipher.SumSHA256(buf[:]) {
+		if b != 0 {
+			return n + bits.LeadingZeros8(b)
+		}
+		n += 8
+	}
+
+	return
+}
+
+// NewStamp finds proof-of-work stamp of given difficulty
+// for Root with given hash. Zero stamp means "no stamp",
+// thus the NewStamp never returns zero
+func NewStamp(
+	hash cipher.SHA256, //  : hash of the Root
+	difficulty int, //      : required bits
+) (
+	stamp uint64, //        : the stamp
+	err error, //           : invalid difficulty
+) {
+
+	if difficulty < 0 || difficulty > MaxStampDifficulty {
+		return 0, fmt.Errorf("invalid stamp difficulty %d", difficulty)
+	}
+
+	for stamp = 1; StampBits(hash, stamp) < difficulty; stamp++ {
+	}
+
+	return
+}
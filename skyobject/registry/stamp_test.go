@@ -0,0 +1,37 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+func TestNewStamp(t *testing.T) {
+
+	var hash = cipher.SumSHA256([]byte("root"))
+
+	for _, d := range []int{0, 1, 8, 12} {
+
+		var stamp, err = NewStamp(hash, d)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stamp == 0 {
+			t.Error("zero stamp")
+		}
+
+		if StampBits(hash, stamp) < d {
+			t.Error("underpowered stamp", d)
+		}
+
+	}
+
+	for _, d := range []int{-1, MaxStampDifficulty + 1} {
+		if _, err := NewStamp(hash, d); err == nil {
+			t.Error("missing error", d)
+		}
+	}
+
+}
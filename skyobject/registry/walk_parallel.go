@@ -0,0 +1,451 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+// a Pack wrapper without flags; the
+// walker never changes Refs it loads
+// and it doesn't need indices; thus,
+// Refs of the walker are always lazy
+type walkPack struct {
+	Pack
+}
+
+func (walkPack) Flags() (_ Flags) { return }
+
+// A walker used to walk through a Root using
+// many goroutines. It's like the Splitter, but
+// it calls a WalkFunc
+type walker struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	pack     Pack
+	walkFunc WalkFunc
+
+	limit chan struct{} // max goroutines
+	await sync.WaitGroup
+
+	mx  sync.Mutex
+	err error // first error
+}
+
+func newWalker(
+	ctx context.Context, // : the context
+	pack Pack, //            : pack to get
+	maxParall int, //        : max goroutines
+	walkFunc WalkFunc, //    : the function
+) (
+	w *walker, //            : the walker
+) {
+
+	if walkFunc == nil {
+		panic("walkFunc is nil") // for developers
+	}
+
+	w = new(walker)
+
+	w.ctx, w.cancel = context.WithCancel(ctx)
+	w.pack = walkPack{pack}
+	w.walkFunc = walkFunc
+
+	if maxParall <= 0 {
+		maxParall = runtime.NumCPU() // default
+	}
+
+	w.limit = make(chan struct{}, maxParall)
+
+	return
+}
+
+// fail terminates the walking with given error;
+// the ErrStopIteration stops walking without error
+func (w *walker) fail(err error) {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+
+	if w.err == nil && err != ErrStopIteration {
+		w.err = err
+	}
+
+	w.cancel()
+}
+
+// is the walking terminated
+func (w *walker) isDone() bool {
+	select {
+	case <-w.ctx.Done():
+		return true
+	default:
+	}
+	return false
+}
+
+// wait for goroutines and
+// return the walking error
+func (w *walker) wait(parent context.Context) (err error) {
+	w.await.Wait()
+	w.cancel() // release resources of the context
+
+	w.mx.Lock()
+	defer w.mx.Unlock()
+
+	if err = w.err; err == nil {
+		err = parent.Err() // cancelled or timed out
+	}
+
+	return
+}
+
+func (w *walker) acquire() (parall bool) {
+
+	select {
+	case w.limit <- struct{}{}:
+		parall = true
+	default:
+		// limit reached
+	}
+
+	return
+}
+
+// Go performs given task in separate goroutine
+// if the limit is not reached, or in the current
+// goroutine otherwise; that never blocks
+func (w *walker) Go(fn func()) {
+
+	if w.isDone() == true {
+		return
+	}
+
+	if w.acquire() == true {
+		w.await.Add(1)
+		go func() {
+			defer w.await.Done()
+			if w.limit != nil {
+				defer func() { <-w.limit }() // release
+			}
+			fn()
+		}()
+		return
+	}
+
+	fn()
+}
+
+// call the WalkFunc
+func (w *walker) call(hash cipher.SHA256, depth int) (deepper bool) {
+
+	if w.isDone() == true {
+		return
+	}
+
+	var err error
+	if deepper, err = w.walkFunc(hash, depth); err != nil {
+		w.fail(err)
+		return false
+	}
+
+	return
+}
+
+// walk an element of given Schema, the WalkFunc
+// is not called with the hash yet
+func (w *walker) walkElement(sch Schema, hash cipher.SHA256) {
+	if w.call(hash, 0) == true {
+		w.walkHash(sch, hash)
+	}
+}
+
+// the WalkFunc already called with the hash
+func (w *walker) walkHash(sch Schema, hash cipher.SHA256) {
+
+	if hash == (cipher.SHA256{}) || sch.HasReferences() == false {
+		return // nothing to walk through
+	}
+
+	if w.isDone() == true {
+		return
+	}
+
+	var val, err = w.pack.Get(hash)
+
+	if err != nil {
+		w.fail(err)
+		return
+	}
+
+	w.walkData(sch, val)
+}
+
+func (w *walker) walkData(sch Schema, val []byte) {
+
+	if sch.HasReferences() == false {
+		return
+	}
+
+	if sch.IsReference() == true {
+		w.walkReference(sch, val)
+		return
+	}
+
+	switch sch.Kind() {
+	case reflect.Array:
+		w.walkArraySlice(sch.Elem(), sch.Len(), val)
+	case reflect.Slice:
+		var ln, err = getLength(val)
+		if err != nil {
+			w.fail(err)
+			return
+		}
+		w.walkArraySlice(sch.Elem(), ln, val[4:])
+	case reflect.Struct:
+		w.walkStruct(sch, val)
+	default:
+		w.fail(fmt.Errorf("invalid Schema to walk through: %s", sch))
+	}
+
+}
+
+func (w *walker) walkReference(sch Schema, val []byte) {
+
+	var err error
+
+	switch rt := sch.ReferenceType(); rt {
+
+	case ReferenceTypeSingle:
+
+		var el Schema
+		if el = sch.Elem(); el == nil {
+			w.fail(fmt.Errorf("Schema of Ref with nil element: %s", sch))
+			return
+		}
+
+		var ref Ref
+		if _, err = encoder.DeserializeRaw(val, &ref); err != nil {
+			w.fail(err)
+			return
+		}
+
+		w.Go(func() { w.walkElement(el, ref.Hash) })
+
+	case ReferenceTypeSlice:
+
+		var el Schema
+		if el = sch.Elem(); el == nil {
+			w.fail(fmt.Errorf("Schema of Refs with nil element: %s", sch))
+			return
+		}
+
+		var refs Refs
+		if _, err = encoder.DeserializeRaw(val, &refs); err != nil {
+			w.fail(err)
+			return
+		}
+
+		w.Go(func() { w.walkRefs(el, &refs) })
+
+	case ReferenceTypeDynamic:
+
+		var dr Dynamic
+		if _, err = encoder.DeserializeRaw(val, &dr); err != nil {
+			w.fail(err)
+			return
+		}
+
+		w.Go(func() { w.walkDynamic(dr) })
+
+	default:
+
+		w.fail(fmt.Errorf("invalid ReferenceType %d to walk through", rt))
+
+	}
+
+}
+
+func (w *walker) walkDynamic(dr Dynamic) {
+
+	if dr.IsValid() == false {
+		w.fail(ErrInvalidDynamicReference)
+		return
+	}
+
+	if w.call(dr.Hash, 0) == false || dr.Hash == (cipher.SHA256{}) {
+		return
+	}
+
+	var reg *Registry
+	if reg = w.pack.Registry(); reg == nil {
+		w.fail(ErrMissingRegistry)
+		return
+	}
+
+	var sch, err = reg.SchemaByReference(dr.Schema)
+
+	if err != nil {
+		w.fail(err)
+		return
+	}
+
+	w.walkHash(sch, dr.Hash)
+}
+
+func (w *walker) walkRefs(el Schema, refs *Refs) {
+
+	if refs.Hash == (cipher.SHA256{}) {
+		w.call(refs.Hash, 1) // the depth can't be zero
+		return
+	}
+
+	if err := refs.initialize(w.pack); err != nil {
+		w.fail(err)
+		return
+	}
+
+	if w.call(refs.Hash, refs.depth+1) == false {
+		return
+	}
+
+	w.walkRefsNode(el, refs, refs.refsNode, refs.depth)
+}
+
+// the walkRefsNode loads branches of the node in the
+// current goroutine and walks through them using others;
+// this way, goroutines never load the same node
+func (w *walker) walkRefsNode(
+	el Schema, //    : schema of elements
+	refs *Refs, //   : the Refs
+	rn *refsNode, // : the node
+	depth int, //    : depth of the node
+) {
+
+	if depth == 0 {
+		for _, leaf := range rn.leafs {
+			var hash = leaf.Hash
+			w.Go(func() { w.walkElement(el, hash) })
+		}
+		return
+	}
+
+	var toWalk []*refsNode
+
+	for _, br := range rn.branches {
+
+		if w.call(br.hash, depth) == false {
+			continue
+		}
+
+		if err := refs.loadNodeIfNeed(w.pack, br, depth-1); err != nil {
+			w.fail(err)
+			return
+		}
+
+		toWalk = append(toWalk, br)
+	}
+
+	for _, br := range toWalk {
+		var br = br
+		w.Go(func() { w.walkRefsNode(el, refs, br, depth-1) })
+	}
+
+}
+
+func (w *walker) walkArraySlice(el Schema, ln int, val []byte) {
+
+	if el == nil {
+		w.fail(fmt.Errorf("nil Schema of element of array or slice"))
+		return
+	}
+
+	var shift, m int
+	var err error
+
+	for i := 0; i < ln; i++ {
+
+		if shift > len(val) {
+			w.fail(fmt.Errorf("unexpected end of encoded array or slice "+
+				"of <%s>, length: %d, index: %d", el, ln, i))
+			return
+		}
+
+		if m, err = el.Size(val[shift:]); err != nil {
+			w.fail(err)
+			return
+		}
+
+		var ev = val[shift : shift+m]
+		w.Go(func() { w.walkData(el, ev) })
+
+		shift += m
+
+	}
+
+}
+
+func (w *walker) walkStruct(sch Schema, val []byte) {
+
+	var shift, s int
+	var err error
+
+	for i, fl := range sch.Fields() {
+
+		if shift > len(val) {
+			w.fail(fmt.Errorf("unexpected end of encoded struct <%s>, "+
+				"field number: %d, field name: %q", sch, i, fl.Name()))
+			return
+		}
+
+		if s, err = fl.Schema().Size(val[shift:]); err != nil {
+			w.fail(err)
+			return
+		}
+
+		if fs := fl.Schema(); fs.HasReferences() == true {
+			var fv = val[shift : shift+s]
+			w.Go(func() { w.walkData(fs, fv) })
+		}
+
+		shift += s
+
+	}
+
+}
+
+// WalkParallel is the same as the Walk, but it walks
+// using many goroutines. The maxParall argument limits
+// number of goroutines (zero means number of CPUs). The
+// WalkFunc is called concurrently and must be safe for
+// that. The WalkFunc is called in undefined order,
+// but for every hash after its parent. To stop the
+// walking the WalkFunc can return ErrStopIteration.
+// The walking can be stopped using given context too.
+// In this case the WalkParallel returns error of the
+// context. The WalkParallel never changes the Root
+// and Refs of the Root. The pack must be safe for
+// concurrent use
+func (r *Root) WalkParallel(
+	ctx context.Context, // : the context
+	pack Pack, //            : pack to get objects
+	maxParall int, //        : max goroutines
+	walkFunc WalkFunc, //    : the function
+) (
+	err error, //            : an error
+) {
+
+	var w = newWalker(ctx, pack, maxParall, walkFunc)
+
+	for _, dr := range r.Refs {
+		var dr = dr
+		w.Go(func() { w.walkDynamic(dr) })
+	}
+
+	return w.wait(ctx)
+}
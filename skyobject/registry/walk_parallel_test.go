@@ -0,0 +1,246 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+func getWalkTestRoot(t *testing.T, pack Pack) (r *Root) {
+	t.Helper()
+
+	var (
+		reg      = pack.Registry()
+		grp      = TestGroup{Name: "group"}
+		err      error
+		grpSch   Schema
+		usrSch   Schema
+		manSch   Schema
+		dr, user Dynamic
+	)
+
+	if grpSch, err = reg.SchemaByName("test.Group"); err != nil {
+		t.Fatal(err)
+	}
+	if usrSch, err = reg.SchemaByName("test.User"); err != nil {
+		t.Fatal(err)
+	}
+	if manSch, err = reg.SchemaByName("test.Man"); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 30; i++ {
+		err = grp.Members.AppendValues(pack, TestUser{
+			Name: fmt.Sprint("user #", i),
+			Age:  uint32(i),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = grp.Curator.SetValue(pack, &TestUser{Name: "curator"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = grp.Developer.SetValue(pack, &TestMan{Name: "dev"}); err != nil {
+		t.Fatal(err)
+	}
+	grp.Developer.Schema = manSch.Reference()
+
+	if err = dr.SetValue(pack, &grp); err != nil {
+		t.Fatal(err)
+	}
+	dr.Schema = grpSch.Reference()
+
+	if err = user.SetValue(pack, &TestUser{Name: "alone"}); err != nil {
+		t.Fatal(err)
+	}
+	user.Schema = usrSch.Reference()
+
+	r = new(Root)
+	r.Refs = []Dynamic{dr, {}, user}
+
+	return
+}
+
+func TestRoot_WalkParallel(t *testing.T) {
+
+	var (
+		pack = testPackReg(testRegistry())
+		r    = getWalkTestRoot(t, pack)
+		want = make(map[cipher.SHA256]int)
+	)
+
+	var err = r.Walk(pack, func(hash cipher.SHA256, _ int) (bool, error) {
+		want[hash]++
+		return true, nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, maxParall := range []int{0, 1, 4} {
+
+		t.Run(fmt.Sprint("max ", maxParall), func(t *testing.T) {
+
+			var (
+				mx  sync.Mutex
+				got = make(map[cipher.SHA256]int)
+			)
+
+			err = r.WalkParallel(context.Background(), pack, maxParall,
+				func(hash cipher.SHA256, _ int) (bool, error) {
+					mx.Lock()
+					defer mx.Unlock()
+					got[hash]++
+					return true, nil
+				})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(got) != len(want) {
+				t.Fatalf("wrong number of hashes: %d, want %d", len(got),
+					len(want))
+			}
+
+			for hash, n := range want {
+				if got[hash] != n {
+					t.Errorf("wrong times of %s: %d, want %d", hash.Hex()[:7],
+						got[hash], n)
+				}
+			}
+
+		})
+
+	}
+
+	t.Run("default limit", func(t *testing.T) {
+
+		var (
+			mx        sync.Mutex
+			now, peak int
+		)
+
+		err = r.WalkParallel(context.Background(), pack, 0,
+			func(hash cipher.SHA256, _ int) (bool, error) {
+				mx.Lock()
+				if now++; now > peak {
+					peak = now
+				}
+				mx.Unlock()
+
+				time.Sleep(time.Millisecond)
+
+				mx.Lock()
+				now--
+				mx.Unlock()
+				return true, nil
+			})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// goroutines of the limit and the caller
+		if peak > runtime.NumCPU()+1 {
+			t.Errorf("too many goroutines: %d, CPUs: %d", peak,
+				runtime.NumCPU())
+		}
+
+	})
+
+	t.Run("deepper", func(t *testing.T) {
+
+		var (
+			mx sync.Mutex
+			n  int
+		)
+
+		err = r.WalkParallel(context.Background(), pack, 4,
+			func(hash cipher.SHA256, _ int) (bool, error) {
+				mx.Lock()
+				defer mx.Unlock()
+				n++
+				return false, nil
+			})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n != len(r.Refs) {
+			t.Errorf("wrong number of hashes: %d, want %d", n, len(r.Refs))
+		}
+
+	})
+
+	t.Run("stop", func(t *testing.T) {
+
+		err = r.WalkParallel(context.Background(), pack, 4,
+			func(hash cipher.SHA256, _ int) (bool, error) {
+				return false, ErrStopIteration
+			})
+
+		if err != nil {
+			t.Error(err)
+		}
+
+	})
+
+	t.Run("error", func(t *testing.T) {
+
+		var testErr = fmt.Errorf("test error")
+
+		err = r.WalkParallel(context.Background(), pack, 4,
+			func(hash cipher.SHA256, depth int) (bool, error) {
+				if depth > 0 {
+					return false, testErr
+				}
+				return true, nil
+			})
+
+		if err != testErr {
+			t.Error("wrong error:", err)
+		}
+
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+
+		var ctx, cancel = context.WithCancel(context.Background())
+
+		var (
+			mx sync.Mutex
+			n  int
+		)
+
+		err = r.WalkParallel(ctx, pack, 4,
+			func(hash cipher.SHA256, _ int) (bool, error) {
+				mx.Lock()
+				defer mx.Unlock()
+				if n++; n == 3 {
+					cancel()
+				}
+				return true, nil
+			})
+
+		if err != context.Canceled {
+			t.Error("wrong error:", err)
+		}
+
+		if n >= len(want) {
+			t.Error("not cancelled")
+		}
+
+	})
+
+}
@@ -0,0 +1,281 @@
+package skyobject
+
+import (
+	"errors"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+
+	"github.com/skycoin/cxo/data"
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+// A SaveTx used to save many Roots, of different
+// feeds or heads, atomically. For example
+//
+//     err := c.SaveTx().
+//         Add(indexUnpack, indexRoot).
+//         Add(contentUnpack, contentRoot).
+//         Commit()
+//
+// Every Root must have its own Unpack. The SaveTx
+// saves all the Roots or nothing. It's possible to
+// add many Roots of the same head, then they will
+// be saved in order of adding
+//
+// The Roots are saved in IdxDB using one transaction.
+// Objects of the Roots are saved in CXDS (and rc of
+// existing objects are incremented) before the
+// transaction, thus no Root points to missing objects.
+// After the transaction, rc of the objects are reduced
+// to actual values. Changes of the rc are stored in
+// IdxDB using the same transaction, and if the SaveTx
+// fails reducing the rc (e.g. CXDS failure), then the
+// rest of the changes are applied on next start of the
+// Container. The CXDS and the IdxDB are different
+// databases, thus the rc can't be changed atomically
+// with the Roots. If the program crashes reducing the
+// rc, then some objects keep increased rc. Such objects
+// are not removed from CXDS, even if no Root uses them,
+// but rc of an object is never reduced twice
+//
+// If the Commit fails, then all Unpacks should be
+// closed to reject objects (or can be used to save
+// the Roots again). The SaveTx is not thread safe
+type SaveTx struct {
+	c     *Container
+	items []saveItem
+}
+
+// SaveTx creates new SaveTx
+func (c *Container) SaveTx() (tx *SaveTx) {
+	return &SaveTx{c: c}
+}
+
+// Add given Root and Unpack to the SaveTx. See also
+// Save method of the Container for details. The Add
+// returns the SaveTx for chaining
+func (s *SaveTx) Add(up *Unpack, r *registry.Root) (tx *SaveTx) {
+	s.items = append(s.items, saveItem{up: up, r: r})
+	return s
+}
+
+// AddIf adds given Root and Unpack like the Add, but
+// the Root will be saved only if last Root of its head
+// is the expected one. Otherwise the Commit returns
+// *SaveConflictError. See SaveIf method of the
+// Container for details
+func (s *SaveTx) AddIf(
+	up *Unpack, //            : unpack
+	r *registry.Root, //      : the Root
+	prev cipher.SHA256, //    : expected last Root
+) (
+	tx *SaveTx, //            : the SaveTx
+) {
+	s.items = append(s.items, saveItem{up: up, r: r, cas: true, prev: prev})
+	return s
+}
+
+// Len returns number of Roots added
+func (s *SaveTx) Len() int {
+	return len(s.items)
+}
+
+// Commit the SaveTx. The Commit sets Seq, Prev,
+// Time, Hash and Sig fields of all the Roots. If
+// the Commit fails reducing rc of objects (see
+// above) or updating field indexes, then the Roots
+// are already saved. For the field indexes the
+// error is *IndexRootError
+func (s *SaveTx) Commit() (err error) {
+
+	if len(s.items) == 0 {
+		return // nothing to save
+	}
+
+	var (
+		ups = make(map[*Unpack]struct{}, len(s.items))
+		rs  = make(map[*registry.Root]struct{}, len(s.items))
+	)
+
+	for _, it := range s.items {
+
+		if _, ok := ups[it.up]; ok == true {
+			return errors.New("the same Unpack used for many Roots")
+		}
+
+		if _, ok := rs[it.r]; ok == true {
+			return errors.New("the same Root added twice")
+		}
+
+		ups[it.up], rs[it.r] = struct{}{}, struct{}{}
+
+	}
+
+	return s.c.saveRoots(s.items)
+}
+
+// changes of rc of objects of saved Roots, that are
+// not applied yet (see SaveTx)
+var rcJournalBucket = []byte("skyobject.rc")
+
+// an rcJournal is changes of rc of objects of saved Roots,
+// the rcJournal is stored in IdxDB with the Roots using
+// one transaction and removed before applying
+type rcJournal struct {
+	ID   cipher.SHA256 `enc:"-"` // hash of first Root (key)
+	Keys []cipher.SHA256
+	Incs []int32
+}
+
+// changes of rc of objects of given signed Roots that make
+// the rc actual; the Roots are saved in CXDS, objects the
+// Roots use hold rc, and other objects saved by the Unpacks
+// are rejected
+func newRCJournal(items []saveItem) (jr *rcJournal) {
+
+	var incs = make(map[cipher.SHA256]int)
+
+	for _, it := range items {
+
+		var r = it.r
+
+		for key, ui := range it.up.m {
+
+			var dec = ui.dec // used
+
+			if key == r.Hash || key == cipher.SHA256(r.Reg) ||
+				(key == r.Envelope && r.IsEncrypted() == true) {
+				dec++
+			}
+
+			if inc := dec - ui.inc; inc < 0 {
+				incs[key] += inc // decrement
+			}
+
+		}
+
+	}
+
+	jr = &rcJournal{ID: items[0].r.Hash}
+
+	for key, inc := range incs {
+		jr.Keys = append(jr.Keys, key)
+		jr.Incs = append(jr.Incs, int32(inc))
+	}
+
+	return
+}
+
+// save the rcJournal inside IdxDB transaction
+func (jr *rcJournal) saveTx(bs data.Buckets) (err error) {
+
+	if len(jr.Keys) == 0 {
+		return // nothing to change
+	}
+
+	var bk data.Bucket
+	if bk, err = bs.Bucket(rcJournalBucket); err != nil {
+		return
+	}
+
+	return bk.Set(jr.ID[:], encoder.Serialize(jr))
+}
+
+// save the rcJournal
+func (c *Container) saveRCJournal(jr *rcJournal) (err error) {
+	return c.db.IdxDB().BucketsTx(jr.saveTx)
+}
+
+// delete rcJournal with given ID
+func (c *Container) delRCJournal(id cipher.SHA256) (err error) {
+
+	return c.db.IdxDB().BucketsTx(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(rcJournalBucket); err != nil {
+			return
+		}
+
+		return bk.Del(id[:])
+
+	})
+
+}
+
+// applyRCJournal changes rc of objects. The rcJournal is
+// removed before, thus a crash can't apply it twice. If
+// the applyRCJournal fails, then rest of the changes are
+// stored to be applied on next start
+func (c *Container) applyRCJournal(jr *rcJournal) (err error) {
+
+	if len(jr.Keys) == 0 {
+		return // nothing to change
+	}
+
+	if err = c.delRCJournal(jr.ID); err != nil {
+		return // will be applied on next start
+	}
+
+	for k, key := range jr.Keys {
+
+		if _, err = c.Inc(key, int(jr.Incs[k])); err == nil ||
+			err == data.ErrNotFound {
+
+			continue // nothing to change, if the object removed
+		}
+
+		c.saveRCJournal(&rcJournal{
+			ID:   jr.ID,
+			Keys: jr.Keys[k:],
+			Incs: jr.Incs[k:],
+		}) // ignore error
+
+		return
+
+	}
+
+	return nil
+}
+
+// apply changes of rc of saved Roots, if the changes
+// have not been applied, because of a failure
+func (c *Container) replayRCJournals() (err error) {
+
+	var jrs []*rcJournal
+
+	err = c.db.IdxDB().BucketsView(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(rcJournalBucket); err != nil {
+			return
+		}
+
+		return bk.Ascend(nil, func(key, val []byte) (err error) {
+
+			var jr = new(rcJournal)
+
+			if _, err = encoder.DeserializeRaw(val, jr); err != nil {
+				return
+			}
+
+			copy(jr.ID[:], key)
+			jrs = append(jrs, jr)
+			return
+
+		})
+
+	})
+
+	if err != nil {
+		return
+	}
+
+	for _, jr := range jrs {
+		if err = c.applyRCJournal(jr); err != nil {
+			return
+		}
+	}
+
+	return
+}
@@ -0,0 +1,268 @@
+package skyobject
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/data"
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+func TestSaveTx_Commit(t *testing.T) {
+
+	var (
+		c        = getTestContainer()
+		pk1, sk1 = cipher.GenerateKeyPair()
+		pk2, sk2 = cipher.GenerateKeyPair()
+	)
+
+	defer c.Close()
+
+	assertNil(t, c.AddFeed(pk1))
+
+	var newRoot = func(
+		pk cipher.PubKey,
+		sk cipher.SecKey,
+		name string,
+	) (
+		up *Unpack,
+		r *registry.Root,
+	) {
+
+		var err error
+		up, err = c.Unpack(sk, testRegistry)
+		assertNil(t, err)
+
+		r = new(registry.Root)
+		r.Pub = pk
+		r.Nonce = 1
+		r.Refs = []registry.Dynamic{
+			createDynamic(up, testRegistry, "test.User", &User{name, 21}),
+		}
+		return
+	}
+
+	t.Run("rollback", func(t *testing.T) {
+
+		var (
+			up1, r1 = newRoot(pk1, sk1, "Alice")
+			up2, r2 = newRoot(pk2, sk2, "Eva") // no such feed
+		)
+
+		var err = c.SaveTx().Add(up1, r1).Add(up2, r2).Commit()
+
+		if err != data.ErrNoSuchFeed {
+			t.Fatal("wrong error:", err)
+		}
+
+		if _, err = c.LastRoot(pk1, 1); err == nil {
+			t.Error("the Root has been saved")
+		}
+
+		assertNil(t, up1.Close())
+		assertNil(t, up2.Close())
+
+		var rc uint32
+		_, rc, err = c.db.CXDS().Get(r1.Refs[0].Hash, 0)
+		assertNil(t, err)
+
+		if rc != 0 {
+			t.Error("wrong rc of rejected object:", rc)
+		}
+
+	})
+
+	assertNil(t, c.AddFeed(pk2))
+
+	t.Run("commit", func(t *testing.T) {
+
+		var (
+			up1, r1 = newRoot(pk1, sk1, "Alice")
+			up2, r2 = newRoot(pk2, sk2, "Eva")
+			up3, r3 = newRoot(pk2, sk2, "Ammy") // the same head
+		)
+
+		var tx = c.SaveTx().Add(up1, r1).Add(up2, r2).Add(up3, r3)
+
+		if tx.Len() != 3 {
+			t.Error("wrong length", tx.Len())
+		}
+
+		assertNil(t, tx.Commit())
+
+		for _, r := range []*registry.Root{r1, r3} {
+
+			var last, err = c.LastRoot(r.Pub, r.Nonce)
+			assertNil(t, err)
+
+			if last.Hash != r.Hash {
+				t.Error("wrong last Root")
+			}
+
+		}
+
+		if r3.Seq != r2.Seq+1 || r3.Prev != r2.Hash {
+			t.Error("wrong chain of Roots", r2.Seq, r3.Seq)
+		}
+
+		for _, r := range []*registry.Root{r1, r2, r3} {
+
+			var _, rc, err = c.db.CXDS().Get(r.Refs[0].Hash, 0)
+			assertNil(t, err)
+
+			if rc != 1 {
+				t.Error("wrong rc of saved object:", rc)
+			}
+
+		}
+
+		if n := testRCJournals(t, c); n != 0 {
+			t.Error("changes of rc are not applied:", n)
+		}
+
+	})
+
+	t.Run("journal", func(t *testing.T) {
+
+		var up, r = newRoot(pk1, sk1, "Journal") // new object
+
+		var key = r.Refs[0].Hash // saved by the Unpack
+
+		// changes of rc of a Root, saved and not applied,
+		// because of a failure
+
+		var jr = &rcJournal{
+			ID:   cipher.SumSHA256([]byte("root")),
+			Keys: []cipher.SHA256{key},
+			Incs: []int32{-1},
+		}
+
+		assertNil(t, c.saveRCJournal(jr))
+		up.saved() // rc of the object is in the rcJournal
+
+		for i := 0; i < 2; i++ {
+
+			assertNil(t, c.replayRCJournals())
+
+			var _, rc, err = c.db.CXDS().Get(key, 0)
+			assertNil(t, err)
+
+			if rc != 0 {
+				t.Error("wrong rc of rejected object:", rc)
+			}
+
+			if n := testRCJournals(t, c); n != 0 {
+				t.Error("changes of rc are not applied:", n)
+			}
+
+		}
+
+	})
+
+	t.Run("the same unpack", func(t *testing.T) {
+
+		var (
+			up1, r1 = newRoot(pk1, sk1, "Alice")
+			r2      = new(registry.Root)
+		)
+
+		defer up1.Close()
+
+		r2.Pub, r2.Nonce = pk2, 1
+
+		if err := c.SaveTx().Add(up1, r1).Add(up1, r2).Commit(); err == nil {
+			t.Error("missing error")
+		}
+
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+
+		var s, err = NewSecKeySigner(sk1)
+		assertNil(t, err)
+
+		const n = 8
+
+		var (
+			wg   sync.WaitGroup
+			errs = make(chan error, n)
+		)
+
+		for k := 0; k < n; k++ {
+
+			var up *Unpack
+			up, err = c.UnpackSigner(slowSigner{s}, testRegistry)
+			assertNil(t, err)
+
+			var r = new(registry.Root)
+			r.Pub, r.Nonce = pk1, 2
+
+			wg.Add(1)
+			go func(up *Unpack, r *registry.Root) {
+				defer wg.Done()
+				defer up.Close()
+				errs <- c.Save(up, r)
+			}(up, r)
+
+		}
+
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			assertNil(t, err)
+		}
+
+		// all the Roots are chained
+
+		var seq = uint64(n)
+
+		err = c.History(pk1, 2, func(r *registry.Root) (_ error) {
+			if seq--; r.Seq != seq {
+				t.Error("wrong seq:", r.Seq, seq)
+			}
+			return
+		})
+
+		assertNil(t, err)
+
+		if seq != 0 {
+			t.Error("missing Roots:", seq)
+		}
+
+	})
+
+}
+
+// a slowSigner signs with delay
+type slowSigner struct {
+	Signer
+}
+
+func (s slowSigner) SignHash(hash cipher.SHA256) (cipher.Sig, error) {
+	time.Sleep(10 * time.Millisecond)
+	return s.Signer.SignHash(hash)
+}
+
+// number of not applied rcJournals
+func testRCJournals(t *testing.T, c *Container) (n int) {
+	t.Helper()
+
+	var err = c.db.IdxDB().BucketsView(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(rcJournalBucket); err != nil {
+			return
+		}
+
+		n = bk.Len()
+		return
+
+	})
+
+	assertNil(t, err)
+	return
+}
@@ -0,0 +1,299 @@
+package skyobject
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+// A schemaWalkFunc used to walk through objects
+// of a Root knowing Schema of every object. Unlike
+// the registry.WalkFunc, the schemaWalkFunc never
+// called with hashes of Refs-nodes. The deepper
+// reply used to explore fields of the object
+type schemaWalkFunc func(
+	hash cipher.SHA256, //  : hash of the object
+	sch registry.Schema, // : schema of the object
+	val []byte, //          : encoded object
+) (
+	deepper bool, //        : go deepper
+	err error, //           : an error
+)
+
+// walkSchemaRoot walks through all objects of given
+// Root calling given function for every object with
+// its Schema. The walkSchemaRoot skips blank references
+func walkSchemaRoot(
+	pack registry.Pack, //       : pack to get objects
+	r *registry.Root, //          : the Root
+	walkFunc schemaWalkFunc, //   : the function
+) (
+	err error, //                 : an error
+) {
+
+	for _, dr := range r.Refs {
+		if err = walkSchemaDynamic(pack, dr, walkFunc); err != nil {
+			break
+		}
+	}
+
+	if err == registry.ErrStopIteration {
+		err = nil
+	}
+
+	return
+}
+
+func walkSchemaDynamic(
+	pack registry.Pack,
+	dr registry.Dynamic,
+	walkFunc schemaWalkFunc,
+) (
+	err error,
+) {
+
+	if dr.IsValid() == false {
+		return registry.ErrInvalidDynamicReference
+	}
+
+	if dr.IsBlank() == true {
+		return
+	}
+
+	var sch registry.Schema
+	if sch, err = pack.Registry().SchemaByReference(dr.Schema); err != nil {
+		return
+	}
+
+	return walkSchemaHash(pack, sch, dr.Hash, walkFunc)
+}
+
+func walkSchemaHash(
+	pack registry.Pack,
+	sch registry.Schema,
+	hash cipher.SHA256,
+	walkFunc schemaWalkFunc,
+) (
+	err error,
+) {
+
+	if hash == (cipher.SHA256{}) {
+		return
+	}
+
+	var val []byte
+	if val, err = pack.Get(hash); err != nil {
+		return
+	}
+
+	var deepper bool
+	if deepper, err = walkFunc(hash, sch, val); err != nil {
+		return
+	}
+
+	if deepper == false || sch.HasReferences() == false {
+		return
+	}
+
+	return walkSchemaData(pack, sch, val, walkFunc)
+}
+
+func walkSchemaData(
+	pack registry.Pack,
+	sch registry.Schema,
+	val []byte,
+	walkFunc schemaWalkFunc,
+) (
+	err error,
+) {
+
+	if sch.IsReference() == true {
+		return walkSchemaReference(pack, sch, val, walkFunc)
+	}
+
+	switch sch.Kind() {
+	case reflect.Array:
+		return walkSchemaArraySlice(pack, sch.Elem(), sch.Len(), val, walkFunc)
+	case reflect.Slice:
+		var ln uint32
+		if _, err = encoder.DeserializeRaw(val, &ln); err != nil {
+			return
+		}
+		return walkSchemaArraySlice(pack, sch.Elem(), int(ln), val[4:],
+			walkFunc)
+	case reflect.Struct:
+		return walkSchemaStruct(pack, sch, val, walkFunc)
+	}
+
+	return fmt.Errorf("invalid Schema to walk through: %s", sch)
+}
+
+func walkSchemaReference(
+	pack registry.Pack,
+	sch registry.Schema,
+	val []byte,
+	walkFunc schemaWalkFunc,
+) (
+	err error,
+) {
+
+	switch rt := sch.ReferenceType(); rt {
+
+	case registry.ReferenceTypeSingle:
+
+		var ref registry.Ref
+		if _, err = encoder.DeserializeRaw(val, &ref); err != nil {
+			return
+		}
+
+		return walkSchemaHash(pack, sch.Elem(), ref.Hash, walkFunc)
+
+	case registry.ReferenceTypeSlice:
+
+		var refs registry.Refs
+		if _, err = encoder.DeserializeRaw(val, &refs); err != nil {
+			return
+		}
+
+		if refs.Hash == (cipher.SHA256{}) {
+			return
+		}
+
+		return refs.Ascend(pack, func(_ int, hash cipher.SHA256) error {
+			return walkSchemaHash(pack, sch.Elem(), hash, walkFunc)
+		})
+
+	case registry.ReferenceTypeDynamic:
+
+		var dr registry.Dynamic
+		if _, err = encoder.DeserializeRaw(val, &dr); err != nil {
+			return
+		}
+
+		return walkSchemaDynamic(pack, dr, walkFunc)
+
+	}
+
+	return fmt.Errorf("invalid ReferenceType to walk through: %s", sch)
+}
+
+func walkSchemaArraySlice(
+	pack registry.Pack,
+	el registry.Schema,
+	ln int,
+	val []byte,
+	walkFunc schemaWalkFunc,
+) (
+	err error,
+) {
+
+	if el == nil {
+		return fmt.Errorf("nil Schema of element of array or slice")
+	}
+
+	var shift, m int
+
+	for i := 0; i < ln; i++ {
+
+		if shift > len(val) {
+			return fmt.Errorf("unexpected end of encoded array or slice "+
+				"of <%s>, length: %d, index: %d", el, ln, i)
+		}
+
+		if m, err = el.Size(val[shift:]); err != nil {
+			return
+		}
+
+		err = walkSchemaData(pack, el, val[shift:shift+m], walkFunc)
+
+		if err != nil {
+			return
+		}
+
+		shift += m
+
+	}
+
+	return
+}
+
+func walkSchemaStruct(
+	pack registry.Pack,
+	sch registry.Schema,
+	val []byte,
+	walkFunc schemaWalkFunc,
+) (
+	err error,
+) {
+
+	var shift, s int
+
+	for i, fl := range sch.Fields() {
+
+		if shift > len(val) {
+			return fmt.Errorf("unexpected end of encoded struct <%s>, "+
+				"field number: %d, field name: %q", sch, i, fl.Name())
+		}
+
+		if s, err = fl.Schema().Size(val[shift:]); err != nil {
+			return
+		}
+
+		if fl.Schema().HasReferences() == true {
+			err = walkSchemaData(pack, fl.Schema(), val[shift:shift+s],
+				walkFunc)
+			if err != nil {
+				return
+			}
+		}
+
+		shift += s
+
+	}
+
+	return
+}
+
+// fieldValue returns encoded value of field with
+// given name of given encoded struct. The fieldValue
+// returns nil if the Schema has not such field
+func fieldValue(
+	sch registry.Schema, // : schema of the struct
+	val []byte, //          : encoded struct
+	name string, //         : name of the field
+) (
+	fv []byte, //           : encoded value of the field
+	err error, //           : an error
+) {
+
+	if sch.Kind() != reflect.Struct || sch.IsReference() == true {
+		return
+	}
+
+	var shift, s int
+
+	for _, fl := range sch.Fields() {
+
+		if shift > len(val) {
+			return nil, fmt.Errorf("unexpected end of encoded struct <%s>",
+				sch)
+		}
+
+		if s, err = fl.Schema().Size(val[shift:]); err != nil {
+			return
+		}
+
+		if fl.Name() == name {
+			return val[shift : shift+s], nil
+		}
+
+		shift += s
+
+	}
+
+	return
+}
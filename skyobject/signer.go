@@ -0,0 +1,54 @@
+package skyobject
+
+import (
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+// A Signer represents key that signs Root objects.
+// The Signer is public key and sign-hash function.
+// Thus, it's possible to keep secret key outside the
+// Container (e.g. in another process). See also the
+// NewSecKeySigner and the skyobject/signer package.
+// A Signer must be safe for concurrent use. A Root is
+// signed before it's saved, and if its head has been
+// changed meanwhile, then the Root is signed again
+type Signer interface {
+	// PubKey returns public key of the Signer
+	PubKey() (pk cipher.PubKey)
+	// SignHash signs given hash
+	SignHash(hash cipher.SHA256) (sig cipher.Sig, err error)
+}
+
+// a secKeySigner is default Signer
+type secKeySigner struct {
+	pk cipher.PubKey
+	sk cipher.SecKey
+}
+
+// NewSecKeySigner creates Signer using given
+// secret key. It's default Signer used by the
+// (*Container).Unpack
+func NewSecKeySigner(sk cipher.SecKey) (s Signer, err error) {
+
+	if err = sk.Verify(); err != nil {
+		return
+	}
+
+	var ss = &secKeySigner{sk: sk}
+
+	if ss.pk, err = cipher.PubKeyFromSecKey(sk); err != nil {
+		return
+	}
+
+	return ss, nil
+}
+
+// PubKey implements Signer interface
+func (s *secKeySigner) PubKey() cipher.PubKey {
+	return s.pk
+}
+
+// SignHash implements Signer interface
+func (s *secKeySigner) SignHash(hash cipher.SHA256) (cipher.Sig, error) {
+	return cipher.SignHash(hash, s.sk)
+}
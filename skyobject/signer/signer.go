@@ -0,0 +1,361 @@
+// Package signer implements signer daemon and its
+// client. The daemon keeps secret keys of feeds in
+// separate process and signs hashes by request. The
+// daemon listens on Unix socket, thus only local
+// processes (and only permitted by file system) can
+// use it. The socket must be in a directory that is
+// accessible only by owner of the daemon, since
+// permissions of a socket are not respected by all
+// systems. The Client implements skyobject.Signer
+// for every key the daemon has. For example
+//
+//     sc, err := signer.Dial("/path/to/signer.sock")
+//     if err != nil {
+//         // handle error
+//     }
+//     defer sc.Close()
+//
+//     s, err := sc.Signer(feedPublicKey)
+//     if err != nil {
+//         // handle error
+//     }
+//
+//     up, err := c.UnpackSigner(s, reg)
+//
+// See also cmd/cxosigner
+package signer
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/skyobject"
+)
+
+// name of the RPC service
+const serviceName = "signer"
+
+// DefaultTimeout is default timeout of requests of
+// the Client (see Dial and DialTimeout)
+const DefaultTimeout = 10 * time.Second
+
+// common errors
+var (
+	// ErrNoSuchKey occurs if the daemon
+	// doesn't have requested key
+	ErrNoSuchKey = errors.New("no such key")
+	// ErrTimeout occurs if the daemon
+	// doesn't reply in time
+	ErrTimeout = errors.New("timeout")
+)
+
+// SignArgs is arguments of the SignHash RPC method
+type SignArgs struct {
+	Pub  cipher.PubKey // key to sign with
+	Hash cipher.SHA256 // hash to sign
+}
+
+// A Server represents signer daemon
+type Server struct {
+	mx   sync.Mutex
+	keys map[cipher.PubKey]cipher.SecKey
+
+	l     net.Listener // underlying listener
+	r     *rpc.Server  //
+	await sync.WaitGroup
+}
+
+// NewServer creates Server with given secret keys.
+// Use AddKey to add keys after. Call Listen to
+// start the Server
+func NewServer(sks ...cipher.SecKey) (s *Server, err error) {
+
+	s = new(Server)
+	s.keys = make(map[cipher.PubKey]cipher.SecKey)
+	s.r = rpc.NewServer()
+
+	for _, sk := range sks {
+		if err = s.AddKey(sk); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = s.r.RegisterName(serviceName, &RPC{s}); err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+// AddKey adds given secret key to the Server
+func (s *Server) AddKey(sk cipher.SecKey) (err error) {
+
+	if err = sk.Verify(); err != nil {
+		return
+	}
+
+	var pk cipher.PubKey
+	if pk, err = cipher.PubKeyFromSecKey(sk); err != nil {
+		return
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.keys[pk] = sk
+	return
+}
+
+// PubKeys returns public keys of the Server
+func (s *Server) PubKeys() (pks []cipher.PubKey) {
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	pks = make([]cipher.PubKey, 0, len(s.keys))
+	for pk := range s.keys {
+		pks = append(pks, pk)
+	}
+	return
+}
+
+// SignHash signs given hash using secret key of given
+// public key. It returns ErrNoSuchKey if the Server
+// doesn't have the key
+func (s *Server) SignHash(
+	pk cipher.PubKey,
+	hash cipher.SHA256,
+) (
+	sig cipher.Sig,
+	err error,
+) {
+
+	s.mx.Lock()
+	var sk, ok = s.keys[pk]
+	s.mx.Unlock()
+
+	if ok == false {
+		return sig, ErrNoSuchKey
+	}
+
+	return cipher.SignHash(hash, sk)
+}
+
+// Listen on Unix socket with given path. The socket
+// is accessible only by owner of the process. The
+// Listen creates directory of the socket if it doesn't
+// exist. The directory must be accessible only by the
+// owner (0700), since other users can connect to the
+// socket before its mode is changed
+func (s *Server) Listen(path string) (err error) {
+
+	var dir = filepath.Dir(path)
+
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return
+	}
+
+	var fi os.FileInfo
+	if fi, err = os.Stat(dir); err != nil {
+		return
+	}
+
+	if fi.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("directory %s of the socket is accessible by "+
+			"other users (%s), use directory with 0700 mode", dir,
+			fi.Mode().Perm())
+	}
+
+	if s.l, err = net.Listen("unix", path); err != nil {
+		return
+	}
+
+	if err = os.Chmod(path, 0600); err != nil {
+		s.l.Close()
+		return
+	}
+
+	s.await.Add(1)
+	go s.run()
+
+	return
+}
+
+func (s *Server) run() {
+	defer s.await.Done()
+	s.r.Accept(s.l)
+}
+
+// Address returns path to socket
+func (s *Server) Address() (address string) {
+	if s.l != nil {
+		address = s.l.Addr().String()
+	}
+	return
+}
+
+// Close the Server. The Close removes the socket
+func (s *Server) Close() (err error) {
+	if s.l != nil {
+		err = s.l.Close()
+		s.await.Wait()
+	}
+	return
+}
+
+// A RPC represents RPC service of the Server.
+// The RPC is exported because the net/rpc
+// package requires it
+type RPC struct {
+	s *Server // back reference
+}
+
+// PubKeys is RPC method
+func (r *RPC) PubKeys(_ struct{}, pks *[]cipher.PubKey) (_ error) {
+	*pks = r.s.PubKeys()
+	return
+}
+
+// SignHash is RPC method
+func (r *RPC) SignHash(args SignArgs, sig *cipher.Sig) (err error) {
+	*sig, err = r.s.SignHash(args.Pub, args.Hash)
+	return
+}
+
+// A Client of signer daemon
+type Client struct {
+	c       *rpc.Client
+	timeout time.Duration
+}
+
+// Dial connects to signer daemon listening on
+// given Unix socket using DefaultTimeout
+func Dial(path string) (c *Client, err error) {
+	return DialTimeout(path, DefaultTimeout)
+}
+
+// DialTimeout connects to signer daemon listening on
+// given Unix socket. The timeout is used to connect and
+// for every request. If the daemon doesn't reply in
+// time, then the request fails with ErrTimeout. Zero
+// timeout means no timeout
+func DialTimeout(path string, timeout time.Duration) (c *Client, err error) {
+
+	var conn net.Conn
+	if conn, err = net.DialTimeout("unix", path, timeout); err != nil {
+		return
+	}
+
+	return &Client{rpc.NewClient(conn), timeout}, nil
+}
+
+// call RPC method with timeout; the reply can be
+// changed after the timeout, thus it must not be
+// used if the call fails
+func (c *Client) call(
+	method string, //        : name of the method
+	args interface{}, //     : arguments
+	reply interface{}, //    : reply
+) (
+	err error, //            : an error
+) {
+
+	if c.timeout == 0 {
+		return c.c.Call(serviceName+"."+method, args, reply)
+	}
+
+	var (
+		done = make(chan *rpc.Call, 1)
+		call = c.c.Go(serviceName+"."+method, args, reply, done)
+		tm   = time.NewTimer(c.timeout)
+	)
+
+	defer tm.Stop()
+
+	select {
+	case <-call.Done:
+		return call.Error
+	case <-tm.C:
+		return ErrTimeout
+	}
+
+}
+
+// PubKeys returns public keys of the daemon
+func (c *Client) PubKeys() (pks []cipher.PubKey, err error) {
+	var reply []cipher.PubKey
+	if err = c.call("PubKeys", struct{}{}, &reply); err != nil {
+		return
+	}
+	return reply, nil
+}
+
+// SignHash signs given hash using given key. The
+// SignHash verifies signature returned by daemon
+func (c *Client) SignHash(
+	pk cipher.PubKey,
+	hash cipher.SHA256,
+) (
+	sig cipher.Sig,
+	err error,
+) {
+
+	var reply cipher.Sig
+	if err = c.call("SignHash", SignArgs{pk, hash}, &reply); err != nil {
+		if err.Error() == ErrNoSuchKey.Error() {
+			err = ErrNoSuchKey
+		}
+		return
+	}
+
+	if err = cipher.VerifyPubKeySignedHash(pk, reply, hash); err != nil {
+		return
+	}
+
+	return reply, nil
+}
+
+// Signer returns skyobject.Signer of given
+// key. The daemon must have the key
+func (c *Client) Signer(pk cipher.PubKey) (s skyobject.Signer, err error) {
+
+	var pks []cipher.PubKey
+	if pks, err = c.PubKeys(); err != nil {
+		return
+	}
+
+	for _, x := range pks {
+		if x == pk {
+			return &remoteSigner{c, pk}, nil
+		}
+	}
+
+	return nil, ErrNoSuchKey
+}
+
+// Close the Client
+func (c *Client) Close() error {
+	return c.c.Close()
+}
+
+// a remoteSigner implements skyobject.Signer
+type remoteSigner struct {
+	c  *Client
+	pk cipher.PubKey
+}
+
+func (r *remoteSigner) PubKey() cipher.PubKey {
+	return r.pk
+}
+
+func (r *remoteSigner) SignHash(hash cipher.SHA256) (cipher.Sig, error) {
+	return r.c.SignHash(r.pk, hash)
+}
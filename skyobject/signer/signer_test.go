@@ -0,0 +1,181 @@
+package signer
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/skyobject"
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+func testServer(t *testing.T, sks ...cipher.SecKey) (s *Server, c *Client) {
+	t.Helper()
+
+	var dir, err = ioutil.TempDir("", "cxosigner")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s, err = NewServer(sks...); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = s.Listen(filepath.Join(dir, "signer.sock")); err != nil {
+		t.Fatal(err)
+	}
+
+	if c, err = Dial(s.Address()); err != nil {
+		t.Fatal(err)
+	}
+
+	return
+}
+
+func TestClient_Signer(t *testing.T) {
+
+	var (
+		pk, sk = cipher.GenerateKeyPair()
+		ok, _  = cipher.GenerateKeyPair() // other
+		s, sc  = testServer(t, sk)
+	)
+
+	defer os.RemoveAll(filepath.Dir(s.Address()))
+	defer s.Close()
+	defer sc.Close()
+
+	if _, err := sc.Signer(ok); err != ErrNoSuchKey {
+		t.Error("wrong error:", err)
+	}
+
+	if _, err := sc.SignHash(ok, cipher.SHA256{}); err != ErrNoSuchKey {
+		t.Error("wrong error:", err)
+	}
+
+	var rs, err = sc.Signer(pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// save a Root signed by the daemon
+
+	var conf = skyobject.NewConfig()
+	conf.InMemoryDB = true
+
+	var c *skyobject.Container
+	if c, err = skyobject.NewContainer(conf); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if err = c.AddFeed(pk); err != nil {
+		t.Fatal(err)
+	}
+
+	var reg = registry.NewRegistry(func(*registry.Reg) {})
+
+	var up *skyobject.Unpack
+	if up, err = c.UnpackSigner(rs, reg); err != nil {
+		t.Fatal(err)
+	}
+	defer up.Close()
+
+	var r = new(registry.Root)
+	r.Pub = pk
+	r.Nonce = 1
+
+	if err = c.Save(up, r); err != nil {
+		t.Fatal(err)
+	}
+
+	var val []byte
+	if val, _, err = c.Get(r.Hash, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = cipher.VerifyPubKeySignedHash(pk, r.Sig,
+		cipher.SumSHA256(val)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestServer_Listen(t *testing.T) {
+
+	var dir, err = ioutil.TempDir("", "cxosigner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err = os.Chmod(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var s *Server
+	if s, err = NewServer(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = s.Listen(filepath.Join(dir, "signer.sock")); err == nil {
+		s.Close()
+		t.Error("listen in directory accessible by others")
+	}
+
+	// create the directory
+	if err = s.Listen(filepath.Join(dir, "private", "signer.sock")); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	var fi os.FileInfo
+	if fi, err = os.Stat(filepath.Join(dir, "private")); err != nil {
+		t.Fatal(err)
+	}
+
+	if fi.Mode().Perm() != 0700 {
+		t.Error("wrong mode of created directory:", fi.Mode().Perm())
+	}
+
+}
+
+func TestClient_timeout(t *testing.T) {
+
+	var dir, err = ioutil.TempDir("", "cxosigner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the daemon never replies
+
+	var l net.Listener
+	if l, err = net.Listen("unix", filepath.Join(dir, "signer.sock")); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	go func() {
+		if conn, err := l.Accept(); err == nil {
+			defer conn.Close()
+			ioutil.ReadAll(conn) // ignore error
+		}
+	}()
+
+	var c *Client
+	if c, err = DialTimeout(l.Addr().String(), 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var pk, _ = cipher.GenerateKeyPair()
+
+	if _, err = c.SignHash(pk, cipher.SHA256{}); err != ErrTimeout {
+		t.Error("wrong error:", err)
+	}
+
+}
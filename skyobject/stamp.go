@@ -0,0 +1,116 @@
+package skyobject
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/data"
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+// root hash -> proof-of-work stamp
+var stampsBucket = []byte("skyobject.stamps")
+
+// Stamp makes the Unpack to compute proof-of-work
+// stamp of given difficulty for every Root saved
+// using it (see registry.StampBits). Public nodes
+// can require stamps to accept Roots of unknown
+// feeds. The stamp is computed before the Root is
+// saved, and it can take a while. Use zero
+// difficulty to turn stamps off
+func (u *Unpack) Stamp(difficulty int) (err error) {
+
+	if difficulty < 0 || difficulty > registry.MaxStampDifficulty {
+		return fmt.Errorf("invalid stamp difficulty %d", difficulty)
+	}
+
+	u.difficulty = difficulty
+	return
+}
+
+// compute stamp of Root with given hash,
+// it returns zero if stamps are turned off
+func (u *Unpack) stamp(hash cipher.SHA256) (stamp uint64, err error) {
+
+	if u.difficulty == 0 {
+		return
+	}
+
+	return registry.NewStamp(hash, u.difficulty)
+}
+
+// store stamp of a Root, the zero stamp is ignored
+func (c *Container) setStamp(hash cipher.SHA256, stamp uint64) (err error) {
+
+	if stamp == 0 {
+		return
+	}
+
+	return c.db.IdxDB().BucketsTx(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(stampsBucket); err != nil {
+			return
+		}
+
+		var val [8]byte
+		binary.LittleEndian.PutUint64(val[:], stamp)
+
+		return bk.Set(hash[:], val[:])
+
+	})
+
+}
+
+// remove stamp of a removed Root
+func (c *Container) delStamp(hash cipher.SHA256) (err error) {
+
+	return c.db.IdxDB().BucketsTx(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(stampsBucket); err != nil {
+			return
+		}
+
+		return bk.Del(hash[:])
+
+	})
+
+}
+
+// Stamp returns proof-of-work stamp of Root with given
+// hash. The Stamp returns zero if the Root has no stamp.
+// Stamps of saved Roots are computed by Unpack (see
+// (*Unpack).Stamp), and stamps of received Roots are
+// stored by AddRoot
+func (c *Container) Stamp(hash cipher.SHA256) (stamp uint64, err error) {
+
+	err = c.db.IdxDB().BucketsView(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(stampsBucket); err != nil {
+			return
+		}
+
+		var val []byte
+		switch val, err = bk.Get(hash[:]); err {
+		case nil:
+		case data.ErrNotFound:
+			return nil
+		default:
+			return
+		}
+
+		if len(val) != 8 {
+			return fmt.Errorf("invalid stamp of Root %s", hash.Hex()[:7])
+		}
+
+		stamp = binary.LittleEndian.Uint64(val)
+		return
+
+	})
+
+	return
+}
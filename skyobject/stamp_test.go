@@ -0,0 +1,104 @@
+package skyobject
+
+import (
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+func TestUnpack_Stamp(t *testing.T) {
+
+	var (
+		c      = getTestContainer()
+		rc     = getTestContainer() // receiver
+		pk, sk = cipher.GenerateKeyPair()
+	)
+
+	defer c.Close()
+	defer rc.Close()
+
+	assertNil(t, c.AddFeed(pk))
+	assertNil(t, rc.AddFeed(pk))
+
+	var up, err = c.Unpack(sk, testRegistry)
+	assertNil(t, err)
+	defer up.Close()
+
+	if err = up.Stamp(-1); err == nil {
+		t.Error("missing error")
+	}
+
+	assertNil(t, up.Stamp(10))
+
+	var r = new(registry.Root)
+	r.Pub = pk
+	r.Nonce = 1
+
+	assertNil(t, c.Save(up, r))
+
+	if registry.StampBits(r.Hash, r.Stamp) < 10 {
+		t.Fatal("underpowered stamp")
+	}
+
+	var stamp uint64
+	stamp, err = c.Stamp(r.Hash)
+	assertNil(t, err)
+
+	if stamp != r.Stamp {
+		t.Error("stamp is not stored")
+	}
+
+	t.Run("received", func(t *testing.T) {
+
+		var val, _, err = c.Get(r.Hash, 0)
+		assertNil(t, err)
+
+		var x *registry.Root
+		x, err = rc.ReceivedRoot(pk, r.Sig, val)
+		assertNil(t, err)
+
+		x.IsFull = true
+		x.Stamp = r.Stamp
+		_, err = rc.AddRoot(x)
+		assertNil(t, err)
+
+		var stamp uint64
+		if stamp, err = rc.Stamp(r.Hash); err != nil {
+			t.Fatal(err)
+		} else if stamp != r.Stamp {
+			t.Error("stamp is not stored")
+		}
+
+	})
+
+	t.Run("removed", func(t *testing.T) {
+
+		assertNil(t, c.DelRoot(pk, 1, r.Seq))
+
+		if stamp, err = c.Stamp(r.Hash); err != nil {
+			t.Fatal(err)
+		} else if stamp != 0 {
+			t.Error("stamp is not removed")
+		}
+
+	})
+
+	t.Run("no stamp", func(t *testing.T) {
+
+		assertNil(t, up.Stamp(0))
+
+		var nr = new(registry.Root)
+		nr.Pub = pk
+		nr.Nonce = 1
+
+		assertNil(t, c.Save(up, nr))
+
+		if nr.Stamp != 0 {
+			t.Error("unexpected stamp")
+		}
+
+	})
+
+}
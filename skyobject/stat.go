@@ -34,6 +34,10 @@ type Stat struct {
 
 	// Feeds contains statistic of feeds
 	Feeds map[cipher.PubKey]FeedStat
+
+	// Evidence is number of stored evidence
+	// of equivocation (see Evidence method)
+	Evidence int
 }
 
 // An ObjectsStat represents
@@ -54,6 +58,10 @@ type ReadWriteStat struct {
 type FeedStat struct {
 	// Hads contains statistic of heads
 	Heads map[uint64]HeadStat
+
+	// Evidence is number of stored
+	// evidence of equivocation
+	Evidence int
 }
 
 // A HeadStat represents statistic of
@@ -108,13 +116,23 @@ func (c *Container) Stat() (s *Stat) {
 
 	s.Feeds = c.Index.feedsStat()
 
+	var feeds map[cipher.PubKey]int
+	s.Evidence, feeds = c.evidenceStat()
+
+	for pk, n := range feeds {
+		if fs, ok := s.Feeds[pk]; ok == true {
+			fs.Evidence = n
+			s.Feeds[pk] = fs
+		}
+	}
+
 	return
 }
 
 func (i *Index) feedsStat() (s map[cipher.PubKey]FeedStat) {
 
-	i.mx.Lock()
-	defer i.mx.Unlock()
+	i.mx.RLock()
+	defer i.mx.RUnlock()
 
 	s = make(map[cipher.PubKey]FeedStat)
 
@@ -136,6 +154,8 @@ func (i *Index) feedsStat() (s map[cipher.PubKey]FeedStat) {
 				continue // ignore error
 			}
 
+			hs.mx.Lock() // lock the feed
+
 			sf.Heads = make(map[uint64]HeadStat)
 
 			//
@@ -184,6 +204,8 @@ func (i *Index) feedsStat() (s map[cipher.PubKey]FeedStat) {
 
 			}
 
+			hs.mx.Unlock()
+
 			s[pk] = sf
 
 			//
@@ -19,10 +19,18 @@ type unpackItem struct {
 // An Unpack implements registry.Pack
 // and used to change or cerate a Root
 type Unpack struct {
-	m     map[cipher.SHA256]*unpackItem // hash -> rc
-	c     *Container                    // Set method
-	*Pack                               // other methods
-	sk    cipher.SecKey                 // owner
+	m      map[cipher.SHA256]*unpackItem // hash -> rc
+	c      *Container                    // Set method
+	*Pack                                // other methods
+	signer Signer                        // owner
+	pk     cipher.PubKey                 // public key of the signer
+
+	certs []registry.Certificate // chain of a delegate
+	revs  []registry.Revocation  // revocations to publish
+
+	readers []cipher.PubKey // readers of encrypted feed
+
+	difficulty int // proof-of-work stamp of saved Roots
 }
 
 func (u *Unpack) reset() {
@@ -31,7 +39,7 @@ func (u *Unpack) reset() {
 	}
 }
 
-// Set value
+// Set value. The Set never encrypts the value
 func (u *Unpack) Set(key cipher.SHA256, val []byte) (err error) {
 
 	var rc int
@@ -39,6 +47,8 @@ func (u *Unpack) Set(key cipher.SHA256, val []byte) (err error) {
 		return
 	}
 
+	u.c.prioritize(u.pk, key)
+
 	var ui, ok = u.m[key]
 
 	if ok == false {
@@ -47,15 +57,21 @@ func (u *Unpack) Set(key cipher.SHA256, val []byte) (err error) {
 	}
 
 	ui.inc++
-	ui.created = (rc == 1)
+	ui.created = ui.created || (rc == 1) // created by first Set
 
 	return
 }
 
-// Add value
+// Add value. The value is encrypted if
+// the Unpack is encrypted (see Encrypt)
 func (u *Unpack) Add(val []byte) (key cipher.SHA256, err error) {
 
-	key = cipher.SumSHA256(val)
+	if u.Pack.pc != nil {
+		key, val = u.Pack.pc.seal(val)
+	} else {
+		key = cipher.SumSHA256(val)
+	}
+
 	err = u.Set(key, val) // use Set of the Unpack
 	return
 
@@ -63,7 +79,8 @@ func (u *Unpack) Add(val []byte) (key cipher.SHA256, err error) {
 
 // Unpack creates Unpack using given registry. Use
 // the Unapck to modify a Root object and to save
-// cahnges after.
+// cahnges after. The Unpack signs Roots using given
+// secret key. See also UnpackSigner
 func (c *Container) Unpack(
 	sk cipher.SecKey,
 	reg *registry.Registry,
@@ -72,22 +89,56 @@ func (c *Container) Unpack(
 	err error,
 ) {
 
+	var s Signer
+	if s, err = NewSecKeySigner(sk); err != nil {
+		return
+	}
+
+	return c.UnpackSigner(s, reg)
+}
+
+// UnpackSigner is the same as the Unpack, but Roots
+// saved using the Unpack are signed by given Signer.
+// Use the UnpackSigner to keep secret key outside
+// the process (see skyobject/signer package). The
+// certs is optional chain of certificates if the
+// Signer is a delegate (see UnpackDelegate)
+func (c *Container) UnpackSigner(
+	s Signer, //                           : signer
+	reg *registry.Registry, //             : registry to use
+	certs ...registry.Certificate, //      : chain (optional)
+) (
+	up *Unpack, //                         : the Unpack
+	err error, //                          : an error
+) {
+
 	if reg == nil {
 		err = errors.New("Registry is nil")
 		return
 	}
 
-	if err = sk.Verify(); err != nil {
+	if s == nil {
+		err = errors.New("Signer is nil")
 		return
 	}
 
+	if len(certs) > 0 {
+		if err = c.checkCertificates(s.PubKey(), certs); err != nil {
+			return
+		}
+	}
+
 	up = &Unpack{
-		sk:   sk,
-		c:    c,
-		m:    make(map[cipher.SHA256]*unpackItem),
-		Pack: c.getPack(reg),
+		signer: s,
+		pk:     s.PubKey(),
+		c:      c,
+		m:      make(map[cipher.SHA256]*unpackItem),
+		Pack:   c.getPack(reg),
+		certs:  append([]registry.Certificate{}, certs...),
 	}
 
+	up.Pack.pk = up.pk
+
 	c.AddRegistryToCache(reg) // cache
 
 	return
@@ -98,10 +149,141 @@ func (c *Container) Unpack(
 // timestamp of the Root. The Root should have correct
 // Pub, and Nonce fields. The Seq field will be set
 // to next inside the Save. The Save also set Hash and
-// Prev fields of the Root, and signs the Root
+// Prev fields of the Root, and signs the Root. If the
+// Save fails updating field indexes, then the Root is
+// already saved and the error is *IndexRootError.
+// The Save checks constraints of objects created using
+// given Unpack (see registry.Constraints) and returns
+// *InvalidObjectError if an object is invalid. If the
+// Unpack requires proof-of-work stamp (see Stamp method
+// of the Unpack), then the Save computes the stamp and
+// sets Stamp field of the Root. See SaveTx for
+// details about rc of objects of the Root
 func (c *Container) Save(up *Unpack, r *registry.Root) (err error) {
+	return c.saveRoots([]saveItem{{up: up, r: r}})
+}
+
+// SaveIf is the same as the Save, but it saves given
+// Root only if last Root of the head is the expected
+// one (compare-and-swap). Use blank hash to save first
+// Root of a blank head. If the head has been changed,
+// then the SaveIf returns *SaveConflictError and the
+// Unpack should be closed. See also Update method
+func (c *Container) SaveIf(
+	up *Unpack, //            : unpack
+	r *registry.Root, //      : the Root to save
+	prev cipher.SHA256, //    : expected last Root
+) (
+	err error, //             : an error
+) {
+	return c.saveRoots([]saveItem{{up: up, r: r, cas: true, prev: prev}})
+}
+
+// a saveItem is Root with its Unpack
+type saveItem struct {
+	up *Unpack
+	r  *registry.Root
+
+	cas  bool          // check previous Root (see SaveIf)
+	prev cipher.SHA256 // expected previous Root
+}
+
+// save given Roots atomically in IdxDB; changes
+// of rc in CXDS are journaled (see SaveTx)
+func (c *Container) saveRoots(items []saveItem) (err error) {
+
+	var committed bool // saved in IdxDB
+
+	defer func() {
+		if err != nil && committed == false {
+			for _, it := range items {
+				it.up.reset()
+			}
+		}
+	}()
+
+	// check and walk the Roots first
+
+	for _, it := range items {
+		if err = c.prepareRoot(it.up, it.r); err != nil {
+			return
+		}
+	}
+
+	var jr *rcJournal // changes of rc to apply after
+
+	for {
+
+		// sign the Roots outside the IdxDB transaction,
+		// since a Signer can be slow (e.g. remote)
+
+		if err = c.signRoots(items); err != nil {
+			return
+		}
+
+		jr = newRCJournal(items)
+
+		// save into Index and IdxDB with the changes of rc
+
+		if err = c.Index.saveRoots(items, jr); err != errHeadChanged {
+			break
+		}
 
-	// save the Root recursive
+		// a head has been changed by another Save, sign again
+
+	}
+
+	if err != nil {
+		return
+	}
+
+	committed = true
+
+	for _, it := range items {
+		it.up.saved()
+	}
+
+	// store published revocations
+
+	for _, it := range items {
+		if err = c.addRevocations(it.r); err != nil {
+			return
+		}
+	}
+
+	// proof-of-work stamps computed before; the Roots are
+	// saved and have the stamps, thus a Save never fails
+	// storing them (a Root without stored stamp is sent
+	// to peers without the stamp later)
+
+	for _, it := range items {
+		c.setStamp(it.r.Hash, it.r.Stamp) // ignore error
+	}
+
+	// make rc of related objects actual
+
+	if err = c.applyRCJournal(jr); err != nil {
+		return // the Roots are saved, the rc is changed on next start
+	}
+
+	for _, it := range items {
+		c.Emit(rootEvent(RootSaved, it.r.Pub, it.r.Nonce, it.r.Seq, it.r.Hash))
+	}
+
+	// the Roots are saved, update field indexes
+
+	for _, it := range items {
+		if ierr := c.indexRoot(it.up, it.r); ierr != nil && err == nil {
+			err = &IndexRootError{Root: it.r.Hash, Err: ierr}
+		}
+	}
+
+	return
+}
+
+// check given Root before saving and walk it to
+// increment rc of existing objects the Root uses
+func (c *Container) prepareRoot(up *Unpack, r *registry.Root) (err error) {
 
 	if r.Pub == (cipher.PubKey{}) {
 		return errors.New("blank Pub field of the Root")
@@ -127,17 +309,34 @@ func (c *Container) Save(up *Unpack, r *registry.Root) (err error) {
 
 	}
 
-	// walk the Root first
+	// check constraints of new objects
 
-	defer func() {
-		if err != nil {
-			up.reset()
-		}
-	}()
+	err = checkRoot(up, r, func(hash cipher.SHA256) bool {
+		var ui, ok = up.m[hash]
+		return ok && ui.created
+	})
+
+	if err != nil {
+		return
+	}
+
+	// envelope of encrypted Root
+
+	if err = up.saveEnvelope(r); err != nil {
+		return
+	}
+
+	// walk the Root
+
+	var sealed map[cipher.SHA256]struct{} // walked by links
+
+	if r.IsEncrypted() == true {
+		sealed = make(map[cipher.SHA256]struct{})
+	}
 
 	for _, dr := range r.Refs {
 
-		err = dr.Walk(up, func(
+		var walkFunc = func(
 			hash cipher.SHA256, // :
 			_ int, //              :
 		) (
@@ -149,6 +348,10 @@ func (c *Container) Save(up *Unpack, r *registry.Root) (err error) {
 				return
 			}
 
+			if sealed != nil {
+				sealed[hash] = struct{}{}
+			}
+
 			// go deepper only if the object was created
 
 			var ui, ok = up.m[hash]
@@ -183,7 +386,13 @@ func (c *Container) Save(up *Unpack, r *registry.Root) (err error) {
 			deepper = ui.created
 			return
 
-		})
+		}
+
+		if sealed != nil {
+			err = walkSealed(c.getSealed, dr.Hash, walkFunc)
+		} else {
+			err = dr.Walk(up, walkFunc)
+		}
 
 		if err != nil {
 			return
@@ -191,132 +400,257 @@ func (c *Container) Save(up *Unpack, r *registry.Root) (err error) {
 
 	}
 
-	// ok, let's save the Root
+	// links of new encrypted objects must be complete
 
-	// check out Index first (has feed)
+	if sealed != nil {
+		if err = checkSealedRoot(up, r, sealed); err != nil {
+			return
+		}
+	}
+
+	// check out Index (has feed)
 	if c.HasFeed(r.Pub) == false {
 		return data.ErrNoSuchFeed
 	}
 
-	// save into Index and IdxDB
-	var val []byte
+	return
+}
 
-	if val, err = c.Index.saveRoot(up, r); err != nil {
-		return
-	}
+// head of a feed
+type headKey struct {
+	pk    cipher.PubKey
+	nonce uint64
+}
 
-	// save the Root in CXDS
+// set Seq, Prev, Time, Delegation, Hash and Stamp fields
+// of given Roots, sign them and save in CXDS; the Roots
+// of the same head are chained in order of the items
+func (c *Container) signRoots(items []saveItem) (err error) {
 
-	if err = up.Set(r.Hash, val); err != nil {
-		return
+	type last struct {
+		seq  uint64
+		hash cipher.SHA256
 	}
 
-	// save registry
+	var lasts = make(map[headKey]last)
 
-	if err = up.Set(cipher.SHA256(r.Reg), up.Registry().Encode()); err != nil {
-		return
-	}
+	for _, it := range items {
 
-	// make rc of related objects actual
+		var (
+			hk = headKey{it.r.Pub, it.r.Nonce}
+			lr last
 
-	for key, ui := range up.m {
+			ok bool
+		)
 
-		if key == r.Hash || key == cipher.SHA256(r.Reg) {
-			ui.dec++
+		if lr, ok = lasts[hk]; ok == false {
+			lr.seq, lr.hash, err = c.Index.lastSeqHash(hk.pk, hk.nonce)
+			if err != nil {
+				return
+			}
 		}
 
-		var inc = ui.dec - ui.inc
-
-		if inc < 0 {
-			// decrement
-			if _, err = c.Inc(key, inc); err != nil {
-				return // leave the CXDS broken if an error occurred
+		if it.cas == true && lr.hash != it.prev {
+			return &SaveConflictError{
+				Pub:      it.r.Pub,
+				Nonce:    it.r.Nonce,
+				Expected: it.prev,
+				Actual:   lr.hash,
 			}
 		}
 
-		delete(up.m, key) // saved
+		if err = c.signRoot(it.up, it.r, lr.seq, lr.hash); err != nil {
+			return
+		}
+
+		lasts[hk] = last{it.r.Seq, it.r.Hash}
 
 	}
 
 	return
 }
 
-func (i *Index) saveRoot(
-	up *Unpack,
-	r *registry.Root,
+// set Seq, Prev, Time, Delegation, Hash and Stamp
+// fields of given Root, sign the Root and save it in
+// CXDS with its Registry
+func (c *Container) signRoot(
+	up *Unpack, //              : the Unpack
+	r *registry.Root, //        : the Root
+	lastSeq uint64, //          : seq of last Root of the head
+	lastHash cipher.SHA256, //  : hash of the last Root or blank
 ) (
-	val []byte,
-	err error,
+	err error, //               : an error
 ) {
 
-	i.mx.Lock()
-	defer i.mx.Unlock()
+	if lastHash != (cipher.SHA256{}) {
+		r.Seq = lastSeq + 1
+		r.Prev = lastHash
+	} else {
+		r.Seq, r.Prev = 0, cipher.SHA256{} // first (reset if retried)
+	}
 
-	// val []byte --> encoded Root
-	var dr = new(data.Root)
+	r.Time = time.Now().UnixNano()
 
-	err = i.c.db.IdxDB().Tx(func(fs data.Feeds) (err error) {
-		var hs data.Heads
-		if hs, err = fs.Heads(r.Pub); err != nil {
-			return // no such feed
-		}
-		var roots data.Roots
-		if roots, err = hs.Add(r.Nonce); err != nil {
-			return
-		}
+	// certificates of a delegate (if any)
 
-		var (
-			lastSeq  uint64
-			lastHash cipher.SHA256
-		)
+	r.Delegation = up.delegation()
 
-		// get last
-		err = roots.Descend(func(dr *data.Root) (err error) {
-			lastSeq = dr.Seq
-			lastHash = dr.Hash
-			return data.ErrStopIteration // enough
-		})
+	if r.Signer() != up.pk {
+		return errors.New("the Unpack can't sign Root of another feed")
+	}
 
-		if err != nil {
-			return
-		}
+	if err = r.VerifyDelegation(); err != nil {
+		return // expired
+	}
 
-		if lastHash != (cipher.SHA256{}) {
-			r.Seq = lastSeq + 1
-			r.Prev = lastHash
-		}
+	// hash of the Root
+
+	var val = r.Encode()
+	r.Hash = cipher.SumSHA256(val)
+	r.IsFull = true
 
-		// else -> 0 and blank
+	// sign
 
-		r.Time = time.Now().UnixNano()
+	if r.Sig, err = up.signer.SignHash(r.Hash); err != nil {
+		return
+	}
 
-		// hash of the Root
+	// proof-of-work stamp (if required)
 
-		val = r.Encode()
-		r.Hash = cipher.SumSHA256(val)
-		r.IsFull = true
+	if r.Stamp, err = up.stamp(r.Hash); err != nil {
+		return
+	}
 
-		// sign
+	// save the Root and its registry in CXDS; if the Root
+	// will be signed again, then the rcJournal rejects
+	// previous one
+
+	if err = up.Set(r.Hash, val); err != nil {
+		return
+	}
+
+	return up.Set(cipher.SHA256(r.Reg), up.Registry().Encode())
+}
+
+// objects of the Unpack are saved, and the
+// changes of their rc are journaled
+func (u *Unpack) saved() {
+	for key := range u.m {
+		delete(u.m, key)
+	}
+}
+
+// saveRoots saves given signed Roots in IdxDB using
+// one transaction with given changes of rc; the Roots
+// and their registries are saved in CXDS before (see
+// signRoots), thus the Roots never point to missing
+// objects. It returns the errHeadChanged if a Root has
+// been signed for a head that has been changed after
+func (i *Index) saveRoots(items []saveItem, jr *rcJournal) (err error) {
+
+	var pks = make([]cipher.PubKey, 0, len(items))
+
+	for _, it := range items {
+		pks = append(pks, it.r.Pub)
+	}
+
+	defer i.lockFeeds(pks)()
+
+	var drs = make([]*data.Root, 0, len(items))
+
+	err = i.c.db.IdxDB().FeedsBucketsTx(func(
+		fs data.Feeds,
+		bs data.Buckets,
+	) (
+		err error,
+	) {
+
+		drs = drs[:0] // for sure
+
+		for _, it := range items {
+
+			var dr *data.Root
+			if dr, err = i.saveRootTx(fs, it); err != nil {
+				return
+			}
+
+			drs = append(drs, dr)
 
-		if r.Sig, err = cipher.SignHash(r.Hash, up.sk); err != nil {
-			return err
 		}
 
-		dr.Seq = r.Seq
-		dr.Prev = r.Prev
-		dr.Hash = r.Hash
-		dr.Sig = r.Sig
-		dr.Time = r.Time
+		return jr.saveTx(bs)
+
+	})
+
+	if err != nil {
+		return
+	}
+
+	for k, it := range items {
+		i.addSavedRoot(it.r, drs[k])
+	}
+
+	return
+}
+
+// save Root inside IdxDB transaction
+func (i *Index) saveRootTx(
+	fs data.Feeds,
+	it saveItem,
+) (
+	dr *data.Root,
+	err error,
+) {
+
+	var r = it.r
+
+	var hs data.Heads
+	if hs, err = fs.Heads(r.Pub); err != nil {
+		return // no such feed
+	}
+	var roots data.Roots
+	if roots, err = hs.Add(r.Nonce); err != nil {
+		return
+	}
 
-		return roots.Set(dr) // save
+	var lastHash cipher.SHA256
 
+	// get last
+	err = roots.Descend(func(dr *data.Root) (err error) {
+		lastHash = dr.Hash
+		return data.ErrStopIteration // enough
 	})
 
 	if err != nil {
 		return
 	}
 
-	i.addSavedRoot(r, dr)
+	// the Root has been signed outside the transaction,
+	// check that the head has not been changed after
+
+	if lastHash != r.Prev {
+
+		if it.cas == true {
+			return nil, &SaveConflictError{
+				Pub:      r.Pub,
+				Nonce:    r.Nonce,
+				Expected: it.prev,
+				Actual:   lastHash,
+			}
+		}
+
+		return nil, errHeadChanged // sign again
+	}
+
+	dr = new(data.Root)
+
+	dr.Seq = r.Seq
+	dr.Prev = r.Prev
+	dr.Hash = r.Hash
+	dr.Sig = r.Sig
+	dr.Time = r.Time
+
+	err = roots.Set(dr) // save
 	return
 }
 
@@ -0,0 +1,130 @@
+package skyobject
+
+import (
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/data"
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+// An UpdateFunc used by the Update to change a Root.
+// The Root is copy of last Root of a head, or new blank
+// Root if the head is blank. The UpdateFunc can be
+// called many times, and it should not keep the Unpack
+// or the Root after return. Use the Unpack to get and
+// create objects. Any error returned by the UpdateFunc
+// terminates the Update
+type UpdateFunc func(up *Unpack, r *registry.Root) (err error)
+
+// Update applies given function to last Root of given
+// head and saves the result using SaveIf. If the head
+// has been changed during the updating, then the Update
+// repeats the updating with new last Root, up to given
+// number of attempts. Use zero or negative attempts to
+// repeat until success. If all attempts failed, then
+// the Update returns *SaveConflictError. Registry of
+// the last Root should be the same as given, or the
+// last Root should be blank. The Update returns saved
+// Root. If the last Root is encrypted, then new Root
+// is encrypted using the same key for the same readers
+// (the Container must have key of a reader)
+func (c *Container) Update(
+	sk cipher.SecKey, //            : owner
+	reg *registry.Registry, //      : registry to use
+	pk cipher.PubKey, //            : feed
+	nonce uint64, //                : head
+	attempts int, //                : max attempts
+	updateFunc UpdateFunc, //       : the function
+) (
+	r *registry.Root, //            : saved Root
+	err error, //                   : an error
+) {
+
+	for k := 0; attempts <= 0 || k < attempts; k++ {
+
+		if r, err = c.update(sk, reg, pk, nonce, updateFunc); err == nil {
+			return
+		}
+
+		if _, ok := err.(*SaveConflictError); ok == false {
+			return nil, err
+		}
+
+	}
+
+	return nil, err // conflict
+}
+
+// perform one attempt of the Update
+func (c *Container) update(
+	sk cipher.SecKey,
+	reg *registry.Registry,
+	pk cipher.PubKey,
+	nonce uint64,
+	updateFunc UpdateFunc,
+) (
+	r *registry.Root,
+	err error,
+) {
+
+	var last *registry.Root
+
+	switch last, err = c.LastRoot(pk, nonce); err {
+	case nil:
+	case data.ErrNotFound, data.ErrNoSuchHead:
+		last, err = nil, nil // blank head
+	default:
+		return
+	}
+
+	var up *Unpack
+	if up, err = c.Unpack(sk, reg); err != nil {
+		return
+	}
+
+	defer up.Close() // reject unused objects
+
+	// encrypted head is kept encrypted for the same readers
+
+	if last != nil && last.IsEncrypted() == true {
+
+		var e *Envelope
+		if e, err = c.Envelope(last); err != nil {
+			return
+		}
+
+		var ck ContentKey
+		if ck, err = c.openEnvelope(e); err != nil {
+			return
+		}
+
+		if err = up.Encrypt(ck, e.Readers()...); err != nil {
+			return
+		}
+
+	}
+
+	r = new(registry.Root)
+
+	r.Pub = pk
+	r.Nonce = nonce
+
+	var prev cipher.SHA256
+
+	if last != nil {
+		r.Reg = last.Reg
+		r.Refs = append([]registry.Dynamic{}, last.Refs...)
+		r.Descriptor = append([]byte{}, last.Descriptor...)
+		prev = last.Hash
+	}
+
+	if err = updateFunc(up, r); err != nil {
+		return nil, err
+	}
+
+	if err = c.SaveIf(up, r, prev); err != nil {
+		return nil, err
+	}
+
+	return
+}
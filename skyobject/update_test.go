@@ -0,0 +1,154 @@
+package skyobject
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+func TestContainer_SaveIf(t *testing.T) {
+
+	var (
+		c      = getTestContainer()
+		pk, sk = cipher.GenerateKeyPair()
+	)
+
+	defer c.Close()
+
+	assertNil(t, c.AddFeed(pk))
+
+	var save = func(prev cipher.SHA256) (r *registry.Root, err error) {
+
+		var up *Unpack
+		if up, err = c.Unpack(sk, testRegistry); err != nil {
+			return
+		}
+		defer up.Close()
+
+		r = new(registry.Root)
+		r.Pub = pk
+		r.Nonce = 1
+
+		err = c.SaveIf(up, r, prev)
+		return
+	}
+
+	var first, err = save(cipher.SHA256{}) // blank head
+	assertNil(t, err)
+
+	var second *registry.Root
+	second, err = save(first.Hash)
+	assertNil(t, err)
+
+	// stale
+	_, err = save(first.Hash)
+
+	if sce, ok := err.(*SaveConflictError); ok == false {
+		t.Fatal("wrong error:", err)
+	} else if sce.Actual != second.Hash || sce.Expected != first.Hash {
+		t.Error("wrong hashes of the conflict")
+	}
+
+	if _, err = save(cipher.SHA256{}); err == nil {
+		t.Error("missing error")
+	}
+
+	var last *registry.Root
+	last, err = c.LastRoot(pk, 1)
+	assertNil(t, err)
+
+	if last.Hash != second.Hash {
+		t.Error("the head has been changed")
+	}
+
+}
+
+func TestContainer_Update(t *testing.T) {
+
+	var (
+		c      = getTestContainer()
+		pk, sk = cipher.GenerateKeyPair()
+	)
+
+	defer c.Close()
+
+	assertNil(t, c.AddFeed(pk))
+
+	const n = 10
+
+	var (
+		wg    sync.WaitGroup
+		calls = make(chan struct{}, 1000)
+	)
+
+	wg.Add(n)
+
+	for i := 0; i < n; i++ {
+
+		go func(i int) {
+			defer wg.Done()
+
+			var _, err = c.Update(sk, testRegistry, pk, 1, 0,
+				func(up *Unpack, r *registry.Root) (_ error) {
+					calls <- struct{}{}
+					r.Refs = append(r.Refs, createDynamic(up, testRegistry,
+						"test.User", &User{strconv.Itoa(i), uint32(i)}))
+					return
+				})
+
+			if err != nil {
+				t.Error(err)
+			}
+
+		}(i)
+
+	}
+
+	wg.Wait()
+
+	var r, err = c.LastRoot(pk, 1)
+	assertNil(t, err)
+
+	if len(r.Refs) != n {
+		t.Errorf("lost updates: %d Refs, want %d", len(r.Refs), n)
+	}
+
+	if r.Seq != n-1 {
+		t.Error("wrong seq", r.Seq)
+	}
+
+	if len(calls) < n {
+		t.Error("too few calls", len(calls))
+	}
+
+	t.Run("attempts", func(t *testing.T) {
+
+		var other *registry.Root
+
+		_, err = c.Update(sk, testRegistry, pk, 1, 2,
+			func(up *Unpack, r *registry.Root) (err error) {
+				// change the head concurrently
+				other, err = c.Update(sk, testRegistry, pk, 1, 1,
+					func(*Unpack, *registry.Root) error { return nil })
+				return
+			})
+
+		if _, ok := err.(*SaveConflictError); ok == false {
+			t.Fatal("wrong error:", err)
+		}
+
+		if r, err = c.LastRoot(pk, 1); err != nil {
+			t.Fatal(err)
+		}
+
+		if r.Hash != other.Hash {
+			t.Error("wrong last Root")
+		}
+
+	})
+
+}
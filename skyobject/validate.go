@@ -0,0 +1,47 @@
+package skyobject
+
+import (
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+// checkRoot checks constraints of objects of given Root
+// (see registry.Constraints). The isNew function used to
+// skip objects and their subtrees that already checked.
+// The checkRoot returns *InvalidObjectError if an object
+// violates constraints of its Schema
+func checkRoot(
+	pack registry.Pack, //                : pack to get objects
+	r *registry.Root, //                   : the Root to check
+	isNew func(hash cipher.SHA256) bool, // : new objects only
+) (
+	err error, //                          : an error
+) {
+
+	if pack.Registry().HasConstraints() == false {
+		return // fast path
+	}
+
+	return walkSchemaRoot(pack, r, func(
+		hash cipher.SHA256,
+		sch registry.Schema,
+		val []byte,
+	) (
+		deepper bool,
+		err error,
+	) {
+
+		if isNew(hash) == false {
+			return // already checked
+		}
+
+		if err = registry.CheckConstraints(pack, sch, val); err != nil {
+			return false, &InvalidObjectError{hash, err}
+		}
+
+		return true, nil
+
+	})
+
+}
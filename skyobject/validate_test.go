@@ -0,0 +1,183 @@
+package skyobject
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/data"
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+type ValidUser struct {
+	Name string `skyobject:"required,maxlen=10"`
+	Age  uint32 `skyobject:"max=150"`
+}
+
+type ValidFeed struct {
+	Head  string        `skyobject:"required"`
+	Users registry.Refs `skyobject:"schema=test.ValidUser,maxlen=2"`
+}
+
+var validRegistry = registry.NewRegistry(func(r *registry.Reg) {
+	r.Register("test.ValidUser", ValidUser{})
+	r.Register("test.ValidFeed", ValidFeed{})
+})
+
+func TestContainer_Save_constraints(t *testing.T) {
+
+	var (
+		sc     = getTestContainer()
+		pk, sk = cipher.GenerateKeyPair()
+	)
+
+	defer sc.Close()
+
+	assertNil(t, sc.AddFeed(pk))
+
+	var up, err = sc.Unpack(sk, validRegistry)
+	assertNil(t, err)
+
+	var r = new(registry.Root)
+
+	r.Pub = pk
+	r.Nonce = 1
+
+	var feed = ValidFeed{Head: "feed"}
+
+	assertNil(t, feed.Users.AppendValues(up,
+		ValidUser{"Alice", 19},
+		ValidUser{"Eva", 200}, // invalid age
+	))
+
+	r.Refs = []registry.Dynamic{
+		createDynamic(up, validRegistry, "test.ValidFeed", &feed),
+	}
+
+	if err = sc.Save(up, r); err == nil {
+		t.Fatal("missing error")
+	}
+
+	var ioe, ok = err.(*InvalidObjectError)
+
+	if ok == false {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if _, ok = ioe.Err().(*registry.ConstraintError); ok == false {
+		t.Error("unexpected error:", ioe.Err())
+	}
+
+	// fix it
+
+	assertNil(t, feed.Users.SetValueByIndex(up, 1, ValidUser{"Eva", 20}))
+
+	r.Refs = []registry.Dynamic{
+		createDynamic(up, validRegistry, "test.ValidFeed", &feed),
+	}
+
+	assertNil(t, sc.Save(up, r))
+
+}
+
+func TestFiller_Run_constraints(t *testing.T) {
+
+	var (
+		sc, rc = getTestContainer(), getTestContainer()
+		pk, sk = cipher.GenerateKeyPair()
+	)
+
+	defer sc.Close()
+	defer rc.Close()
+
+	assertNil(t, rc.AddFeed(pk))
+
+	// create the Root by hands, since the Save
+	// doesn't allow to save invalid objects
+
+	var up, err = sc.Unpack(sk, validRegistry)
+	assertNil(t, err)
+
+	var (
+		r    = new(registry.Root)
+		feed = ValidFeed{Head: "feed"}
+	)
+
+	assertNil(t, feed.Users.AppendValues(up,
+		ValidUser{"Alice", 19},
+		ValidUser{"Eva", 20},
+		ValidUser{"Ammy", 21}, // too many
+	))
+
+	r.Pub = pk
+	r.Nonce = 1
+	r.Reg = validRegistry.Reference()
+	r.Refs = []registry.Dynamic{
+		createDynamic(up, validRegistry, "test.ValidFeed", &feed),
+	}
+
+	_, err = sc.Set(cipher.SHA256(r.Reg), validRegistry.Encode(), 1)
+	assertNil(t, err)
+
+	r.Hash = cipher.SumSHA256(r.Encode())
+
+	var (
+		rq = make(chan cipher.SHA256, 10)
+		f  = rc.Fill(r, rq, 10)
+	)
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for key := range rq {
+			var val, _, err = sc.Get(key, 0)
+			assertNil(t, err)
+
+			_, err = rc.SetWanted(key, val)
+			assertNil(t, err)
+		}
+
+	}()
+
+	err = f.Run()
+
+	close(rq)
+	wg.Wait()
+
+	if err == nil {
+		t.Fatal("missing error")
+	}
+
+	if _, ok := err.(*InvalidObjectError); ok == false {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if _, err = rc.Root(r.Pub, r.Nonce, r.Seq); err == nil {
+		t.Error("invalid Root saved")
+	}
+
+	// the Refs is too long, and its elements are not requested
+
+	var el cipher.SHA256
+	if el, err = feed.Users.HashByIndex(up, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err = rc.Get(el, 0); err != data.ErrNotFound {
+		t.Error("element of invalid Refs received:", err)
+	}
+
+	// and the filling is not resumed
+
+	var fs []*Filling
+	if fs, err = rc.Fillings(pk); err != nil {
+		t.Fatal(err)
+	} else if len(fs) != 0 {
+		t.Error("filling of invalid Root is kept")
+	}
+
+}
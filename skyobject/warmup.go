@@ -0,0 +1,227 @@
+package skyobject
+
+import (
+	"sort"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+
+	"github.com/skycoin/cxo/data"
+	"github.com/skycoin/cxo/skyobject/registry"
+)
+
+// hot keys of the Cache kept across restarts
+var warmUpBucket = []byte("skyobject.warmup")
+
+// key of the list in the warmUpBucket
+var warmUpKey = []byte("hot")
+
+// a warmUp is list of hot objects and Registries
+// of the Cache saved on Close, to load them after
+type warmUp struct {
+	Keys       []cipher.SHA256
+	Registries []registry.RegistryRef
+}
+
+// hotKeys returns keys of up to n most valuable items
+// of the Cache; wanted and filling items are skipped
+func (c *Cache) hotKeys(n int) (keys []cipher.SHA256) {
+
+	if n <= 0 {
+		return
+	}
+
+	var rank []*rankItem
+
+	for _, s := range c.shards {
+		rank = s.rank(rank, cipher.SHA256{})
+	}
+
+	// the most valuable first; for 2Q frequent items
+	// are more valuable then new
+
+	sort.Slice(rank, func(i, j int) bool {
+		if rank[i].hot != rank[j].hot {
+			return rank[i].hot == true
+		}
+		return rank[i].points > rank[j].points
+	})
+
+	if len(rank) > n {
+		rank = rank[:n]
+	}
+
+	keys = make([]cipher.SHA256, 0, len(rank))
+
+	for _, ri := range rank {
+		keys = append(keys, ri.key)
+	}
+
+	return
+}
+
+// references of cached Registries
+func (c *Cache) registryRefs() (rrs []registry.RegistryRef) {
+
+	c.rmx.Lock()
+	defer c.rmx.Unlock()
+
+	for rr := range c.rs {
+		rrs = append(rrs, rr)
+	}
+
+	return
+}
+
+// saveWarmUp saves hot keys of the Cache (see
+// CacheWarmUp configuration); the Cache should
+// not be closed yet
+func (c *Cache) saveWarmUp() (err error) {
+
+	if c.enable == false || c.c.conf.CacheWarmUp <= 0 {
+		return
+	}
+
+	var wu warmUp
+
+	wu.Keys = c.hotKeys(c.c.conf.CacheWarmUp)
+	wu.Registries = c.registryRefs()
+
+	return c.c.db.IdxDB().BucketsTx(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(warmUpBucket); err != nil {
+			return
+		}
+
+		return bk.Set(warmUpKey, encoder.Serialize(&wu))
+
+	})
+
+}
+
+// loadWarmUp loads list of hot keys saved
+// by saveWarmUp; it returns empty list if
+// there is not a saved list
+func (c *Cache) loadWarmUp() (wu warmUp, err error) {
+
+	err = c.c.db.IdxDB().BucketsView(func(bs data.Buckets) (err error) {
+
+		var bk data.Bucket
+		if bk, err = bs.Bucket(warmUpBucket); err != nil {
+			return
+		}
+
+		var val []byte
+		switch val, err = bk.Get(warmUpKey); err {
+		case nil:
+		case data.ErrNotFound:
+			return nil // nothing saved
+		default:
+			return
+		}
+
+		_, err = encoder.DeserializeRaw(val, &wu)
+		return
+
+	})
+
+	return
+}
+
+// warmUp loads saved list of hot keys and starts
+// goroutine that prefetches the objects and the
+// Registries to the Cache in background
+func (c *Cache) warmUp() (err error) {
+
+	if c.enable == false || c.c.conf.CacheWarmUp <= 0 {
+		return
+	}
+
+	var wu warmUp
+	if wu, err = c.loadWarmUp(); err != nil {
+		return
+	}
+
+	if len(wu.Keys) > c.c.conf.CacheWarmUp {
+		wu.Keys = wu.Keys[:c.c.conf.CacheWarmUp] // budget
+	}
+
+	if len(wu.Keys) == 0 && len(wu.Registries) == 0 {
+		return
+	}
+
+	c.warmwg.Add(1)
+	go c.prefetch(wu)
+
+	return
+}
+
+// prefetch objects and Registries, the objects can be
+// removed meanwhile, thus, all errors are ignored
+func (c *Cache) prefetch(wu warmUp) {
+	defer c.warmwg.Done()
+
+	for _, rr := range wu.Registries {
+
+		select {
+		case <-c.warmq:
+			return
+		default:
+		}
+
+		c.Registry(rr) // ignore error
+
+	}
+
+	for _, key := range wu.Keys {
+
+		select {
+		case <-c.warmq:
+			return
+		default:
+		}
+
+		c.warmItem(key) // ignore error
+
+	}
+
+}
+
+// put object to the Cache if it's not cached yet and its
+// rc is not zero. Prefetching is not a DB get of the Cache,
+// thus, it's not counted in statistic. Objects with zero rc
+// are not cached, since it's garbage and cached objects are
+// not removed by cxoutils.RemoveObjects
+func (c *Cache) warmItem(key cipher.SHA256) (err error) {
+
+	var s = c.shard(key)
+
+	defer c.cleanAfter(key, &err) // after unlock
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if _, ok := s.is[key]; ok == true {
+		return // already cached
+	}
+
+	var (
+		val []byte
+		rc  uint32
+	)
+
+	if val, rc, err = s.db().Get(key, 0); err != nil || rc == 0 {
+		return // not found, DB failure or garbage
+	}
+
+	return s.putItem(key, val, int(rc))
+}
+
+// stop prefetching, if any
+func (c *Cache) stopWarmUp() {
+	c.closeo.Do(func() {
+		close(c.warmq)
+	})
+	c.warmwg.Wait()
+}
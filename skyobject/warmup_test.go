@@ -0,0 +1,121 @@
+package skyobject
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func getTestWarmUpContainer(
+	t *testing.T,
+	dir string,
+	warmUp int,
+) (
+	c *Container,
+) {
+
+	var conf = getTestConfig()
+
+	conf.InMemoryDB = false
+	conf.DataDir = dir
+	conf.CacheWarmUp = warmUp
+
+	var err error
+	if c, err = NewContainer(conf); err != nil {
+		t.Fatal(err)
+	}
+
+	return
+}
+
+func TestCache_warmUp(t *testing.T) {
+
+	var dir, err = ioutil.TempDir("", "cxowarmup")
+	assertNil(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		c          = getTestWarmUpContainer(t, dir, 10)
+		keys, vals = testCacheObjects("warm", 20)
+	)
+
+	testCacheSet(t, c, keys, vals)
+	assertNil(t, c.Close())
+
+	c = getTestWarmUpContainer(t, dir, 10)
+
+	var wu warmUp
+	wu, err = c.Cache.loadWarmUp()
+	assertNil(t, err)
+
+	if len(wu.Keys) != 10 {
+		t.Fatal("wrong number of hot keys", len(wu.Keys))
+	}
+
+	// wait the prefetching
+
+	for _, key := range wu.Keys {
+		for k := 0; c.IsCached(key) == false; k++ {
+			if k == 100 {
+				t.Fatal("not prefetched")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	var cached int
+
+	for _, key := range keys {
+		if c.IsCached(key) == true {
+			cached++
+		}
+	}
+
+	if cached != 10 {
+		t.Error("budget exceeded", cached)
+	}
+
+	assertNil(t, c.Close())
+
+	t.Run("garbage", func(t *testing.T) {
+
+		var c = getTestWarmUpContainer(t, dir, 0)
+		defer c.Close()
+
+		var key = keys[0]
+
+		if _, rc, err := c.Get(key, -1); err != nil {
+			t.Fatal(err)
+		} else if rc != 0 {
+			t.Fatal("wrong rc", rc)
+		}
+
+		assertNil(t, c.Cache.warmItem(key))
+
+		if c.IsCached(key) == true {
+			t.Error("object with zero rc prefetched")
+		}
+
+		assertNil(t, c.Cache.warmItem(keys[1]))
+
+		if c.IsCached(keys[1]) == false {
+			t.Error("not prefetched")
+		}
+
+	})
+
+	t.Run("off", func(t *testing.T) {
+
+		var c = getTestWarmUpContainer(t, dir, 0)
+		defer c.Close()
+
+		for _, key := range keys {
+			if c.IsCached(key) == true {
+				t.Fatal("prefetched")
+			}
+		}
+
+	})
+
+}